@@ -0,0 +1,115 @@
+// Package auth holds the authenticated caller of a request and the permission checks applied per route
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/response"
+)
+
+// Role grants access to a group of routes. Roles are ordered, a role includes every role below it.
+type Role string
+
+const (
+	RoleReader    Role = "reader"
+	RoleRateAdmin Role = "rate-admin"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRank = map[Role]int{
+	RoleReader:    1,
+	RoleRateAdmin: 2,
+	RoleAdmin:     3,
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// Includes reports whether r grants the access of required
+func (r Role) Includes(required Role) bool {
+	return r.Valid() && roleRank[r] >= roleRank[required]
+}
+
+// Permission is the access a route requires. API keys are granted a permission through
+// their role, bearer tokens through their scopes.
+type Permission struct {
+	Role  Role
+	Scope string
+}
+
+var (
+	ReadCurrencies   = Permission{Role: RoleReader, Scope: "currencies:read"}
+	WriteCurrencies  = Permission{Role: RoleRateAdmin, Scope: "currencies:write"}
+	ReadConversions  = Permission{Role: RoleReader, Scope: "conversions:read"}
+	WriteConversions = Permission{Role: RoleRateAdmin, Scope: "conversions:write"}
+	Convert          = Permission{Role: RoleReader, Scope: "convert"}
+	AuditConversions = Permission{Role: RoleAdmin, Scope: "convert:audit"}
+	ManageAPIKeys    = Permission{Role: RoleAdmin, Scope: "api-keys:manage"}
+	ManageQuotas     = Permission{Role: RoleAdmin, Scope: "quotas:manage"}
+	ManageWebhooks   = Permission{Role: RoleAdmin, Scope: "webhooks:manage"}
+)
+
+// Principal is the authenticated caller of a request. An empty TenantID is the global
+// tenant, which owns the shared currencies and rates.
+type Principal struct {
+	Subject  string
+	ClientID string
+	TenantID string
+	Role     Role
+	Scopes   []string
+}
+
+// Allows reports whether the caller is granted perm, by its role when it has one and by its scopes otherwise
+func (p *Principal) Allows(perm Permission) bool {
+	if p == nil {
+		return false
+	}
+	if p.Role != "" {
+		return p.Role.Includes(perm.Role)
+	}
+	for _, s := range p.Scopes {
+		if s == perm.Scope {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated caller
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// PrincipalFromContext returns the authenticated caller, return nil if the request is anonymous
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
+
+// Require wraps a route so it is only served to callers granted perm.
+// Anonymous callers get 401 and callers without the permission get 403.
+func Require(perm Permission, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		p := PrincipalFromContext(r.Context())
+		if p == nil {
+			w.Header().Add("WWW-Authenticate", `ApiKey realm="whim"`)
+			w.Header().Add("WWW-Authenticate", `Bearer realm="whim"`)
+			response.Write(w, response.BuildError([]error{response.UnauthorizedError}), response.UnauthorizedError.HTTPCode)
+			return
+		}
+
+		if !p.Allows(perm) {
+			response.Write(w, response.BuildError([]error{response.ForbiddenError}), response.ForbiddenError.HTTPCode)
+			return
+		}
+
+		next(w, r, ps)
+	}
+}
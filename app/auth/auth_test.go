@@ -0,0 +1,50 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequire(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		required       auth.Permission
+		expectedStatus int
+	}{
+		{"anonymous", nil, auth.ReadCurrencies, http.StatusUnauthorized},
+		{"reader reads", &auth.Principal{Role: auth.RoleReader}, auth.ReadCurrencies, http.StatusOK},
+		{"reader changes rates", &auth.Principal{Role: auth.RoleReader}, auth.WriteCurrencies, http.StatusForbidden},
+		{"rate admin changes rates", &auth.Principal{Role: auth.RoleRateAdmin}, auth.WriteCurrencies, http.StatusOK},
+		{"rate admin manages keys", &auth.Principal{Role: auth.RoleRateAdmin}, auth.ManageAPIKeys, http.StatusForbidden},
+		{"admin manages keys", &auth.Principal{Role: auth.RoleAdmin}, auth.ManageAPIKeys, http.StatusOK},
+		{"unknown role", &auth.Principal{Role: "root"}, auth.ReadCurrencies, http.StatusForbidden},
+		{"scope reads currencies", &auth.Principal{Scopes: []string{"currencies:read"}}, auth.ReadCurrencies, http.StatusOK},
+		{"scope reads conversions", &auth.Principal{Scopes: []string{"currencies:read"}}, auth.ReadConversions, http.StatusForbidden},
+		{"scope changes conversions", &auth.Principal{Scopes: []string{"conversions:read", "conversions:write"}}, auth.WriteConversions, http.StatusOK},
+		{"scope converts", &auth.Principal{Scopes: []string{"convert"}}, auth.Convert, http.StatusOK},
+		{"no scopes", &auth.Principal{}, auth.Convert, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost/", nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+
+			recorder := httptest.NewRecorder()
+			auth.Require(tt.required, ok)(recorder, req, nil)
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+		})
+	}
+}
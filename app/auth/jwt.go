@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTVerifier validates bearer tokens signed with RS256 or ES256 by a key of the JWKS
+type JWTVerifier struct {
+	Keys     *JWKS
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// NewJWTVerifier returns a verifier of the tokens signed by keys. It warns when issuer or
+// audience is empty, the tokens minted for any other service are then accepted.
+func NewJWTVerifier(keys *JWKS, issuer, audience string, leeway time.Duration) *JWTVerifier {
+	if issuer == "" {
+		slog.Warn("jwt issuer is not set, tokens of any issuer are accepted")
+	}
+	if audience == "" {
+		slog.Warn("jwt audience is not set, tokens of any audience are accepted")
+	}
+	return &JWTVerifier{Keys: keys, Issuer: issuer, Audience: audience, Leeway: leeway}
+}
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope    string   `json:"scope"`
+	Scp      []string `json:"scp"`
+	ClientID string   `json:"client_id"`
+	Azp      string   `json:"azp"`
+	TenantID string   `json:"tenant_id"`
+}
+
+// Verify checks the signature, issuer, audience and expiry of token and returns its caller.
+// The issuer and audience are only checked when they are set, the configuration requires
+// both with a JWKS.
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.Leeway),
+	}
+	if v.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.Issuer))
+	}
+	if v.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.Audience))
+	}
+	parser := jwt.NewParser(opts...)
+
+	claims := tokenClaims{}
+	_, err := parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token has no key id")
+		}
+		return v.Keys.Key(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := strings.Fields(claims.Scope)
+	scopes = append(scopes, claims.Scp...)
+
+	clientID := claims.ClientID
+	if clientID == "" {
+		clientID = claims.Azp
+	}
+	if clientID == "" {
+		clientID = claims.Subject
+	}
+
+	return &Principal{
+		Subject:  claims.Subject,
+		ClientID: clientID,
+		TenantID: claims.TenantID,
+		Scopes:   scopes,
+	}, nil
+}
@@ -0,0 +1,199 @@
+package auth_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func b64(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(key.N), "e": b64(big.NewInt(int64(key.E)))}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(key.X), "y": b64(key.Y)}
+}
+
+func jwksBody(t *testing.T, keys ...map[string]string) []byte {
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	return b
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   "https://id.example.com",
+		"aud":   "whim",
+		"sub":   "svc-pricing",
+		"azp":   "pricing",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"scope": "currencies:read convert",
+	}
+}
+
+func TestJWTVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, ioutil.WriteFile(path, jwksBody(t, rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey)), 0600))
+
+	keys, err := auth.NewJWKS(path, time.Hour)
+	require.NoError(t, err)
+	verifier := &auth.JWTVerifier{Keys: keys, Issuer: "https://id.example.com", Audience: "whim"}
+
+	claims := func(set func(jwt.MapClaims)) jwt.MapClaims {
+		c := validClaims()
+		set(c)
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()), true},
+		{"ES256", sign(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims()), true},
+		{"wrong key", sign(t, jwt.SigningMethodRS256, "rsa-1", otherKey, validClaims()), false},
+		{"unknown key id", sign(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, validClaims()), false},
+		{"HS256", sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), validClaims()), false},
+		{"wrong issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" })), false},
+		{"wrong audience", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { c["aud"] = "billing" })), false},
+		{"no issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { delete(c, "iss") })), false},
+		{"no audience", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { delete(c, "aud") })), false},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() })), false},
+		{"no expiry", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { delete(c, "exp") })), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := verifier.Verify(tt.token)
+			if !tt.valid {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, &auth.Principal{Subject: "svc-pricing", ClientID: "pricing", Scopes: []string{"currencies:read", "convert"}}, p)
+		})
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	body := jwksBody(t, rsaJWK("2024-01", oldKey))
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	keys, err := auth.NewJWKS(srv.URL, time.Hour)
+	require.NoError(t, err)
+	verifier := &auth.JWTVerifier{Keys: keys, Issuer: "https://id.example.com", Audience: "whim"}
+
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodRS256, "2024-01", oldKey, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	// the identity provider rotates to a new key, the unknown key id reloads the set
+	body = jwksBody(t, ecJWK("2024-02", newKey))
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodES256, "2024-02", newKey, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodRS256, "2024-01", oldKey, validClaims()))
+	assert.Error(t, err, "retired key is no longer accepted")
+
+	// unknown key ids do not reload the set again until the minimum interval has passed
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodES256, "forged", newKey, validClaims()))
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestJWKSSkipsUnusableKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	encryption := rsaJWK("enc", rsaKey)
+	encryption["use"] = "enc"
+	source := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, ioutil.WriteFile(source, jwksBody(t,
+		map[string]string{"kty": "EC", "kid": "p384", "crv": "P-384", "x": b64(p384.X), "y": b64(p384.Y)},
+		map[string]string{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+		encryption,
+		rsaJWK("sig", rsaKey),
+	), 0o600))
+
+	keys, err := auth.NewJWKS(source, time.Hour)
+	require.NoError(t, err, "unusable keys do not fail the set")
+
+	verifier := auth.NewJWTVerifier(keys, "https://id.example.com", "whim", 0)
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodRS256, "sig", rsaKey, validClaims()))
+	assert.NoError(t, err)
+
+	for _, kid := range []string{"p384", "hmac", "enc"} {
+		_, err := keys.Key(kid)
+		assert.Error(t, err, kid)
+	}
+	assert.Contains(t, logs.String(), `msg="skipping jwks key" kid=p384`)
+	assert.Contains(t, logs.String(), `msg="skipping jwks key" kid=hmac`)
+}
+
+func TestNewJWTVerifierWarns(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	auth.NewJWTVerifier(nil, "https://id.example.com", "whim", 0)
+	assert.Empty(t, logs.String())
+
+	auth.NewJWTVerifier(nil, "", "", 0)
+	assert.Contains(t, logs.String(), "jwt issuer is not set")
+	assert.Contains(t, logs.String(), "jwt audience is not set")
+}
@@ -0,0 +1,17 @@
+// Package cache holds the caches the repositories can keep their results in: an in-process
+// LRU and a client of a Redis compatible server shared by the instances of the service
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores values by key for a while. A value set with a zero ttl is kept until it is
+// deleted or evicted.
+type Cache interface {
+	// Get returns the value of key, false when it is missing or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,59 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.TODO()
+	c := cache.NewLRU(2)
+
+	assert.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	assert.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	assert.NoError(t, c.Set(ctx, "c", []byte("3"), 0))
+	assert.Equal(t, 2, c.Len())
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok, "b is the least recently used")
+	v, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+}
+
+func TestLRUExpires(t *testing.T) {
+	ctx := context.TODO()
+	c := cache.NewLRU(10)
+
+	assert.NoError(t, c.Set(ctx, "short", []byte("1"), 20*time.Millisecond))
+	assert.NoError(t, c.Set(ctx, "kept", []byte("2"), 0))
+	time.Sleep(30 * time.Millisecond)
+
+	_, ok, _ := c.Get(ctx, "short")
+	assert.False(t, ok, "the value expired")
+	_, ok, _ = c.Get(ctx, "kept")
+	assert.True(t, ok, "a value without ttl does not expire")
+	assert.Equal(t, 1, c.Len(), "the expired value is evicted")
+}
+
+func TestLRUDelete(t *testing.T) {
+	ctx := context.TODO()
+	c := cache.NewLRU(10)
+
+	v := []byte("1")
+	assert.NoError(t, c.Set(ctx, "a", v, 0))
+	v[0] = '2'
+	got, _, _ := c.Get(ctx, "a")
+	assert.Equal(t, []byte("1"), got, "the cache keeps a copy of the value")
+
+	assert.NoError(t, c.Delete(ctx, "a", "missing"))
+	_, ok, _ := c.Get(ctx, "a")
+	assert.False(t, ok)
+}
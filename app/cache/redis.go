@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a cache kept by a Redis compatible server, so it is shared by the instances of the
+// service
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis returns a cache kept in the database db of the server at addr, authenticated with
+// password unless it is empty. Up to poolSize connections are kept, and each command times out
+// after timeout.
+func NewRedis(addr, password string, db int, timeout time.Duration, poolSize int) *Redis {
+	return &Redis{
+		client: redis.NewClient(&redis.Options{
+			Addr:         addr,
+			Password:     password,
+			DB:           db,
+			DialTimeout:  timeout,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+			PoolSize:     poolSize,
+		}),
+	}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// Ping checks the server answers
+func (c *Redis) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// Close closes the connections
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,71 @@
+package cache_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rbpermadi/whim_assignment/app/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedis(t *testing.T) {
+	s := miniredis.RunT(t)
+	s.RequireAuth("secret")
+	ctx := context.TODO()
+
+	c := cache.NewRedis(s.Addr(), "secret", 0, time.Second, 2)
+	defer c.Close()
+
+	assert.NoError(t, c.Ping(ctx))
+
+	_, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Set(ctx, "a", []byte("line\r\nbreak"), 1500*time.Millisecond))
+	v, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("line\r\nbreak"), v)
+	assert.Equal(t, 1500*time.Millisecond, s.TTL("a"))
+
+	s.FastForward(2 * time.Second)
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok, "the value expired")
+
+	assert.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	assert.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+	assert.Zero(t, s.TTL("a"), "a zero ttl keeps the value")
+	assert.NoError(t, c.Delete(ctx, "a", "b"))
+	assert.False(t, s.Exists("a") || s.Exists("b"))
+	assert.NoError(t, c.Delete(ctx))
+}
+
+func TestRedisWrongPassword(t *testing.T) {
+	s := miniredis.RunT(t)
+	s.RequireAuth("secret")
+
+	c := cache.NewRedis(s.Addr(), "other", 0, time.Second, 2)
+	defer c.Close()
+
+	_, _, err := c.Get(context.TODO(), "a")
+	assert.Error(t, err)
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	c := cache.NewRedis(addr, "", 0, 100*time.Millisecond, 2)
+	defer c.Close()
+
+	_, _, err = c.Get(context.TODO(), "a")
+	assert.Error(t, err)
+}
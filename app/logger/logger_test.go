@@ -0,0 +1,44 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/logger"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, slog.LevelInfo)
+
+	ctx := logger.NewContext(request.WithRequestID(context.TODO(), "req-1"))
+	logger.AddFields(ctx, slog.String("client_id", "spoofed"))
+	logger.AddFields(ctx, slog.String("client_id", "pricing"))
+
+	log.DebugContext(ctx, "skipped")
+	log.InfoContext(ctx, "converted", slog.Int("amount", 10))
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "converted", record["msg"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "pricing", record["client_id"])
+	assert.Equal(t, float64(10), record["amount"])
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]slog.Level{"": slog.LevelInfo, "DEBUG": slog.LevelDebug, "warn": slog.LevelWarn, "error": slog.LevelError} {
+		level, err := logger.ParseLevel(s)
+		assert.NoError(t, err)
+		assert.Equal(t, want, level)
+	}
+
+	_, err := logger.ParseLevel("verbose")
+	assert.Error(t, err)
+}
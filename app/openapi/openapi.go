@@ -0,0 +1,235 @@
+// Package openapi holds the OpenAPI 3 document of the API and validates requests against it
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Document is the part of an OpenAPI document used to validate requests
+type Document struct {
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components struct {
+		Schemas    map[string]*Schema    `yaml:"schemas"`
+		Parameters map[string]*Parameter `yaml:"parameters"`
+	} `yaml:"components"`
+}
+
+type PathItem struct {
+	Parameters []*Parameter `yaml:"parameters"`
+	Get        *Operation   `yaml:"get"`
+	Post       *Operation   `yaml:"post"`
+	Put        *Operation   `yaml:"put"`
+	Patch      *Operation   `yaml:"patch"`
+	Delete     *Operation   `yaml:"delete"`
+}
+
+// Operations returns the operations of the path by HTTP method
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := map[string]*Operation{}
+	for method, op := range map[string]*Operation{"GET": p.Get, "POST": p.Post, "PUT": p.Put, "PATCH": p.Patch, "DELETE": p.Delete} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+type Operation struct {
+	OperationID string       `yaml:"operationId"`
+	Parameters  []*Parameter `yaml:"parameters"`
+	RequestBody *RequestBody `yaml:"requestBody"`
+}
+
+type Parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+type Schema struct {
+	Ref              string             `yaml:"$ref"`
+	Type             string             `yaml:"type"`
+	Format           string             `yaml:"format"`
+	Properties       map[string]*Schema `yaml:"properties"`
+	Required         []string           `yaml:"required"`
+	Items            *Schema            `yaml:"items"`
+	Enum             []interface{}      `yaml:"enum"`
+	Minimum          *float64           `yaml:"minimum"`
+	ExclusiveMinimum bool               `yaml:"exclusiveMinimum"`
+	Nullable         bool               `yaml:"nullable"`
+	ReadOnly         bool               `yaml:"readOnly"`
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Document
+	loadErr  error
+)
+
+// Load returns the document of the API with its references resolved
+func Load() (*Document, error) {
+	loadOnce.Do(func() {
+		loaded, loadErr = parse(spec)
+	})
+	return loaded, loadErr
+}
+
+// JSON returns the document of the API as JSON
+func JSON() ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+func parse(b []byte) (*Document, error) {
+	var d Document
+	if err := yaml.Unmarshal(b, &d); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	r := resolver{doc: &d}
+	for name, s := range d.Components.Schemas {
+		d.Components.Schemas[name] = r.schema(s)
+	}
+	for _, p := range d.Components.Parameters {
+		p.Schema = r.schema(p.Schema)
+	}
+	for _, item := range d.Paths {
+		r.parameters(item.Parameters)
+		for _, op := range item.Operations() {
+			r.parameters(op.Parameters)
+			if op.RequestBody != nil {
+				for _, mt := range op.RequestBody.Content {
+					mt.Schema = r.schema(mt.Schema)
+				}
+			}
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &d, nil
+}
+
+// resolver replaces the references of the document by what they point to
+type resolver struct {
+	doc *Document
+	err error
+}
+
+func (r *resolver) schema(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		target, ok := r.doc.Components.Schemas[name]
+		if !ok {
+			r.err = fmt.Errorf("openapi: unknown schema %s", s.Ref)
+			return s
+		}
+		if target.Ref != "" {
+			return r.schema(target)
+		}
+		return target
+	}
+	for name, p := range s.Properties {
+		s.Properties[name] = r.schema(p)
+	}
+	s.Items = r.schema(s.Items)
+	return s
+}
+
+func (r *resolver) parameters(params []*Parameter) {
+	for i, p := range params {
+		if p.Ref == "" {
+			p.Schema = r.schema(p.Schema)
+			continue
+		}
+		name := strings.TrimPrefix(p.Ref, "#/components/parameters/")
+		target, ok := r.doc.Components.Parameters[name]
+		if !ok {
+			r.err = fmt.Errorf("openapi: unknown parameter %s", p.Ref)
+			continue
+		}
+		params[i] = target
+	}
+}
+
+// Find returns the path template and operation matching method and path, with the values
+// of the path parameters. Static segments are preferred to parameters.
+func (d *Document) Find(method, path string) (string, *Operation, map[string]string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	best, bestScore := "", -1
+	var params map[string]string
+	for template, item := range d.Paths {
+		if item.Operations()[method] == nil {
+			continue
+		}
+		pattern := strings.Split(strings.Trim(template, "/"), "/")
+		if len(pattern) != len(segments) {
+			continue
+		}
+
+		score, values := 0, map[string]string{}
+		for i, segment := range pattern {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				values[strings.Trim(segment, "{}")] = segments[i]
+				continue
+			}
+			if segment != segments[i] {
+				score = -1
+				break
+			}
+			score++
+		}
+		if score > bestScore {
+			best, bestScore, params = template, score, values
+		}
+	}
+
+	if best == "" {
+		return "", nil, nil
+	}
+	return best, d.Paths[best].Operations()[method], params
+}
+
+// Parameters returns the parameters of the operation at template, including the ones
+// shared by the path
+func (d *Document) Parameters(template string, op *Operation) []*Parameter {
+	return append(append([]*Parameter{}, d.Paths[template].Parameters...), op.Parameters...)
+}
+
+// Path converts an httprouter route such as /v1/quotes/:id to an OpenAPI path template
+func Path(route string) string {
+	segments := strings.Split(route, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
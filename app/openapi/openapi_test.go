@@ -0,0 +1,209 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// internalEntities are not exposed by the API
+var internalEntities = map[string]bool{
+	"IdempotencyKey": true,
+	"DomainEvent":    true,
+}
+
+// registeredRoutes finds the routes registered in the non test files of dirs, as
+// r.GET("/path", ...) or router.HandlerFunc("GET", "/path", ...)
+func registeredRoutes(t *testing.T, dirs ...string) map[string]bool {
+	methods := map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+	routes := map[string]bool{}
+
+	for _, dir := range dirs {
+		pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi fs.FileInfo) bool {
+			return !strings.HasSuffix(fi.Name(), "_test.go")
+		}, 0)
+		require.NoError(t, err)
+
+		for _, pkg := range pkgs {
+			ast.Inspect(pkg, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+
+				var args []string
+				for _, arg := range call.Args {
+					if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						s, _ := strconv.Unquote(lit.Value)
+						args = append(args, s)
+					}
+				}
+
+				switch {
+				case methods[sel.Sel.Name] && len(args) >= 1 && strings.HasPrefix(args[0], "/"):
+					routes[sel.Sel.Name+" "+openapi.Path(args[0])] = true
+				case (sel.Sel.Name == "Handler" || sel.Sel.Name == "HandlerFunc") && len(args) >= 2 && methods[args[0]]:
+					routes[args[0]+" "+openapi.Path(args[1])] = true
+				}
+				return true
+			})
+		}
+	}
+	return routes
+}
+
+func TestDocumentCoversRoutes(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	routes := registeredRoutes(t, "../../delivery", "../../handler")
+	require.NotEmpty(t, routes)
+
+	documented := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for route := range routes {
+		assert.True(t, documented[route], "route %s is missing from openapi.yaml", route)
+	}
+	for route := range documented {
+		assert.True(t, routes[route], "openapi.yaml documents %s, which is not registered", route)
+	}
+}
+
+func TestDocumentCoversEntities(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	pkgs, err := parser.ParseDir(token.NewFileSet(), "../../entity", nil, 0)
+	require.NoError(t, err)
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok || internalEntities[ts.Name.Name] {
+						continue
+					}
+
+					schema, ok := doc.Components.Schemas[ts.Name.Name]
+					if !assert.True(t, ok, "entity %s is missing from openapi.yaml", ts.Name.Name) {
+						continue
+					}
+
+					fields := map[string]bool{}
+					for _, f := range st.Fields.List {
+						if f.Tag == nil {
+							continue
+						}
+						tag, _ := strconv.Unquote(f.Tag.Value)
+						name := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+						if name == "" || name == "-" {
+							continue
+						}
+						fields[name] = true
+						assert.Contains(t, schema.Properties, name, "field %s of entity %s is missing from openapi.yaml", name, ts.Name.Name)
+					}
+					for name := range schema.Properties {
+						assert.True(t, fields[name], "openapi.yaml documents %s.%s, which entity %s does not have", ts.Name.Name, name, ts.Name.Name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	b, err := openapi.JSON()
+	require.NoError(t, err)
+
+	var doc struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(b, &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Contains(t, doc.Paths, "/v1/currencies/{id}")
+}
+
+func TestFind(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	template, op, params := doc.Find("POST", "/v1/quotes/12/execute")
+	require.NotNil(t, op)
+	assert.Equal(t, "/v1/quotes/{id}/execute", template)
+	assert.Equal(t, "executeQuote", op.OperationID)
+	assert.Equal(t, map[string]string{"id": "12"}, params)
+
+	_, op, _ = doc.Find("GET", "/v1/quota")
+	require.NotNil(t, op)
+	assert.Equal(t, "getOwnConversionQuota", op.OperationID)
+
+	_, op, _ = doc.Find("DELETE", "/v1/currencies/1")
+	assert.Nil(t, op)
+}
+
+func TestValidateBody(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	schemas := doc.Components.Schemas
+
+	tests := []struct {
+		name    string
+		schema  string
+		body    string
+		patch   bool
+		wantErr string
+	}{
+		{name: "valid", schema: "Conversion", body: `{"currency_id_from":1,"currency_id_to":2,"rate":1.5}`},
+		{name: "read-only fields are not required", schema: "Currency", body: `{"name":"IDR"}`},
+		{name: "missing field", schema: "Conversion", body: `{"currency_id_from":1,"rate":1.5}`, wantErr: "currency_id_to is required"},
+		{name: "wrong type", schema: "Conversion", body: `{"currency_id_from":"1","currency_id_to":2,"rate":1.5}`, wantErr: "currency_id_from must be an integer"},
+		{name: "not an integer", schema: "Conversion", body: `{"currency_id_from":1.5,"currency_id_to":2,"rate":1.5}`, wantErr: "currency_id_from must be an integer"},
+		{name: "exclusive minimum", schema: "Quote", body: `{"currency_id_from":1,"currency_id_to":2,"amount":0}`, wantErr: "amount must be greater than 0"},
+		{name: "enum", schema: "APIKey", body: `{"name":"pricing","role":"root"}`, wantErr: "role must be one of reader, rate-admin, admin"},
+		{name: "null", schema: "Currency", body: `{"name":null}`, wantErr: "name cannot be null"},
+		{name: "not an object", schema: "Currency", body: `["IDR"]`, wantErr: "body must be an object"},
+		{name: "invalid JSON", schema: "Currency", body: `{"name":`, wantErr: "body must be valid JSON"},
+		{name: "merge patch", schema: "Conversion", body: `{"rate":2}`, patch: true},
+		{name: "merge patch removal", schema: "Currency", body: `{"name":null}`, patch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schemas[tt.schema].ValidateBody([]byte(tt.body), tt.patch)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
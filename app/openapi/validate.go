@@ -0,0 +1,151 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateParameter checks the raw value of a path, query or header parameter
+func ValidateParameter(p *Parameter, raw string) error {
+	if p.Schema == nil {
+		return nil
+	}
+
+	var v interface{} = raw
+	switch p.Schema.Type {
+	case "integer", "number":
+		v = json.Number(raw)
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a boolean", p.Name)
+		}
+		v = b
+	}
+	return p.Schema.validate(v, p.Name, false)
+}
+
+// ValidateBody checks a JSON body. Read-only properties are not required in requests, and
+// no property is required in merge patches, which only hold the properties being changed.
+func (s *Schema) ValidateBody(body []byte, patch bool) error {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(string(body)))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("body must be valid JSON")
+	}
+	return s.validate(v, "body", patch)
+}
+
+func (s *Schema) validate(v interface{}, at string, patch bool) error {
+	if v == nil {
+		if s.Nullable || patch {
+			return nil
+		}
+		return fmt.Errorf("%s cannot be null", at)
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object", at)
+		}
+		if !patch {
+			for _, name := range s.Required {
+				if p := s.Properties[name]; p != nil && p.ReadOnly {
+					continue
+				}
+				if _, ok := obj[name]; !ok {
+					return fmt.Errorf("%s is required", join(at, name))
+				}
+			}
+		}
+
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if p := s.Properties[name]; p != nil {
+				if err := p.validate(obj[name], join(at, name), patch); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an array", at)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", at, i), patch); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", at)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return fmt.Errorf("%s must be an RFC 3339 date-time", at)
+			}
+		}
+	case "integer", "number":
+		kind := "a number"
+		if s.Type == "integer" {
+			kind = "an integer"
+		}
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s must be %s", at, kind)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%s must be %s", at, kind)
+		}
+		if _, err := n.Int64(); s.Type == "integer" && err != nil {
+			return fmt.Errorf("%s must be %s", at, kind)
+		}
+		if s.Minimum != nil {
+			if s.ExclusiveMinimum && f <= *s.Minimum {
+				return fmt.Errorf("%s must be greater than %g", at, *s.Minimum)
+			}
+			if f < *s.Minimum {
+				return fmt.Errorf("%s must be at least %g", at, *s.Minimum)
+			}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", at)
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				return nil
+			}
+			allowed[i] = fmt.Sprint(e)
+		}
+		return fmt.Errorf("%s must be one of %s", at, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+func join(at, name string) string {
+	if at == "body" {
+		return name
+	}
+	return at + "." + name
+}
@@ -0,0 +1,83 @@
+package request
+
+import (
+	"context"
+)
+
+type contextKey string
+
+const (
+	clientIDKey       contextKey = "client_id"
+	idempotencyKeyKey contextKey = "idempotency_key"
+	tenantIDKey       contextKey = "tenant_id"
+	requestIDKey      contextKey = "request_id"
+	readPrimaryKey    contextKey = "read_primary"
+	routeKey          contextKey = "route"
+)
+
+// WithClientID returns a copy of ctx carrying the id of the calling client
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, clientIDKey, clientID)
+}
+
+// ClientID returns the id of the calling client, return empty string if not set
+func ClientID(ctx context.Context) string {
+	v, _ := ctx.Value(clientIDKey).(string)
+	return v
+}
+
+// WithIdempotencyKey returns a copy of ctx carrying the idempotency key of the request
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey, key)
+}
+
+// IdempotencyKey returns the idempotency key of the request, return empty string if not set
+func IdempotencyKey(ctx context.Context) string {
+	v, _ := ctx.Value(idempotencyKeyKey).(string)
+	return v
+}
+
+// WithTenantID returns a copy of ctx carrying the tenant of the authenticated caller
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, tenantID)
+}
+
+// TenantID returns the tenant of the authenticated caller, return empty string for the global tenant
+func TenantID(ctx context.Context) string {
+	v, _ := ctx.Value(tenantIDKey).(string)
+	return v
+}
+
+// WithRequestID returns a copy of ctx carrying the id used to correlate the logs of a request
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the id of the request, return empty string if not set
+func RequestID(ctx context.Context) string {
+	v, _ := ctx.Value(requestIDKey).(string)
+	return v
+}
+
+// WithReadPrimary returns a copy of ctx whose reads go to the primary database, so they see
+// the changes just made instead of a replica lagging behind
+func WithReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readPrimaryKey, true)
+}
+
+// ReadPrimary reports whether the reads of ctx must go to the primary database
+func ReadPrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(readPrimaryKey).(bool)
+	return v
+}
+
+// WithRoute returns a copy of ctx carrying the route pattern matching the request, like /v1/currencies/:id
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey, route)
+}
+
+// Route returns the route pattern matching the request, return empty string when no route matches
+func Route(ctx context.Context) string {
+	v, _ := ctx.Value(routeKey).(string)
+	return v
+}
@@ -0,0 +1,61 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// PatchField describes how a member of a JSON Merge Patch document is handled
+type PatchField int
+
+const (
+	// PatchImmutable fields are part of the resource but cannot be changed
+	PatchImmutable PatchField = iota
+	// PatchRequired fields can be changed but not cleared with null
+	PatchRequired
+	// PatchNullable fields can be changed or cleared with null
+	PatchNullable
+)
+
+// PatchSchema maps JSON field names of a resource to how they can be patched
+type PatchSchema map[string]PatchField
+
+// DecodeMergePatch reads a JSON Merge Patch (RFC 7396) document and validates its members
+// against schema. Members not in schema and immutable members are rejected, and so are
+// nulls for fields that cannot be cleared. Omitted fields are simply absent from the result.
+func DecodeMergePatch(body io.Reader, schema PatchSchema) (map[string]json.RawMessage, error) {
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&patch); err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return nil, fmt.Errorf("Bad Request: merge patch must be a JSON object")
+	}
+
+	fields := make([]string, 0, len(patch))
+	for field := range patch {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		rule, ok := schema[field]
+		if !ok {
+			return nil, fmt.Errorf("Bad Request: unknown field %q", field)
+		}
+
+		switch rule {
+		case PatchImmutable:
+			return nil, fmt.Errorf("Bad Request: field %q is immutable", field)
+		case PatchRequired:
+			if bytes.Equal(bytes.TrimSpace(patch[field]), []byte("null")) {
+				return nil, fmt.Errorf("%s cannot be null", field)
+			}
+		}
+	}
+
+	return patch, nil
+}
@@ -0,0 +1,78 @@
+package request_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeMergePatch(t *testing.T) {
+	schema := request.PatchSchema{
+		"id":          request.PatchImmutable,
+		"name":        request.PatchRequired,
+		"description": request.PatchNullable,
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantFields []string
+		wantErr    string
+	}{
+		{
+			name:       "empty patch",
+			body:       `{}`,
+			wantFields: []string{},
+		},
+		{
+			name:       "change and clear",
+			body:       `{"name":"IDR","description":null}`,
+			wantFields: []string{"name", "description"},
+		},
+		{
+			name:    "null required field",
+			body:    `{"name":null}`,
+			wantErr: "name cannot be null",
+		},
+		{
+			name:    "immutable field",
+			body:    `{"id":2}`,
+			wantErr: `field "id" is immutable`,
+		},
+		{
+			name:    "unknown field",
+			body:    `{"nmae":"IDR"}`,
+			wantErr: `unknown field "nmae"`,
+		},
+		{
+			name:    "not an object",
+			body:    `null`,
+			wantErr: "must be a JSON object",
+		},
+		{
+			name:    "invalid json",
+			body:    `{"name":`,
+			wantErr: "unexpected EOF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := request.DecodeMergePatch(strings.NewReader(tt.body), schema)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, patch, len(tt.wantFields))
+			for _, field := range tt.wantFields {
+				assert.Contains(t, patch, field)
+			}
+		})
+	}
+}
@@ -1,9 +1,27 @@
 package request
 
+import (
+	"time"
+)
+
+// MaxLimit is the most items a page of a list holds
+const MaxLimit = 100
+
+// Page bounds the limit and offset of a list page, whichever API they come from: a limit
+// below 1 is the default of 10 items, one above MaxLimit is MaxLimit, a negative offset is 0
+func Page(limit, offset int) (int, int) {
+	if limit < 1 {
+		limit = 10
+	}
+	return min(limit, MaxLimit), max(offset, 0)
+}
+
+// CurrencyParameter filters a list of currencies, by IDs when IDs is not empty
 type CurrencyParameter struct {
 	Limit  int
 	Offset int
 	Query  string
+	IDs    []int64
 }
 
 type ConversionParameter struct {
@@ -12,3 +30,38 @@ type ConversionParameter struct {
 	CurrencyIDFrom int64
 	CurrencyIDTo   int64
 }
+
+type ConvertCurrenciesParameter struct {
+	Limit          int
+	Offset         int
+	CurrencyIDFrom int64
+	CurrencyIDTo   int64
+	ClientID       string
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+}
+
+type APIKeyParameter struct {
+	Limit          int
+	Offset         int
+	IncludeRevoked bool
+}
+
+type WebhookParameter struct {
+	Limit  int
+	Offset int
+}
+
+// WebhookDeliveryParameter filters the delivery log of a subscription, by status when Status is not empty
+type WebhookDeliveryParameter struct {
+	Limit          int
+	Offset         int
+	SubscriptionID int64
+	Status         string
+}
+
+// RateMatrixParameter lists the currencies of a rate matrix by name or id, every currency when Currencies is empty
+type RateMatrixParameter struct {
+	Base       string
+	Currencies []string
+}
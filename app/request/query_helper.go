@@ -1,7 +1,6 @@
 package request
 
 import (
-	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -101,7 +100,6 @@ func (q *QueryHelper) GetDate(p string, vars ...time.Time) *time.Time {
 	if sv != "" {
 		v, err := time.Parse(time.RFC3339, sv)
 		if err != nil {
-			fmt.Println("=====>", err)
 			return defValue
 		}
 
@@ -111,6 +109,18 @@ func (q *QueryHelper) GetDate(p string, vars ...time.Time) *time.Time {
 	return defValue
 }
 
+// GetLimit returns the limit of a list page, 10 when the limit query url is missing, bounded like Page does
+func (q *QueryHelper) GetLimit() int {
+	limit, _ := Page(q.GetInt("limit", 10), 0)
+	return limit
+}
+
+// GetOffset returns the offset of a list page, 0 when the offset query url is missing or negative
+func (q *QueryHelper) GetOffset() int {
+	_, offset := Page(1, q.GetInt("offset", 0))
+	return offset
+}
+
 // NewQueryHelper is a function to create query helper struct
 func NewQueryHelper(r *http.Request) *QueryHelper {
 	return &QueryHelper{r, r.URL.Query()}
@@ -60,6 +60,69 @@ var (
 		HTTPCode: http.StatusUnprocessableEntity,
 	}
 
+	// GoneError represents resource that is no longer available
+	GoneError = CustomError{
+		Message:  "Gone",
+		Code:     10214,
+		HTTPCode: http.StatusGone,
+	}
+
+	// IdempotencyKeyReusedError represents Idempotency-Key sent again with a different request
+	IdempotencyKeyReusedError = CustomError{
+		Message:  "Idempotency-Key has already been used with a different request",
+		Code:     10215,
+		HTTPCode: http.StatusUnprocessableEntity,
+	}
+
+	// IdempotencyKeyInProgressError represents Idempotency-Key whose first request is still being processed
+	IdempotencyKeyInProgressError = CustomError{
+		Message:  "A request with this Idempotency-Key is still being processed",
+		Code:     10216,
+		HTTPCode: http.StatusConflict,
+	}
+
+	// IdempotencyKeyTooLongError represents Idempotency-Key longer than the keys that can be stored
+	IdempotencyKeyTooLongError = CustomError{
+		Message:  "Idempotency-Key must be at most 255 characters",
+		Code:     10218,
+		HTTPCode: http.StatusBadRequest,
+	}
+
+	// PreconditionFailedError represents If-Match header not matching the current version
+	PreconditionFailedError = CustomError{
+		Message:  "Precondition Failed",
+		Code:     10217,
+		HTTPCode: http.StatusPreconditionFailed,
+	}
+
+	// UnauthorizedError represents missing or invalid credentials
+	UnauthorizedError = CustomError{
+		Message:  "Unauthorized",
+		Code:     10401,
+		HTTPCode: http.StatusUnauthorized,
+	}
+
+	// ForbiddenError represents credentials without access to the requested route
+	ForbiddenError = CustomError{
+		Message:  "Forbidden",
+		Code:     10403,
+		HTTPCode: http.StatusForbidden,
+	}
+
+	// TooManyRequestsError represents a client exceeding its rate limit or quota
+	TooManyRequestsError = CustomError{
+		Message:  "Too Many Requests",
+		Code:     10429,
+		HTTPCode: http.StatusTooManyRequests,
+	}
+
+	// RequestEntityTooLargeError represents a request body over the size limit
+	RequestEntityTooLargeError = CustomError{
+		Message:  "Request Entity Too Large",
+		Code:     10413,
+		HTTPCode: http.StatusRequestEntityTooLarge,
+	}
+
 	//NotFoundError represents not found
 	NotFoundError = CustomError{
 		Message:  "Not Found",
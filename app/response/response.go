@@ -154,6 +154,28 @@ func BuildErrorAndStatus(err error, fieldName string) (ErrorBody, int) {
 		ce.Message = err.Error()
 
 		return BuildError([]error{ce}), NotFoundError.HTTPCode
+	} else if strings.Contains(err.Error(), "Conflict") {
+		ce := RecordConflictError
+		ce.Message = err.Error()
+
+		return BuildError([]error{ce}), RecordConflictError.HTTPCode
+	} else if strings.Contains(err.Error(), "Gone") {
+		ce := GoneError
+		ce.Message = err.Error()
+
+		return BuildError([]error{ce}), GoneError.HTTPCode
+	} else if strings.Contains(err.Error(), "Precondition Failed") {
+		ce := PreconditionFailedError
+		ce.Message = err.Error()
+
+		return BuildError([]error{ce}), PreconditionFailedError.HTTPCode
+	} else if strings.Contains(err.Error(), "Too Many Requests") {
+		ce := TooManyRequestsError
+		ce.Message = err.Error()
+
+		return BuildError([]error{ce}), TooManyRequestsError.HTTPCode
+	} else if strings.Contains(err.Error(), "request body too large") {
+		return BuildError([]error{RequestEntityTooLargeError}), RequestEntityTooLargeError.HTTPCode
 	} else if strings.Contains(err.Error(), "Bad Request") {
 		ce := BadRequestError
 		ce.Message = err.Error()
@@ -0,0 +1,246 @@
+// Package stream fans the changes of conversion rates out to the clients subscribed to
+// their currency pairs. The hub is in-process: a client only receives the changes made
+// through the instance it is connected to.
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+)
+
+var (
+	// ErrSlowConsumer ends a subscription whose buffer is full, so a slow client never
+	// holds up the publishers or the other clients
+	ErrSlowConsumer = errors.New("subscription dropped: events were not consumed fast enough")
+
+	// ErrClosed ends the subscriptions of a hub that has been closed
+	ErrClosed = errors.New("subscription closed: the server is shutting down")
+)
+
+// RateEvent is published when a conversion rate is created or updated
+type RateEvent struct {
+	Type           string    `json:"type"`
+	ConversionID   int64     `json:"conversion_id"`
+	TenantID       string    `json:"tenant_id"`
+	CurrencyIDFrom int64     `json:"currency_id_from"`
+	CurrencyIDTo   int64     `json:"currency_id_to"`
+	Rate           float64   `json:"rate"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+const (
+	RateCreated = "rate.created"
+	RateUpdated = "rate.updated"
+)
+
+// Publisher publishes rate events, the hub implements it
+type Publisher interface {
+	Publish(e RateEvent)
+}
+
+// Pair is a currency pair, as the currency_id_from and currency_id_to of a conversion
+type Pair struct {
+	From int64 `json:"currency_id_from"`
+	To   int64 `json:"currency_id_to"`
+}
+
+// ParsePairs parses a comma separated list of pairs such as "1-2,1-3"
+func ParsePairs(s string) ([]Pair, error) {
+	var pairs []Pair
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(item, "-")
+		f, errFrom := strconv.ParseInt(from, 10, 64)
+		t, errTo := strconv.ParseInt(to, 10, 64)
+		if !ok || errFrom != nil || errTo != nil || f <= 0 || t <= 0 {
+			return nil, fmt.Errorf("Bad Request: invalid currency pair %q, want <currency_id_from>-<currency_id_to>", item)
+		}
+		pairs = append(pairs, Pair{From: f, To: t})
+	}
+	return pairs, nil
+}
+
+// Hub delivers the published events to the subscriptions matching them
+type Hub struct {
+	buffer int
+
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub returns a hub whose subscriptions buffer up to buffer events
+func NewHub(buffer int) *Hub {
+	if buffer < 1 {
+		buffer = 1
+	}
+	return &Hub{buffer: buffer, subs: map[*Subscription]struct{}{}}
+}
+
+// Subscribe returns a subscription to the events of pairs visible to tenantID, the global
+// rates and the ones of the tenant. A subscription without pairs receives no event until
+// pairs are added.
+func (h *Hub) Subscribe(tenantID string, pairs ...Pair) *Subscription {
+	s := &Subscription{
+		hub:      h,
+		tenantID: tenantID,
+		pairs:    map[Pair]bool{},
+		events:   make(chan RateEvent, h.buffer),
+		done:     make(chan struct{}),
+	}
+	s.Add(pairs...)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		s.end(ErrClosed)
+		return s
+	}
+	h.subs[s] = struct{}{}
+	metrics.StreamSubscribers.Inc()
+	return s
+}
+
+// Publish delivers e without blocking. Subscriptions whose buffer is full are ended with
+// ErrSlowConsumer.
+func (h *Hub) Publish(e RateEvent) {
+	var slow []*Subscription
+
+	h.mu.RLock()
+	for s := range h.subs {
+		if !s.matches(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			slow = append(slow, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, s := range slow {
+		metrics.StreamSlowConsumers.Inc()
+		h.remove(s, ErrSlowConsumer)
+	}
+}
+
+// Close ends every subscription with ErrClosed, new subscriptions end immediately
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	subs := h.subs
+	h.subs = map[*Subscription]struct{}{}
+	h.mu.Unlock()
+
+	for s := range subs {
+		metrics.StreamSubscribers.Dec()
+		s.end(ErrClosed)
+	}
+}
+
+func (h *Hub) remove(s *Subscription, err error) {
+	h.mu.Lock()
+	_, ok := h.subs[s]
+	delete(h.subs, s)
+	h.mu.Unlock()
+
+	if ok {
+		metrics.StreamSubscribers.Dec()
+	}
+	s.end(err)
+}
+
+// Subscription receives the events of its pairs until it is closed
+type Subscription struct {
+	hub      *Hub
+	tenantID string
+
+	mu    sync.RWMutex
+	pairs map[Pair]bool
+
+	events chan RateEvent
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// Events returns the events of the subscription. It is never closed, wait on Done too.
+func (s *Subscription) Events() <-chan RateEvent {
+	return s.events
+}
+
+// Done is closed when the subscription ends, Err then tells why
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns ErrSlowConsumer or ErrClosed once the subscription has ended, and nil
+// when it has been closed by its owner
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Add subscribes to more pairs
+func (s *Subscription) Add(pairs ...Pair) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range pairs {
+		s.pairs[p] = true
+	}
+}
+
+// Remove unsubscribes from pairs
+func (s *Subscription) Remove(pairs ...Pair) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range pairs {
+		delete(s.pairs, p)
+	}
+}
+
+// Pairs returns the pairs of the subscription
+func (s *Subscription) Pairs() []Pair {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	pairs := make([]Pair, 0, len(s.pairs))
+	for p := range s.pairs {
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.hub.remove(s, nil)
+}
+
+func (s *Subscription) matches(e RateEvent) bool {
+	if e.TenantID != "" && e.TenantID != s.tenantID {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pairs[Pair{From: e.CurrencyIDFrom, To: e.CurrencyIDTo}]
+}
+
+func (s *Subscription) end(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
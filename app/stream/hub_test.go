@@ -0,0 +1,92 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePairs(t *testing.T) {
+	pairs, err := stream.ParsePairs("1-2, 3-1,")
+	require.NoError(t, err)
+	assert.Equal(t, []stream.Pair{{From: 1, To: 2}, {From: 3, To: 1}}, pairs)
+
+	for _, s := range []string{"1", "1-x", "0-2", "1-2-3"} {
+		_, err := stream.ParsePairs(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestHubDelivers(t *testing.T) {
+	hub := stream.NewHub(10)
+	acme := hub.Subscribe("acme", stream.Pair{From: 1, To: 2})
+	other := hub.Subscribe("other", stream.Pair{From: 1, To: 2}, stream.Pair{From: 2, To: 1})
+	defer acme.Close()
+	defer other.Close()
+
+	hub.Publish(stream.RateEvent{ConversionID: 1, CurrencyIDFrom: 1, CurrencyIDTo: 2})
+	hub.Publish(stream.RateEvent{ConversionID: 2, TenantID: "acme", CurrencyIDFrom: 1, CurrencyIDTo: 2})
+	hub.Publish(stream.RateEvent{ConversionID: 3, CurrencyIDFrom: 2, CurrencyIDTo: 1})
+
+	assert.Equal(t, []int64{1, 2}, drain(acme), "global rates and the rates of the tenant")
+	assert.Equal(t, []int64{1, 3}, drain(other))
+
+	acme.Remove(stream.Pair{From: 1, To: 2})
+	acme.Add(stream.Pair{From: 2, To: 1})
+	hub.Publish(stream.RateEvent{ConversionID: 4, CurrencyIDFrom: 1, CurrencyIDTo: 2})
+	hub.Publish(stream.RateEvent{ConversionID: 5, CurrencyIDFrom: 2, CurrencyIDTo: 1})
+	assert.Equal(t, []int64{5}, drain(acme))
+}
+
+func TestHubDropsSlowConsumers(t *testing.T) {
+	hub := stream.NewHub(2)
+	slow := hub.Subscribe("", stream.Pair{From: 1, To: 2})
+	fast := hub.Subscribe("", stream.Pair{From: 1, To: 2})
+	defer fast.Close()
+
+	for i := int64(1); i <= 3; i++ {
+		hub.Publish(stream.RateEvent{ConversionID: i, CurrencyIDFrom: 1, CurrencyIDTo: 2})
+		drain(fast)
+	}
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow subscription not dropped")
+	}
+	assert.Equal(t, stream.ErrSlowConsumer, slow.Err())
+	assert.NoError(t, fast.Err())
+
+	// the dropped subscription no longer receives events
+	drain(slow)
+	hub.Publish(stream.RateEvent{ConversionID: 4, CurrencyIDFrom: 1, CurrencyIDTo: 2})
+	assert.Empty(t, drain(slow))
+	assert.Equal(t, []int64{4}, drain(fast))
+}
+
+func TestHubClose(t *testing.T) {
+	hub := stream.NewHub(1)
+	sub := hub.Subscribe("", stream.Pair{From: 1, To: 2})
+
+	hub.Close()
+	<-sub.Done()
+	assert.Equal(t, stream.ErrClosed, sub.Err())
+
+	late := hub.Subscribe("", stream.Pair{From: 1, To: 2})
+	<-late.Done()
+	assert.Equal(t, stream.ErrClosed, late.Err())
+}
+
+func drain(s *stream.Subscription) []int64 {
+	var ids []int64
+	for {
+		select {
+		case e := <-s.Events():
+			ids = append(ids, e.ConversionID)
+		default:
+			return ids
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: `SELECT id FROM currencies WHERE id = 12 AND tenant_id IN ('', "acme")`,
+			want:  `SELECT id FROM currencies WHERE id = ? AND tenant_id IN (?, ?)`,
+		},
+		{
+			query: "INSERT INTO conversions (rate, name)\n\t\tVALUES (1.5e-3, \"it\\\"s\")",
+			want:  "INSERT INTO conversions (rate, name) VALUES (?, ?)",
+		},
+		{
+			query: "SELECT used FROM conversion_quota_usages WHERE client_id = ? AND period = ?",
+			want:  "SELECT used FROM conversion_quota_usages WHERE client_id = ? AND period = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tracing.SanitizeSQL(tt.query))
+	}
+}
+
+func TestEnd(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSyncer(exporter)))
+
+	_, span := tracing.Start(context.Background(), "failed")
+	tracing.End(span, errors.New("boom"))
+	_, span = tracing.Start(context.Background(), "not found")
+	tracing.End(span, errors.New("Not Found"))
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "boom", spans[0].Status.Description)
+	assert.Equal(t, codes.Unset, spans[1].Status.Code)
+}
+
+func TestSetup(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagator) })
+
+	shutdown, err := tracing.Setup(context.Background(), "none")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = tracing.Setup(context.Background(), "zipkin")
+	assert.EqualError(t, err, `unknown traces exporter "zipkin"`)
+}
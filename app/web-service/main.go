@@ -1,31 +1,136 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/subosito/gotenv"
-
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/cache"
+	"github.com/rbpermadi/whim_assignment/app/logger"
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/openapi"
+	"github.com/rbpermadi/whim_assignment/app/stream"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
 	"github.com/rbpermadi/whim_assignment/config"
 	"github.com/rbpermadi/whim_assignment/delivery"
+	"github.com/rbpermadi/whim_assignment/delivery/rpc"
 	"github.com/rbpermadi/whim_assignment/handler"
 	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/api_key"
 	"github.com/rbpermadi/whim_assignment/usecase/conversion"
+	"github.com/rbpermadi/whim_assignment/usecase/conversion_quota"
 	"github.com/rbpermadi/whim_assignment/usecase/convert_currencies"
 	"github.com/rbpermadi/whim_assignment/usecase/currency"
+	"github.com/rbpermadi/whim_assignment/usecase/outbox"
+	"github.com/rbpermadi/whim_assignment/usecase/quote"
+	"github.com/rbpermadi/whim_assignment/usecase/rate_matrix"
+	"github.com/rbpermadi/whim_assignment/usecase/webhook"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+const usage = `Usage: whim [-config file.yaml] [command]
+
+Commands:
+  serve          run the web service, the default
+  config print   print the configuration with secrets redacted
+`
+
 func main() {
-	gotenv.Load()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file, overridden by the environment")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+
+	switch args := flag.Args(); {
+	case len(args) == 0 || len(args) == 1 && args[0] == "serve":
+	case len(args) == 2 && args[0] == "config" && args[1] == "print":
+		cfg.Print(os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	level, _ := logger.ParseLevel(cfg.LogLevel)
+	slog.SetDefault(logger.New(os.Stdout, level))
+	for _, warning := range cfg.Warnings() {
+		slog.Warn(warning)
+	}
+
+	if err != nil {
+		fatal(fmt.Errorf("invalid configuration: %w", err))
+	}
 
-	db := config.NewMySQL()
+	if err := run(cfg); err != nil {
+		fatal(err)
+	}
+}
+
+// run serves the web service until shutdown. Its errors are returned rather than fatal, so
+// the deferred calls stop the relay and the dispatcher, close the database and flush the
+// traces before the process exits.
+func run(cfg *config.Config) error {
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	db, err := config.NewMySQL(cfg.Database)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
+	if err := metrics.RegisterDB(db, "whim"); err != nil {
+		return err
+	}
+
+	// read replicas, serving the lookups of currencies, conversions and conversions of amounts
+	replicas, err := config.NewMySQLReplicas(cfg.Database)
+	if err != nil {
+		return err
+	}
+	for i, replica := range replicas {
+		defer replica.Close()
+		if err := metrics.RegisterDB(replica, fmt.Sprintf("whim_replica_%d", i+1)); err != nil {
+			return err
+		}
+	}
+
+	// domain events, recorded in the outbox with each change and relayed to the publishers until shutdown
+	var publishers outbox.Publishers
+	switch cfg.Outbox.Publisher {
+	case "log":
+		publishers = append(publishers, &outbox.LogPublisher{})
+	case "file":
+		filePublisher, err := outbox.NewFilePublisher(cfg.Outbox.File)
+		if err != nil {
+			return err
+		}
+		defer filePublisher.Close()
+		publishers = append(publishers, filePublisher)
+	}
+
 	// currencies
-	currencyRepo := repository.NewMysqlCurrency(db)
+	currencyRepo := repository.NewInstrumentedCurrency(repository.NewMysqlCurrency(db, replicas...))
 
 	currencyUseCase := currency.NewService(&currency.Provider{
 		Repo: currencyRepo,
@@ -33,37 +138,320 @@ func main() {
 
 	currencyHandler := delivery.NewCurrencyHandler(currencyUseCase)
 
-	// conversions
-	conversionRepo := repository.NewMysqlConversion(db)
+	// webhooks, the deliveries of the rate events relayed from the outbox are sent by the dispatcher until shutdown
+	var webhookUseCase webhook.WebhookUsecase
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	dispatched := make(chan struct{})
+	if cfg.Features.Webhooks {
+		webhookRepo := repository.NewMysqlWebhook(db)
+		webhookUseCase = webhook.NewService(&webhook.Provider{
+			Repo: webhookRepo,
+		})
+		publishers = append(publishers, webhookUseCase)
+
+		wh := cfg.Webhook
+		dispatcher := webhook.NewDispatcher(webhookRepo, wh.MaxAttempts, wh.Backoff, wh.MaxBackoff, wh.Timeout, wh.BatchSize)
+		dispatcher.Retention = wh.Retention
+		go func() {
+			dispatcher.Run(dispatchCtx, wh.PollInterval)
+			close(dispatched)
+		}()
+	} else {
+		close(dispatched)
+	}
+	defer func() {
+		stopDispatch()
+		<-dispatched
+	}()
+
+	// the events are relayed even without publishers, so they are marked published and purged
+	// instead of piling up in the outbox
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayed := make(chan struct{})
+	relay := outbox.NewRelay(repository.NewMysqlOutbox(db), publishers, cfg.Outbox.BatchSize)
+	relay.Retention = cfg.Outbox.Retention
+	go func() {
+		relay.Run(relayCtx, cfg.Outbox.PollInterval)
+		close(relayed)
+	}()
+	defer func() {
+		stopRelay()
+		<-relayed
+	}()
+
+	// conversions, with their rate changes streamed to the subscribed clients and delivered to webhooks
+	conversionRepo := repository.NewInstrumentedConversion(repository.NewMysqlConversion(db, replicas...))
+	switch cfg.Cache.Backend {
+	case "memory":
+		conversionRepo = repository.NewCachedConversion(conversionRepo, cache.NewLRU(cfg.Cache.Size), cfg.Cache.TTL)
+	case "redis":
+		redis := cache.NewRedis(cfg.Cache.RedisAddr, cfg.Cache.RedisPassword, cfg.Cache.RedisDB, time.Second, 16)
+		defer redis.Close()
+		conversionRepo = repository.NewCachedConversion(conversionRepo, redis, cfg.Cache.TTL)
+	}
+
+	rateHub := stream.NewHub(cfg.Stream.Buffer)
 
 	conversionUseCase := conversion.NewService(&conversion.Provider{
 		Repo:         conversionRepo,
 		CurrencyRepo: currencyRepo,
+		Tx:           repository.NewMysqlTransactor(db),
+		Events:       rateHub,
 	})
 
 	conversionHandler := delivery.NewConversionHandler(conversionUseCase)
+	rateStreamHandler := delivery.NewRateStreamHandler(rateHub, cfg.Stream.Heartbeat)
+
+	rateMatrixUseCase := rate_matrix.NewService(&rate_matrix.Provider{
+		Repo:         conversionRepo,
+		CurrencyRepo: currencyRepo,
+		Tx:           repository.NewMysqlTransactor(db),
+	})
+	rateMatrixHandler := delivery.NewRateMatrixHandler(rateMatrixUseCase)
+
+	// quotas, conversions are unlimited when they are turned off
+	var quotaUseCase conversion_quota.ConversionQuotaUsecase
+	if cfg.Features.Quotas {
+		quotaUseCase = conversion_quota.NewService(&conversion_quota.Provider{
+			Repo:         repository.NewMysqlConversionQuota(db),
+			DefaultLimit: cfg.Conversion.MonthlyQuota,
+		})
+	}
 
 	// convert
+	convertCurrenciesRepo := repository.NewMysqlConvertCurrencies(db, replicas...)
+
 	convertCurrenciesUseCase := convert_currencies.NewService(&convert_currencies.Provider{
-		Repo: conversionRepo,
+		Repo:                  conversionRepo,
+		ConvertCurrenciesRepo: convertCurrenciesRepo,
+		Quota:                 quotaUseCase,
 	})
 
 	convertCurrenciesHandler := delivery.NewConvertCurrenciesHandler(convertCurrenciesUseCase)
 
-	h := handler.NewHandler(&currencyHandler, &conversionHandler, &convertCurrenciesHandler)
+	// api keys
+	apiKeyRepo := repository.NewMysqlAPIKey(db)
+
+	apiKeyUseCase := api_key.NewService(&api_key.Provider{
+		Repo: apiKeyRepo,
+	})
+
+	if key := cfg.Auth.BootstrapAdminAPIKey; key != "" {
+		if err := apiKeyUseCase.BootstrapAPIKey(context.Background(), key); err != nil {
+			return err
+		}
+	}
+
+	apiKeyHandler := delivery.NewAPIKeyHandler(apiKeyUseCase)
+
+	registrations := []handler.Registration{
+		handler.WithMiddleware(handler.LimitBody(cfg.Server.MaxBodyBytes)),
+	}
+
+	// requests that do not match the OpenAPI document are rejected before authentication
+	if cfg.Features.RequestValidation {
+		doc, err := openapi.Load()
+		if err != nil {
+			return err
+		}
+		registrations = append(registrations, handler.WithMiddleware(handler.ValidateRequests(doc)))
+	}
+
+	registrations = append(registrations, handler.WithMiddleware(handler.APIKeyAuth(apiKeyUseCase)))
+
+	// bearer tokens
+	var verifier *auth.JWTVerifier
+	if source := cfg.Auth.JWKS; source != "" {
+		keys, err := auth.NewJWKS(source, cfg.Auth.JWKSRefresh)
+		if err != nil {
+			return err
+		}
+
+		verifier = auth.NewJWTVerifier(keys, cfg.Auth.Issuer, cfg.Auth.Audience, cfg.Auth.Leeway)
+		registrations = append(registrations, handler.WithMiddleware(handler.BearerAuth(verifier)))
+	}
+
+	// rate limits, applied per API key or token once the caller is authenticated, the gRPC
+	// calls and the GraphQL convert mutation take their tokens from the same limiter
+	var limiter *handler.RateLimiter
+	if cfg.Features.RateLimit {
+		rl := cfg.RateLimit
+		var rules []handler.RateLimitRule
+		for _, route := range rl.Routes() {
+			rules = append(rules, handler.RateLimitRule{Method: route.Method, Path: route.Path, Rate: rl.ConvertRPS, Burst: rl.ConvertBurst})
+		}
+		limiter = handler.NewRateLimiter(append(rules, handler.RateLimitRule{Rate: rl.RPS, Burst: rl.Burst})...)
+		registrations = append(registrations, handler.WithMiddleware(handler.RateLimit(limiter)))
+	}
+
+	// the reads of a caller go to the primary while the replicas may lag behind its writes
+	if len(replicas) > 0 {
+		registrations = append(registrations, handler.WithMiddleware(handler.ReadYourWrites(handler.NewWriteTracker(cfg.Database.ReadYourWrites))))
+	}
+
+	var idempotencyKeyRepo repository.IdempotencyKeyRepo
+	if cfg.Features.Idempotency {
+		idempotencyKeyRepo = repository.NewMysqlIdempotencyKey(db)
+		registrations = append(registrations, handler.WithMiddleware(handler.Idempotency(idempotencyKeyRepo)))
+	}
+
+	graphQLHandler := delivery.NewGraphQLHandler(currencyUseCase, conversionUseCase, convertCurrenciesUseCase, limiter, idempotencyKeyRepo)
+
+	// readiness
+	healthRepo := repository.NewMysqlHealth(db)
+
+	readiness := handler.NewReadiness(cfg.Readiness.Timeout,
+		handler.DatabaseCheck(healthRepo),
+		handler.MigrationCheck(healthRepo),
+		handler.RateFreshnessCheck(healthRepo, cfg.Readiness.RateMaxAge),
+	)
+
+	registrations = append(registrations,
+		&currencyHandler,
+		&conversionHandler,
+		&rateStreamHandler,
+		&rateMatrixHandler,
+		&convertCurrenciesHandler,
+		&apiKeyHandler,
+		&graphQLHandler,
+		readiness,
+	)
+
+	// quotes
+	if cfg.Features.Quotes {
+		quoteUseCase := quote.NewService(&quote.Provider{
+			Repo:                  repository.NewMysqlQuote(db),
+			ConversionRepo:        conversionRepo,
+			ConvertCurrenciesRepo: convertCurrenciesRepo,
+			Tx:                    repository.NewMysqlTransactor(db),
+			Quota:                 quotaUseCase,
+			TTL:                   cfg.Conversion.QuoteTTL,
+		})
+
+		quoteHandler := delivery.NewQuoteHandler(quoteUseCase)
+		registrations = append(registrations, &quoteHandler)
+	}
+
+	if quotaUseCase != nil {
+		quotaHandler := delivery.NewConversionQuotaHandler(quotaUseCase)
+		registrations = append(registrations, &quotaHandler)
+	}
+
+	if webhookUseCase != nil {
+		webhookHandler := delivery.NewWebhookHandler(webhookUseCase)
+		registrations = append(registrations, &webhookHandler)
+	}
+
+	h := handler.NewHandler(registrations...)
 
-	logger := log.New(os.Stderr, "logger: ", log.Lshortfile)
 	srv := &http.Server{
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		Addr:         fmt.Sprintf(":%s", os.Getenv("APP_PORT")),
-		Handler:      h,
-		ErrorLog:     logger,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		Addr:              fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:           h,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
 	}
+	// streams never complete on their own, end them so the shutdown does not wait for them
+	srv.RegisterOnShutdown(rateHub.Close)
 
-	log.Printf("whim is available at %s\n", srv.Addr)
-	err := srv.ListenAndServe()
-	if err != nil {
-		log.Fatal(err.Error())
+	// gRPC, served on its own port with the same usecases
+	var grpcSrv *grpc.Server
+	if cfg.Server.GRPCPort != 0 {
+		var opts []grpc.ServerOption
+		if cfg.Server.TLS() {
+			creds, err := credentials.NewServerTLSFromFile(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+
+		grpcSrv = rpc.NewServer(rpc.Services{
+			Currency:          currencyUseCase,
+			Conversion:        conversionUseCase,
+			ConvertCurrencies: convertCurrenciesUseCase,
+			APIKey:            apiKeyUseCase,
+			Verifier:          verifier,
+			Limiter:           limiter,
+		}, opts...)
+	}
+
+	// the deferred calls close the database once the server has drained
+	return serve(srv, grpcSrv, cfg.Server, readiness)
+}
+
+// serve runs srv, and grpcSrv when it is not nil, until SIGINT or SIGTERM. It then fails the
+// readiness probe for the drain period so load balancers stop routing to this instance, and
+// waits up to the shutdown timeout for the requests in flight to complete.
+func serve(srv *http.Server, grpcSrv *grpc.Server, cfg config.ServerConfig, readiness *handler.Readiness) error {
+	errs := make(chan error, 2)
+	if grpcSrv != nil {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			return err
+		}
+		go func() {
+			slog.Info("whim gRPC is available", slog.String("addr", lis.Addr().String()), slog.Bool("tls", cfg.TLS()))
+			errs <- grpcSrv.Serve(lis)
+		}()
+	}
+	go func() {
+		slog.Info("whim is available", slog.String("addr", srv.Addr), slog.Bool("tls", cfg.TLS()))
+		if cfg.TLS() {
+			errs <- srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+			return
+		}
+		errs <- srv.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-stop:
+		slog.Info("shutting down", slog.String("signal", sig.String()), slog.Duration("drain", cfg.DrainPeriod))
+	}
+
+	readiness.Drain()
+	select {
+	case <-time.After(cfg.DrainPeriod):
+	case <-stop:
+		// a second signal skips the drain period
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if grpcSrv != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		defer func() {
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				grpcSrv.Stop()
+			}
+		}()
+	}
+
+	if err := srv.Shutdown(ctx); err != nil {
+		srv.Close()
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	slog.Info("server stopped")
+	return nil
+}
+
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
 }
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Config holds the settings of the web service. Each field is read from the environment
+// variable of its env tag, or else from the key of its yaml tag in the config file, or else
+// takes the value of its default tag. Durations are a number of seconds or a Go duration
+// such as 1m30s.
+type Config struct {
+	Env        string           `yaml:"env" env:"ENV" default:"development"`
+	LogLevel   string           `yaml:"log_level" env:"LOG_LEVEL" default:"info"`
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Auth       AuthConfig       `yaml:"auth"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	Conversion ConversionConfig `yaml:"conversion"`
+	Readiness  ReadinessConfig  `yaml:"readiness"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Stream     StreamConfig     `yaml:"stream"`
+	Webhook    WebhookConfig    `yaml:"webhook"`
+	Outbox     OutboxConfig     `yaml:"outbox"`
+	Cache      CacheConfig      `yaml:"cache"`
+	Features   FeaturesConfig   `yaml:"features"`
+
+	warnings []string
+}
+
+// Warnings lists the settings that are deprecated or ignored, to be logged at startup
+func (c *Config) Warnings() []string {
+	return c.warnings
+}
+
+type ServerConfig struct {
+	Port              int           `yaml:"port" env:"APP_PORT" default:"7171"`
+	GRPCPort          int           `yaml:"grpc_port" env:"GRPC_PORT" default:"7172"`
+	TLSCertFile       string        `yaml:"tls_cert_file" env:"SERVER_TLS_CERT_FILE"`
+	TLSKeyFile        string        `yaml:"tls_key_file" env:"SERVER_TLS_KEY_FILE"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT_SECONDS" default:"5s"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT_SECONDS" default:"2s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT_SECONDS" default:"10s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT_SECONDS" default:"60s"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" default:"1048576"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES" default:"1048576"`
+	DrainPeriod       time.Duration `yaml:"drain_period" env:"SHUTDOWN_DRAIN_SECONDS" default:"5s"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT_SECONDS" default:"20s"`
+}
+
+// TLS tells whether the server serves HTTPS
+func (s ServerConfig) TLS() bool {
+	return s.TLSCertFile != ""
+}
+
+type DatabaseConfig struct {
+	Host            string        `yaml:"host" env:"DATABASE_HOST" default:"127.0.0.1"`
+	Port            int           `yaml:"port" env:"DATABASE_PORT" default:"3306"`
+	Name            string        `yaml:"name" env:"DATABASE_NAME"`
+	Username        string        `yaml:"username" env:"DATABASE_USERNAME"`
+	Password        string        `yaml:"password" env:"DATABASE_PASSWORD" secret:"true"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DATABASE_MAX_OPEN_CONNS" default:"50"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DATABASE_MAX_IDLE_CONNS" default:"25"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DATABASE_CONN_MAX_LIFETIME_SECONDS" default:"1m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DATABASE_CONN_MAX_IDLE_TIME_SECONDS" default:"30s"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout" env:"DATABASE_CONNECT_TIMEOUT_SECONDS" default:"5s"`
+	// ReplicaDSNs lists the DSNs of the read replicas, separated by commas
+	ReplicaDSNs    string        `yaml:"replica_dsns" env:"DATABASE_REPLICA_DSNS" secret:"true"`
+	ReadYourWrites time.Duration `yaml:"read_your_writes" env:"DATABASE_READ_YOUR_WRITES_SECONDS" default:"5s"`
+}
+
+// Replicas returns the DSNs of the read replicas
+func (d DatabaseConfig) Replicas() []string {
+	var dsns []string
+	for _, dsn := range strings.Split(d.ReplicaDSNs, ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			dsns = append(dsns, dsn)
+		}
+	}
+	return dsns
+}
+
+type AuthConfig struct {
+	BootstrapAdminAPIKey string        `yaml:"bootstrap_admin_api_key" env:"BOOTSTRAP_ADMIN_API_KEY" secret:"true"`
+	JWKS                 string        `yaml:"jwks" env:"JWT_JWKS"`
+	Issuer               string        `yaml:"issuer" env:"JWT_ISSUER"`
+	Audience             string        `yaml:"audience" env:"JWT_AUDIENCE"`
+	JWKSRefresh          time.Duration `yaml:"jwks_refresh" env:"JWT_JWKS_REFRESH_SECONDS" default:"1h"`
+	Leeway               time.Duration `yaml:"leeway" env:"JWT_LEEWAY_SECONDS" default:"30s"`
+}
+
+type RateLimitConfig struct {
+	RPS          float64 `yaml:"rps" env:"RATE_LIMIT_RPS" default:"10"`
+	Burst        int     `yaml:"burst" env:"RATE_LIMIT_BURST" default:"20"`
+	ConvertRPS   float64 `yaml:"convert_rps" env:"RATE_LIMIT_CONVERT_RPS" default:"2"`
+	ConvertBurst int     `yaml:"convert_burst" env:"RATE_LIMIT_CONVERT_BURST" default:"5"`
+	// ConvertRoutes lists the routes held to ConvertRPS and ConvertBurst, separated by commas,
+	// each a method and a path such as "POST /v1/convert-currencies"
+	ConvertRoutes string `yaml:"convert_routes" env:"RATE_LIMIT_CONVERT_ROUTES" default:"POST /v1/convert-currencies,POST /v1/quotes/:id/execute"`
+}
+
+// RateLimitRoute is a route held to its own rate limit, with the method and the path of its handler
+type RateLimitRoute struct {
+	Method string
+	Path   string
+}
+
+// Routes returns the routes held to the conversion rate limit
+func (r RateLimitConfig) Routes() []RateLimitRoute {
+	routes, _ := parseRoutes(r.ConvertRoutes)
+	return routes
+}
+
+func parseRoutes(s string) ([]RateLimitRoute, error) {
+	var routes []RateLimitRoute
+	for i, route := range strings.Split(s, ",") {
+		if route = strings.TrimSpace(route); route == "" {
+			continue
+		}
+		fields := strings.Fields(route)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "/") {
+			return nil, fmt.Errorf("invalid route %q at position %d, want a method and a path", route, i+1)
+		}
+		routes = append(routes, RateLimitRoute{Method: strings.ToUpper(fields[0]), Path: fields[1]})
+	}
+	return routes, nil
+}
+
+type ConversionConfig struct {
+	MonthlyQuota int64         `yaml:"monthly_quota" env:"CONVERSION_MONTHLY_QUOTA"`
+	QuoteTTL     time.Duration `yaml:"quote_ttl" env:"QUOTE_TTL_SECONDS" default:"30s"`
+}
+
+type ReadinessConfig struct {
+	Timeout    time.Duration `yaml:"timeout" env:"READINESS_TIMEOUT_SECONDS" default:"2s"`
+	RateMaxAge time.Duration `yaml:"rate_max_age" env:"RATE_MAX_AGE_SECONDS"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" default:"none"`
+}
+
+type StreamConfig struct {
+	Heartbeat time.Duration `yaml:"heartbeat" env:"STREAM_HEARTBEAT_SECONDS" default:"15s"`
+	Buffer    int           `yaml:"buffer" env:"STREAM_BUFFER" default:"64"`
+}
+
+type WebhookConfig struct {
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	Backoff      time.Duration `yaml:"backoff" env:"WEBHOOK_BACKOFF_SECONDS" default:"30s"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" env:"WEBHOOK_MAX_BACKOFF_SECONDS" default:"1h"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT_SECONDS" default:"10s"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL_SECONDS" default:"5s"`
+	BatchSize    int           `yaml:"batch_size" env:"WEBHOOK_BATCH_SIZE" default:"50"`
+	Retention    time.Duration `yaml:"retention" env:"WEBHOOK_RETENTION_SECONDS" default:"720h"`
+}
+
+// OutboxConfig sets where the relay publishes the domain events recorded in the outbox
+type OutboxConfig struct {
+	Publisher    string        `yaml:"publisher" env:"OUTBOX_PUBLISHER" default:"log"`
+	File         string        `yaml:"file" env:"OUTBOX_FILE" default:"outbox.jsonl"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL_SECONDS" default:"2s"`
+	BatchSize    int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" default:"100"`
+	Retention    time.Duration `yaml:"retention" env:"OUTBOX_RETENTION_SECONDS" default:"168h"`
+}
+
+// CacheConfig sets where the conversions of the currency pairs are cached: in process, in a
+// Redis compatible server shared by the instances, or not at all
+type CacheConfig struct {
+	Backend       string        `yaml:"backend" env:"CACHE_BACKEND" default:"memory"`
+	Size          int           `yaml:"size" env:"CACHE_SIZE" default:"10000"`
+	TTL           time.Duration `yaml:"ttl" env:"CACHE_TTL_SECONDS" default:"60s"`
+	RedisAddr     string        `yaml:"redis_addr" env:"CACHE_REDIS_ADDR" default:"localhost:6379"`
+	RedisPassword string        `yaml:"redis_password" env:"CACHE_REDIS_PASSWORD" secret:"true"`
+	RedisDB       int           `yaml:"redis_db" env:"CACHE_REDIS_DB" default:"0"`
+}
+
+// FeaturesConfig turns optional parts of the service on and off
+type FeaturesConfig struct {
+	Quotes            bool `yaml:"quotes" env:"FEATURE_QUOTES" default:"true"`
+	Quotas            bool `yaml:"quotas" env:"FEATURE_QUOTAS" default:"true"`
+	RateLimit         bool `yaml:"rate_limit" env:"FEATURE_RATE_LIMIT" default:"true"`
+	Idempotency       bool `yaml:"idempotency" env:"FEATURE_IDEMPOTENCY" default:"true"`
+	RequestValidation bool `yaml:"request_validation" env:"FEATURE_REQUEST_VALIDATION" default:"false"`
+	Webhooks          bool `yaml:"webhooks" env:"FEATURE_WEBHOOKS" default:"true"`
+}
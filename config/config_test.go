@@ -0,0 +1,183 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+var required = map[string]string{
+	"DATABASE_NAME":     "whim",
+	"DATABASE_USERNAME": "whim",
+}
+
+func TestParseDefaults(t *testing.T) {
+	cfg, err := config.Parse("", lookup(required))
+	require.NoError(t, err)
+
+	assert.Equal(t, 7171, cfg.Server.Port)
+	assert.Equal(t, 7172, cfg.Server.GRPCPort)
+	assert.Equal(t, 5*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, "127.0.0.1", cfg.Database.Host)
+	assert.Equal(t, 50, cfg.Database.MaxOpenConns)
+	assert.Equal(t, time.Minute, cfg.Database.ConnMaxLifetime)
+	assert.Equal(t, 30*time.Second, cfg.Conversion.QuoteTTL)
+	assert.True(t, cfg.Features.Quotes)
+	assert.False(t, cfg.Server.TLS())
+	assert.Equal(t, 7*24*time.Hour, cfg.Outbox.Retention)
+	assert.Equal(t, 30*24*time.Hour, cfg.Webhook.Retention)
+	assert.Equal(t, 10.0, cfg.RateLimit.RPS)
+	assert.Equal(t, 20, cfg.RateLimit.Burst)
+	assert.Equal(t, 2.0, cfg.RateLimit.ConvertRPS)
+	assert.Equal(t, 5, cfg.RateLimit.ConvertBurst)
+	assert.Equal(t, []config.RateLimitRoute{
+		{Method: "POST", Path: "/v1/convert-currencies"},
+		{Method: "POST", Path: "/v1/quotes/:id/execute"},
+	}, cfg.RateLimit.Routes())
+}
+
+func TestParseFileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "whim.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+log_level: debug
+server:
+  port: 8080
+  write_timeout: 1m30s
+  read_timeout: 7
+database:
+  host: db.internal
+  name: whim_production
+  max_open_conns: 100
+features:
+  quotes: false
+`), 0o600))
+
+	cfg, err := config.Parse(path, lookup(map[string]string{
+		"DATABASE_USERNAME":   "whim",
+		"DATABASE_HOST":       "replica.internal",
+		"APP_PORT":            "",
+		"RATE_LIMIT_RPS":      "2.5",
+		"RATE_LIMIT_BURST":    "5",
+		"FEATURE_IDEMPOTENCY": "false",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, 8080, cfg.Server.Port, "empty variables are ignored")
+	assert.Equal(t, 90*time.Second, cfg.Server.WriteTimeout)
+	assert.Equal(t, 7*time.Second, cfg.Server.ReadTimeout, "durations without unit are seconds")
+	assert.Equal(t, "replica.internal", cfg.Database.Host, "the environment overrides the file")
+	assert.Equal(t, "whim_production", cfg.Database.Name)
+	assert.Equal(t, 100, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 2.5, cfg.RateLimit.RPS)
+	assert.False(t, cfg.Features.Quotes)
+	assert.False(t, cfg.Features.Idempotency)
+	assert.True(t, cfg.Features.Quotas)
+}
+
+func TestParseErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "whim.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  port: http
+  timeout: 5s
+`), 0o600))
+
+	_, err := config.Parse(path, lookup(map[string]string{
+		"DATABASE_USERNAME":         "whim",
+		"DATABASE_MAX_IDLE_CONNS":   "80",
+		"SERVER_TLS_CERT_FILE":      "cert.pem",
+		"RATE_LIMIT_RPS":            "10",
+		"RATE_LIMIT_BURST":          "0",
+		"RATE_LIMIT_CONVERT_ROUTES": "POST /v1/convert-currencies,/v1/quotes",
+		"QUOTE_TTL_SECONDS":         "soon",
+		"OTEL_TRACES_EXPORTER":      "zipkin",
+		"JWT_JWKS":                  "jwks.json",
+		"JWT_AUDIENCE":              "whim",
+		"OUTBOX_RETENTION_SECONDS":  "-60",
+	}))
+	require.Error(t, err)
+
+	for _, want := range []string{
+		path + `: server.port: invalid integer "http"`,
+		path + ": server.timeout: unknown key",
+		`QUOTE_TTL_SECONDS: invalid duration "soon"`,
+		"DATABASE_NAME is required",
+		"DATABASE_MAX_IDLE_CONNS must be between 0 and DATABASE_MAX_OPEN_CONNS (50), got 80",
+		"SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE must be set together",
+		"RATE_LIMIT_BURST must be at least 1 when RATE_LIMIT_RPS is set, got 0",
+		`RATE_LIMIT_CONVERT_ROUTES has an invalid route "/v1/quotes" at position 2, want a method and a path`,
+		`OTEL_TRACES_EXPORTER must be none, otlp or stdout, got "zipkin"`,
+		"JWT_ISSUER and JWT_AUDIENCE are required when JWT_JWKS is set",
+		"OUTBOX_RETENTION_SECONDS must not be negative",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestParseLegacyPool(t *testing.T) {
+	env := map[string]string{"DATABASE_POOL": "80"}
+	for k, v := range required {
+		env[k] = v
+	}
+	cfg, err := config.Parse("", lookup(env))
+	require.NoError(t, err, "DATABASE_POOL is a warning, not an error")
+	assert.Equal(t, 50, cfg.Database.MaxIdleConns, "capped at the open connections")
+	assert.Equal(t, []string{"DATABASE_POOL is deprecated, use DATABASE_MAX_OPEN_CONNS and DATABASE_MAX_IDLE_CONNS"}, cfg.Warnings())
+
+	env["DATABASE_POOL"] = "many"
+	cfg, err = config.Parse("", lookup(env))
+	require.NoError(t, err)
+	assert.Equal(t, 25, cfg.Database.MaxIdleConns)
+	assert.Len(t, cfg.Warnings(), 2)
+
+	env["DATABASE_POOL"] = "10"
+	env["DATABASE_MAX_IDLE_CONNS"] = "5"
+	cfg, err = config.Parse("", lookup(env))
+	require.NoError(t, err)
+	assert.Equal(t, 5, cfg.Database.MaxIdleConns)
+}
+
+func TestPrint(t *testing.T) {
+	env := map[string]string{
+		"DATABASE_PASSWORD":    "s3cret",
+		"CACHE_REDIS_PASSWORD": "hunter2",
+	}
+	for k, v := range required {
+		env[k] = v
+	}
+	cfg, err := config.Parse("", lookup(env))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Print(&buf))
+
+	out := buf.String()
+	assert.NotContains(t, out, "s3cret")
+	assert.NotContains(t, out, "hunter2")
+	assert.Contains(t, out, "redis_password: '[REDACTED]' # CACHE_REDIS_PASSWORD")
+	assert.Contains(t, out, "password: '[REDACTED]' # DATABASE_PASSWORD")
+	assert.Contains(t, out, `bootstrap_admin_api_key: "" # BOOTSTRAP_ADMIN_API_KEY`)
+	assert.Contains(t, out, "read_timeout: 5s # SERVER_READ_TIMEOUT_SECONDS")
+
+	// the printed configuration can be loaded back
+	path := filepath.Join(t.TempDir(), "whim.yaml")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	reloaded, err := config.Parse(path, lookup(nil))
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Server, reloaded.Server)
+	assert.Equal(t, "[REDACTED]", reloaded.Database.Password)
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/subosito/gotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the configuration from the environment, the .env file of the working
+// directory and the YAML file at path, when path is not empty, then validates it
+func Load(path string) (*Config, error) {
+	gotenv.Load()
+	return Parse(path, os.LookupEnv)
+}
+
+// Parse reads the configuration from lookupEnv and the YAML file at path, when path is not
+// empty, then validates it. Empty variables are ignored. The configuration is returned
+// with the validation errors, so it can be printed.
+func Parse(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := &Config{}
+	var errs []error
+
+	fields(reflect.ValueOf(cfg).Elem(), "", func(f field) {
+		if def := f.tag.Get("default"); def != "" {
+			if err := setValue(f.value, def); err != nil {
+				panic(fmt.Sprintf("config: default of %s: %v", f.path, err))
+			}
+		}
+	})
+
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	fields(reflect.ValueOf(cfg).Elem(), "", func(f field) {
+		name := f.tag.Get("env")
+		if s, ok := lookupEnv(name); ok && s != "" {
+			if err := setValue(f.value, s); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", name, err))
+			}
+		}
+	})
+
+	cfg.legacyPool(lookupEnv)
+
+	errs = append(errs, cfg.Validate()...)
+	return cfg, errors.Join(errs...)
+}
+
+// legacyPool applies DATABASE_POOL as it used to be, to the idle connections, unless
+// DATABASE_MAX_IDLE_CONNS is set. An invalid value is ignored as before, with a warning.
+func (c *Config) legacyPool(lookupEnv func(string) (string, bool)) {
+	s, ok := lookupEnv("DATABASE_POOL")
+	if !ok || s == "" {
+		return
+	}
+	c.warnings = append(c.warnings, "DATABASE_POOL is deprecated, use DATABASE_MAX_OPEN_CONNS and DATABASE_MAX_IDLE_CONNS")
+
+	if idle, _ := lookupEnv("DATABASE_MAX_IDLE_CONNS"); idle != "" {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 {
+		c.warnings = append(c.warnings, fmt.Sprintf("DATABASE_POOL must be a positive integer, got %q, ignored", s))
+		return
+	}
+	c.Database.MaxIdleConns = min(n, c.Database.MaxOpenConns)
+}
+
+// loadFile sets the fields found in the YAML file at path, unknown keys are an error
+func loadFile(cfg *Config, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	var errs []error
+	applyYAML(reflect.ValueOf(cfg).Elem(), doc, "", func(key string, err error) {
+		errs = append(errs, fmt.Errorf("%s: %s: %v", path, key, err))
+	})
+	return errors.Join(errs...)
+}
+
+func applyYAML(v reflect.Value, doc map[string]interface{}, prefix string, fail func(string, error)) {
+	known := map[string]int{}
+	for i := 0; i < v.NumField(); i++ {
+		if sf := v.Type().Field(i); sf.IsExported() {
+			known[sf.Tag.Get("yaml")] = i
+		}
+	}
+
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		i, ok := known[key]
+		if !ok {
+			fail(prefix+key, errors.New("unknown key"))
+			continue
+		}
+
+		fv := v.Field(i)
+		if doc[key] == nil {
+			continue
+		}
+		if fv.Kind() == reflect.Struct {
+			section, ok := doc[key].(map[string]interface{})
+			if !ok {
+				fail(prefix+key, errors.New("must be a mapping"))
+				continue
+			}
+			applyYAML(fv, section, prefix+key+".", fail)
+			continue
+		}
+
+		if err := setValue(fv, fmt.Sprint(doc[key])); err != nil {
+			fail(prefix+key, err)
+		}
+	}
+}
+
+type field struct {
+	path  string
+	tag   reflect.StructTag
+	value reflect.Value
+}
+
+// fields calls fn with each setting of v, in declaration order
+func fields(v reflect.Value, prefix string, fn func(field)) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		path := prefix + sf.Tag.Get("yaml")
+		if v.Field(i).Kind() == reflect.Struct {
+			fields(v.Field(i), path+".", fn)
+			continue
+		}
+		fn(field{path: path, tag: sf.Tag, value: v.Field(i)})
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue parses s into v according to its type. A duration without a unit is a number
+// of seconds.
+func setValue(v reflect.Value, s string) error {
+	s = strings.TrimSpace(s)
+
+	if v.Type() == durationType {
+		if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+			v.SetInt(seconds * int64(time.Second))
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -1,47 +1,93 @@
 package config
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"os"
+	"log/slog"
+	"net"
 	"strconv"
-	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
-// Init returns connector to Soteria database
-func NewMySQL() *sql.DB {
+// NewMySQL opens the connection pool to the whim database and checks it can connect
+func NewMySQL(cfg DatabaseConfig) (*sql.DB, error) {
+	dsn := mysql.NewConfig()
+	dsn.User = cfg.Username
+	dsn.Passwd = cfg.Password
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dsn.DBName = cfg.Name
+	dsn.ParseTime = true
+	dsn.Timeout = cfg.ConnectTimeout
 
-	var db *sql.DB
+	slog.Info("connecting to database", slog.String("host", cfg.Host), slog.Int("port", cfg.Port), slog.String("database", cfg.Name))
 
-	env := os.Getenv("ENV")
-
-	dbUsername := os.Getenv("DATABASE_USERNAME")
-	dbPassword := os.Getenv("DATABASE_PASSWORD")
-	dbHost := os.Getenv("DATABASE_HOST")
-	dbName := os.Getenv("DATABASE_NAME")
-	dbPort := os.Getenv("DATABASE_PORT")
-	if dbPort == "" {
-		dbPort = "3306"
+	db, err := sql.Open("mysql", dsn.FormatDSN())
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
 	}
 
-	if env == "development" || env == "staging" {
-		fmt.Printf("Connecting to [USERNAME]:[PASSWORD]@tcp(%s:%v)/%s?parseTime=true\n", dbHost, dbPort, dbName)
-	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
-	dataSourceName := fmt.Sprintf("%s:%v@(%s:%v)/%s?parseTime=true", dbUsername, dbPassword, dbHost, dbPort, dbName)
-	db, _ = sql.Open("mysql", dataSourceName)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
 
-	if err := db.Ping(); err != nil {
-		panic(err.Error())
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database: %w", err)
 	}
 
-	if dp, err := strconv.Atoi(os.Getenv("DATABASE_POOL")); err == nil && dp > 0 {
-		db.SetMaxIdleConns(dp)
+	return db, nil
+}
+
+// NewMySQLReplicas opens the connection pools to the read replicas, with the settings of the
+// primary pool. A replica that cannot be reached yet is kept, its queries go to the primary
+// until it answers.
+func NewMySQLReplicas(cfg DatabaseConfig) ([]*sql.DB, error) {
+	var replicas []*sql.DB
+	for i, raw := range cfg.Replicas() {
+		dsn, err := mysql.ParseDSN(raw)
+		if err != nil {
+			closeAll(replicas)
+			return nil, fmt.Errorf("database replica %d: invalid DSN", i+1)
+		}
+		dsn.ParseTime = true
+		if dsn.Timeout == 0 {
+			dsn.Timeout = cfg.ConnectTimeout
+		}
+
+		slog.Info("connecting to database replica", slog.String("address", dsn.Addr), slog.String("database", dsn.DBName))
+
+		db, err := sql.Open("mysql", dsn.FormatDSN())
+		if err != nil {
+			closeAll(replicas)
+			return nil, fmt.Errorf("database replica %d: %w", i+1, err)
+		}
+
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+		if err := db.PingContext(ctx); err != nil {
+			slog.Warn("database replica unreachable", slog.String("address", dsn.Addr), slog.String("error", err.Error()))
+		}
+		cancel()
+
+		replicas = append(replicas, db)
 	}
 
-	db.SetConnMaxLifetime(time.Minute)
+	return replicas, nil
+}
 
-	return db
+func closeAll(dbs []*sql.DB) {
+	for _, db := range dbs {
+		db.Close()
+	}
 }
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Print writes the configuration to w as YAML, each setting commented with its environment
+// variable. Secrets that are set are replaced by [REDACTED].
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlNode(reflect.ValueOf(c).Elem())); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func yamlNode(v reflect.Value) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: sf.Tag.Get("yaml")}
+
+		if v.Field(i).Kind() == reflect.Struct {
+			node.Content = append(node.Content, key, yamlNode(v.Field(i)))
+			continue
+		}
+
+		value := &yaml.Node{Kind: yaml.ScalarNode, LineComment: sf.Tag.Get("env")}
+		value.Tag, value.Value = scalar(v.Field(i))
+		if sf.Tag.Get("secret") == "true" && value.Value != "" {
+			value.Value = redacted
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	return node
+}
+
+func scalar(v reflect.Value) (tag, value string) {
+	if v.Type() == durationType {
+		return "!!str", time.Duration(v.Int()).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return "", strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int64:
+		return "", strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64:
+		return "", strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	}
+	return "!!str", fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/rbpermadi/whim_assignment/app/logger"
+)
+
+// Validate returns an error for each invalid setting, naming its environment variable
+func (c *Config) Validate() []error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, err := logger.ParseLevel(c.LogLevel); err != nil {
+		fail("LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+
+	s := c.Server
+	if s.Port < 1 || s.Port > 65535 {
+		fail("APP_PORT must be between 1 and 65535, got %d", s.Port)
+	}
+	if s.GRPCPort < 0 || s.GRPCPort > 65535 {
+		fail("GRPC_PORT must be between 1 and 65535, or 0 to turn gRPC off, got %d", s.GRPCPort)
+	}
+	if s.GRPCPort != 0 && s.GRPCPort == s.Port {
+		fail("GRPC_PORT must differ from APP_PORT, got %d", s.GRPCPort)
+	}
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		fail("SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE must be set together")
+	}
+	for _, file := range []struct{ name, path string }{
+		{"SERVER_TLS_CERT_FILE", s.TLSCertFile},
+		{"SERVER_TLS_KEY_FILE", s.TLSKeyFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			fail("%s: %v", file.name, err)
+		}
+	}
+	if s.ReadTimeout <= 0 || s.ReadHeaderTimeout <= 0 || s.WriteTimeout <= 0 || s.IdleTimeout <= 0 {
+		fail("SERVER_READ_TIMEOUT_SECONDS, SERVER_READ_HEADER_TIMEOUT_SECONDS, SERVER_WRITE_TIMEOUT_SECONDS and SERVER_IDLE_TIMEOUT_SECONDS must be greater than zero")
+	}
+	if s.MaxHeaderBytes < 0 {
+		fail("SERVER_MAX_HEADER_BYTES must not be negative, got %d", s.MaxHeaderBytes)
+	}
+	if s.MaxBodyBytes < 0 {
+		fail("SERVER_MAX_BODY_BYTES must not be negative, got %d", s.MaxBodyBytes)
+	}
+	if s.DrainPeriod < 0 || s.ShutdownTimeout <= 0 {
+		fail("SHUTDOWN_DRAIN_SECONDS must not be negative and SHUTDOWN_TIMEOUT_SECONDS must be greater than zero")
+	}
+
+	db := c.Database
+	if db.Host == "" {
+		fail("DATABASE_HOST is required")
+	}
+	if db.Port < 1 || db.Port > 65535 {
+		fail("DATABASE_PORT must be between 1 and 65535, got %d", db.Port)
+	}
+	if db.Name == "" {
+		fail("DATABASE_NAME is required")
+	}
+	if db.Username == "" {
+		fail("DATABASE_USERNAME is required")
+	}
+	if db.MaxOpenConns < 1 {
+		fail("DATABASE_MAX_OPEN_CONNS must be at least 1, got %d", db.MaxOpenConns)
+	}
+	if db.MaxIdleConns < 0 || db.MaxIdleConns > db.MaxOpenConns {
+		fail("DATABASE_MAX_IDLE_CONNS must be between 0 and DATABASE_MAX_OPEN_CONNS (%d), got %d", db.MaxOpenConns, db.MaxIdleConns)
+	}
+	if db.ConnMaxLifetime < 0 || db.ConnMaxIdleTime < 0 {
+		fail("DATABASE_CONN_MAX_LIFETIME_SECONDS and DATABASE_CONN_MAX_IDLE_TIME_SECONDS must not be negative")
+	}
+	if db.ConnectTimeout <= 0 {
+		fail("DATABASE_CONNECT_TIMEOUT_SECONDS must be greater than zero")
+	}
+	for i, dsn := range db.Replicas() {
+		if _, err := mysql.ParseDSN(dsn); err != nil {
+			// the error may quote the DSN and its password
+			fail("DATABASE_REPLICA_DSNS has an invalid DSN at position %d", i+1)
+		}
+	}
+	if db.ReadYourWrites < 0 {
+		fail("DATABASE_READ_YOUR_WRITES_SECONDS must not be negative")
+	}
+
+	if c.Auth.JWKS == "" && (c.Auth.Issuer != "" || c.Auth.Audience != "") {
+		fail("JWT_ISSUER and JWT_AUDIENCE require JWT_JWKS")
+	}
+	if c.Auth.JWKS != "" && (c.Auth.Issuer == "" || c.Auth.Audience == "") {
+		// without them, a token minted by the identity provider for any other service is accepted
+		fail("JWT_ISSUER and JWT_AUDIENCE are required when JWT_JWKS is set")
+	}
+	if c.Auth.JWKS != "" && c.Auth.JWKSRefresh <= 0 {
+		fail("JWT_JWKS_REFRESH_SECONDS must be greater than zero")
+	}
+	if c.Auth.Leeway < 0 {
+		fail("JWT_LEEWAY_SECONDS must not be negative")
+	}
+
+	for _, l := range []struct {
+		rate, burst string
+		rps         float64
+		n           int
+	}{
+		{"RATE_LIMIT_RPS", "RATE_LIMIT_BURST", c.RateLimit.RPS, c.RateLimit.Burst},
+		{"RATE_LIMIT_CONVERT_RPS", "RATE_LIMIT_CONVERT_BURST", c.RateLimit.ConvertRPS, c.RateLimit.ConvertBurst},
+	} {
+		if l.rps < 0 {
+			fail("%s must not be negative, got %g", l.rate, l.rps)
+		}
+		if l.rps > 0 && l.n < 1 {
+			fail("%s must be at least 1 when %s is set, got %d", l.burst, l.rate, l.n)
+		}
+	}
+	if _, err := parseRoutes(c.RateLimit.ConvertRoutes); err != nil {
+		fail("RATE_LIMIT_CONVERT_ROUTES has an %v", err)
+	}
+
+	if c.Conversion.MonthlyQuota < 0 {
+		fail("CONVERSION_MONTHLY_QUOTA must not be negative, got %d", c.Conversion.MonthlyQuota)
+	}
+	if c.Conversion.QuoteTTL <= 0 {
+		fail("QUOTE_TTL_SECONDS must be greater than zero")
+	}
+
+	if c.Readiness.Timeout <= 0 {
+		fail("READINESS_TIMEOUT_SECONDS must be greater than zero")
+	}
+	if c.Readiness.RateMaxAge < 0 {
+		fail("RATE_MAX_AGE_SECONDS must not be negative")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		fail("OTEL_TRACES_EXPORTER must be none, otlp or stdout, got %q", c.Tracing.Exporter)
+	}
+
+	if c.Stream.Heartbeat <= 0 {
+		fail("STREAM_HEARTBEAT_SECONDS must be greater than zero")
+	}
+	if c.Stream.Buffer < 1 {
+		fail("STREAM_BUFFER must be at least 1, got %d", c.Stream.Buffer)
+	}
+
+	wh := c.Webhook
+	if wh.MaxAttempts < 1 {
+		fail("WEBHOOK_MAX_ATTEMPTS must be at least 1, got %d", wh.MaxAttempts)
+	}
+	if wh.Backoff <= 0 || wh.MaxBackoff < wh.Backoff {
+		fail("WEBHOOK_BACKOFF_SECONDS must be greater than zero and WEBHOOK_MAX_BACKOFF_SECONDS must not be below it")
+	}
+	if wh.Timeout <= 0 || wh.PollInterval <= 0 {
+		fail("WEBHOOK_TIMEOUT_SECONDS and WEBHOOK_POLL_INTERVAL_SECONDS must be greater than zero")
+	}
+	if wh.BatchSize < 1 {
+		fail("WEBHOOK_BATCH_SIZE must be at least 1, got %d", wh.BatchSize)
+	}
+	if wh.Retention < 0 {
+		fail("WEBHOOK_RETENTION_SECONDS must not be negative")
+	}
+
+	switch c.Outbox.Publisher {
+	case "none", "log":
+	case "file":
+		if c.Outbox.File == "" {
+			fail("OUTBOX_FILE cannot be empty when OUTBOX_PUBLISHER is file")
+		}
+	default:
+		fail("OUTBOX_PUBLISHER must be none, log or file, got %q", c.Outbox.Publisher)
+	}
+	if c.Outbox.PollInterval <= 0 {
+		fail("OUTBOX_POLL_INTERVAL_SECONDS must be greater than zero")
+	}
+	if c.Outbox.BatchSize < 1 {
+		fail("OUTBOX_BATCH_SIZE must be at least 1, got %d", c.Outbox.BatchSize)
+	}
+	if c.Outbox.Retention < 0 {
+		fail("OUTBOX_RETENTION_SECONDS must not be negative")
+	}
+
+	switch c.Cache.Backend {
+	case "none":
+	case "memory":
+		if c.Cache.Size < 1 {
+			fail("CACHE_SIZE must be at least 1, got %d", c.Cache.Size)
+		}
+	case "redis":
+		if c.Cache.RedisAddr == "" {
+			fail("CACHE_REDIS_ADDR cannot be empty when CACHE_BACKEND is redis")
+		}
+		if c.Cache.RedisDB < 0 {
+			fail("CACHE_REDIS_DB cannot be negative, got %d", c.Cache.RedisDB)
+		}
+	default:
+		fail("CACHE_BACKEND must be none, memory or redis, got %q", c.Cache.Backend)
+	}
+	if c.Cache.Backend != "none" && c.Cache.TTL <= 0 {
+		fail("CACHE_TTL_SECONDS must be greater than zero")
+	}
+
+	return errs
+}
@@ -0,0 +1,13 @@
+-- Adds the tenant and version of the currencies and conversions to a database created from
+-- the first schema, db/whim_development.sql only creates the tables that do not exist yet
+use whim_development;
+
+ALTER TABLE `conversions`
+  ADD COLUMN `tenant_id` varchar(100) NOT NULL DEFAULT '' AFTER `id`,
+  ADD COLUMN `version` bigint(20) unsigned NOT NULL DEFAULT 1 AFTER `rate`,
+  ADD KEY `index_conversions_on_tenant_id_and_currencies` (`tenant_id`, `currency_id_from`, `currency_id_to`);
+
+ALTER TABLE `currencies`
+  ADD COLUMN `tenant_id` varchar(100) NOT NULL DEFAULT '' AFTER `id`,
+  ADD COLUMN `version` bigint(20) unsigned NOT NULL DEFAULT 1 AFTER `name`,
+  ADD KEY `index_currencies_on_tenant_id` (`tenant_id`);
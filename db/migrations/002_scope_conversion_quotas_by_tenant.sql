@@ -0,0 +1,12 @@
+-- Keys the quotas and usages of the clients by tenant, client ids are only unique within a tenant
+use whim_development;
+
+ALTER TABLE `conversion_quotas`
+  ADD COLUMN `tenant_id` varchar(100) NOT NULL DEFAULT '' FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`tenant_id`, `client_id`);
+
+ALTER TABLE `conversion_quota_usages`
+  ADD COLUMN `tenant_id` varchar(100) NOT NULL DEFAULT '' FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`tenant_id`, `client_id`, `period`);
@@ -0,0 +1,7 @@
+-- Records the tenant of every conversion made, so a tenant only sees the conversions of its clients
+use whim_development;
+
+ALTER TABLE `convert_currencies`
+  ADD COLUMN `tenant_id` varchar(100) NOT NULL DEFAULT '' AFTER `id`,
+  DROP KEY `index_convert_currencies_on_client_id_and_created_at`,
+  ADD KEY `index_convert_currencies_on_tenant_id_and_client_id_and_created_at` (`tenant_id`, `client_id`, `created_at`);
@@ -0,0 +1,5 @@
+-- Records the tenant of the client a quote was given to, client ids are only unique within a tenant
+use whim_development;
+
+ALTER TABLE `quotes`
+  ADD COLUMN `tenant_id` varchar(100) NOT NULL DEFAULT '' AFTER `id`;
@@ -0,0 +1,7 @@
+-- Scopes the idempotency keys by tenant, client ids are only unique within a tenant
+use whim_development;
+
+ALTER TABLE `idempotency_keys`
+  ADD COLUMN `tenant_id` varchar(100) NOT NULL DEFAULT '' AFTER `idempotency_key`,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`tenant_id`, `client_id`, `idempotency_key`);
@@ -4,16 +4,147 @@ use whim_development;
 
 CREATE TABLE if not exists `conversions` (
   `id` bigint(20) unsigned NOT NULL PRIMARY KEY AUTO_INCREMENT,
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
   `currency_id_from` bigint(20) NOT NULL,
   `currency_id_to` bigint(20) NOT NULL,
   `rate` float NOT NULL,
+  `version` bigint(20) unsigned NOT NULL DEFAULT 1,
   `created_at` datetime NOT NULL,
-  `updated_at` datetime NOT NULL
+  `updated_at` datetime NOT NULL,
+  KEY `index_conversions_on_tenant_id_and_currencies` (`tenant_id`, `currency_id_from`, `currency_id_to`)
 ) ENGINE=InnoDB DEFAULT CHARSET=latin1;
 
 CREATE TABLE if not exists `currencies` (
   `id` bigint(20) unsigned NOT NULL PRIMARY KEY AUTO_INCREMENT,
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
   `name` varchar(50) NOT NULL,
+  `version` bigint(20) unsigned NOT NULL DEFAULT 1,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  KEY `index_currencies_on_tenant_id` (`tenant_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE if not exists `quotes` (
+  `id` bigint(20) unsigned NOT NULL PRIMARY KEY AUTO_INCREMENT,
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
+  `conversion_id` bigint(20) NOT NULL,
+  `currency_id_from` bigint(20) NOT NULL,
+  `currency_id_to` bigint(20) NOT NULL,
+  `rate` double NOT NULL,
+  `amount` double NOT NULL,
+  `result` double NOT NULL,
+  `client_id` varchar(100) NOT NULL DEFAULT '',
+  `expires_at` datetime NOT NULL,
+  `executed_at` datetime NULL DEFAULT NULL,
   `created_at` datetime NOT NULL,
   `updated_at` datetime NOT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE if not exists `convert_currencies` (
+  `id` bigint(20) unsigned NOT NULL PRIMARY KEY AUTO_INCREMENT,
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
+  `conversion_id` bigint(20) NOT NULL,
+  `quote_id` bigint(20) NOT NULL DEFAULT 0,
+  `currency_id_from` bigint(20) NOT NULL,
+  `currency_id_to` bigint(20) NOT NULL,
+  `amount` double NOT NULL,
+  `rate` double NOT NULL,
+  `result` double NOT NULL,
+  `client_id` varchar(100) NOT NULL DEFAULT '',
+  `idempotency_key` varchar(255) NOT NULL DEFAULT '',
+  `created_at` datetime NOT NULL,
+  KEY `index_convert_currencies_on_tenant_id_and_client_id_and_created_at` (`tenant_id`, `client_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE if not exists `idempotency_keys` (
+  `idempotency_key` varchar(255) NOT NULL,
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
+  `client_id` varchar(100) NOT NULL DEFAULT '',
+  `request_hash` char(64) NOT NULL,
+  `response_status` int NOT NULL DEFAULT 0,
+  `response_headers` blob NULL,
+  `response_body` mediumblob NULL,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`tenant_id`, `client_id`, `idempotency_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE if not exists `api_keys` (
+  `id` bigint(20) unsigned NOT NULL PRIMARY KEY AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `client_id` varchar(100) NOT NULL,
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
+  `role` varchar(20) NOT NULL,
+  `prefix` varchar(20) NOT NULL,
+  `key_hash` char(64) NOT NULL,
+  `revoked_at` datetime NULL DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  UNIQUE KEY `index_api_keys_on_key_hash` (`key_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE if not exists `conversion_quotas` (
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
+  `client_id` varchar(100) NOT NULL,
+  `monthly_conversions` bigint(20) NOT NULL,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`tenant_id`, `client_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE if not exists `conversion_quota_usages` (
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
+  `client_id` varchar(100) NOT NULL,
+  `period` char(7) NOT NULL,
+  `used` bigint(20) NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`tenant_id`, `client_id`, `period`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE if not exists `webhook_subscriptions` (
+  `id` bigint(20) unsigned NOT NULL PRIMARY KEY AUTO_INCREMENT,
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
+  `url` varchar(2048) NOT NULL,
+  `secret` varchar(100) NOT NULL,
+  `pairs` varchar(1000) NOT NULL DEFAULT '',
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  KEY `index_webhook_subscriptions_on_tenant_id` (`tenant_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE if not exists `webhook_deliveries` (
+  `id` bigint(20) unsigned NOT NULL PRIMARY KEY AUTO_INCREMENT,
+  `subscription_id` bigint(20) unsigned NOT NULL,
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
+  `event_id` char(32) NOT NULL,
+  `event_type` varchar(50) NOT NULL,
+  `payload` mediumblob NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `attempts` int NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime NOT NULL,
+  `last_attempt_at` datetime NULL DEFAULT NULL,
+  `response_status` int NOT NULL DEFAULT 0,
+  `last_error` varchar(1000) NOT NULL DEFAULT '',
+  `locked_by` varchar(64) NOT NULL DEFAULT '',
+  `locked_until` datetime NULL DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  KEY `index_webhook_deliveries_on_status_and_next_attempt_at` (`status`, `next_attempt_at`),
+  UNIQUE KEY `index_webhook_deliveries_on_subscription_id_and_event_id` (`subscription_id`, `event_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE if not exists `outbox_events` (
+  `id` bigint(20) unsigned NOT NULL PRIMARY KEY AUTO_INCREMENT,
+  `event_type` varchar(50) NOT NULL,
+  `aggregate_type` varchar(50) NOT NULL,
+  `aggregate_id` bigint(20) unsigned NOT NULL,
+  `tenant_id` varchar(100) NOT NULL DEFAULT '',
+  `payload` mediumblob NOT NULL,
+  `occurred_at` datetime NOT NULL,
+  `published_at` datetime NULL DEFAULT NULL,
+  `locked_by` varchar(64) NOT NULL DEFAULT '',
+  `locked_until` datetime NULL DEFAULT NULL,
+  KEY `index_outbox_events_on_published_at` (`published_at`),
+  KEY `index_outbox_events_on_locked_by` (`locked_by`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
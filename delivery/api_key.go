@@ -0,0 +1,112 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/usecase/api_key"
+)
+
+type APIKeyHandler struct {
+	uc api_key.APIKeyUsecase
+}
+
+func NewAPIKeyHandler(usecase api_key.APIKeyUsecase) APIKeyHandler {
+	return APIKeyHandler{uc: usecase}
+}
+
+func (ah *APIKeyHandler) Register(r *httprouter.Router) error {
+	if r == nil {
+		return errors.New("Passed router cannot be nil or empty")
+	}
+
+	r.GET("/v1/api-keys", auth.Require(auth.ManageAPIKeys, ah.GetAPIKeys))
+	r.POST("/v1/api-keys", auth.Require(auth.ManageAPIKeys, ah.IssueAPIKey))
+	r.DELETE("/v1/api-keys/:id", auth.Require(auth.ManageAPIKeys, ah.RevokeAPIKey))
+
+	return nil
+}
+
+func (ah *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	helper := request.NewQueryHelper(r)
+
+	params := request.APIKeyParameter{
+		Limit:          helper.GetLimit(),
+		Offset:         helper.GetOffset(),
+		IncludeRevoked: helper.GetBool("include_revoked", false),
+	}
+
+	context := r.Context()
+	keys, total, err := ah.uc.GetAPIKeys(context, &params)
+
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	if len(keys) <= 0 {
+		m := response.MetaInfo{HTTPStatus: http.StatusNoContent}
+		response.Write(w, response.BuildSuccess(keys, m), http.StatusOK)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+		Offset:     params.Offset,
+		Limit:      params.Limit,
+		Total:      total,
+	}
+	response.Write(w, response.BuildSuccess(keys, meta), http.StatusOK)
+	return
+}
+
+func (ah *APIKeyHandler) IssueAPIKey(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	decoder := json.NewDecoder(r.Body)
+	var key entity.APIKey
+	if err := decoder.Decode(&key); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+	defer r.Body.Close()
+
+	context := r.Context()
+	if err := ah.uc.IssueAPIKey(context, &key); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusCreated,
+	}
+	response.Write(w, response.BuildSuccess(key, meta), http.StatusCreated)
+	return
+}
+
+func (ah *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	keyID, err := strconv.ParseInt(p.ByName("id"), 10, 64)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	context := r.Context()
+	if err := ah.uc.RevokeAPIKey(context, keyID); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return
+}
@@ -0,0 +1,75 @@
+package delivery_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/delivery"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// authenticateAs makes every request of the test handler come from a caller with role
+func authenticateAs(role auth.Role, clientID string) handler.Registration {
+	return handler.WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := auth.WithPrincipal(r.Context(), &auth.Principal{Subject: "test", ClientID: clientID, Role: role})
+			ctx = request.WithClientID(ctx, clientID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+}
+
+func TestAPIKeyRequest(t *testing.T) {
+	uc := new(mocks.APIKeyUsecase)
+	APIKeyHandler := delivery.NewAPIKeyHandler(uc)
+
+	stubKeys := []entity.APIKey{{ID: 1, Name: "pricing", ClientID: "pricing", Role: "reader", Prefix: "whim_0123abcd"}}
+
+	uc.On("IssueAPIKey", mock.Anything, mock.Anything).Return(nil)
+	uc.On("GetAPIKeys", mock.Anything, mock.Anything).Return(stubKeys, int64(1), nil)
+	uc.On("RevokeAPIKey", mock.Anything, int64(1)).Return(nil)
+	uc.On("RevokeAPIKey", mock.Anything, int64(2)).Return(fmt.Errorf("Not Found"))
+
+	testCases := []struct {
+		name           string
+		role           auth.Role
+		method         string
+		endpoint       string
+		payload        []byte
+		expectedStatus int
+	}{
+		{"Issue key", auth.RoleAdmin, "POST", "/v1/api-keys", []byte(`{"name":"pricing","role":"reader"}`), http.StatusCreated},
+		{"List keys", auth.RoleAdmin, "GET", "/v1/api-keys", nil, http.StatusOK},
+		{"Revoke key", auth.RoleAdmin, "DELETE", "/v1/api-keys/1", nil, http.StatusNoContent},
+		{"Revoke unknown key", auth.RoleAdmin, "DELETE", "/v1/api-keys/2", nil, http.StatusNotFound},
+		{"Issue key as rate admin", auth.RoleRateAdmin, "POST", "/v1/api-keys", []byte(`{"name":"pricing","role":"admin"}`), http.StatusForbidden},
+		{"List keys as reader", auth.RoleReader, "GET", "/v1/api-keys", nil, http.StatusForbidden},
+		{"List keys anonymously", "", "GET", "/v1/api-keys", nil, http.StatusUnauthorized},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			registrations := []handler.Registration{&APIKeyHandler}
+			if testCase.role != "" {
+				registrations = append(registrations, authenticateAs(testCase.role, "test"))
+			}
+			h := handler.NewHandler(registrations...)
+
+			stubRequest := NewConversionHTTPRequest(testCase.method, testCase.endpoint, "", testCase.payload)
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, stubRequest)
+			assert.Equal(t, testCase.expectedStatus, recorder.Code)
+		})
+	}
+
+	uc.AssertNumberOfCalls(t, "IssueAPIKey", 1)
+	uc.AssertNumberOfCalls(t, "GetAPIKeys", 1)
+}
@@ -7,12 +7,25 @@ import (
 	"strconv"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
 	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/app/response"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/usecase/conversion"
 )
 
+// conversionPatchSchema lists the fields accepted by PATCH /v1/conversions/:id
+var conversionPatchSchema = request.PatchSchema{
+	"id":               request.PatchImmutable,
+	"tenant_id":        request.PatchImmutable,
+	"currency_id_from": request.PatchImmutable,
+	"currency_id_to":   request.PatchImmutable,
+	"rate":             request.PatchRequired,
+	"version":          request.PatchImmutable,
+	"created_at":       request.PatchImmutable,
+	"updated_at":       request.PatchImmutable,
+}
+
 type ConversionHandler struct {
 	uc conversion.ConversionUsecase
 }
@@ -26,10 +39,10 @@ func (ch *ConversionHandler) Register(r *httprouter.Router) error {
 		return errors.New("Passed router cannot be nil or empty")
 	}
 
-	r.GET("/v1/conversions", ch.GetConversions)
-	r.GET("/v1/conversions/:id", ch.GetConversion)
-	r.POST("/v1/conversions", ch.CreateConversion)
-	r.PATCH("/v1/conversions/:id", ch.UpdateConversion)
+	r.GET("/v1/conversions", auth.Require(auth.ReadConversions, ch.GetConversions))
+	r.GET("/v1/conversions/:id", auth.Require(auth.ReadConversions, ch.GetConversion))
+	r.POST("/v1/conversions", auth.Require(auth.WriteConversions, ch.CreateConversion))
+	r.PATCH("/v1/conversions/:id", auth.Require(auth.WriteConversions, ch.UpdateConversion))
 
 	return nil
 }
@@ -38,8 +51,8 @@ func (ch *ConversionHandler) GetConversions(w http.ResponseWriter, r *http.Reque
 	helper := request.NewQueryHelper(r)
 
 	params := request.ConversionParameter{
-		Limit:          helper.GetInt("limit", 10),
-		Offset:         helper.GetInt("offset", 0),
+		Limit:          helper.GetLimit(),
+		Offset:         helper.GetOffset(),
 		CurrencyIDFrom: helper.GetInt64("currency_id_from", 0),
 		CurrencyIDTo:   helper.GetInt64("currency_id_to", 0),
 	}
@@ -86,6 +99,10 @@ func (ch *ConversionHandler) GetConversion(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if notModified(w, r, conversion.Version) {
+		return
+	}
+
 	meta := response.MetaInfo{
 		HTTPStatus: http.StatusOK,
 	}
@@ -125,22 +142,22 @@ func (ch *ConversionHandler) UpdateConversion(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	var conversion entity.Conversion
-	if err := decoder.Decode(&conversion); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
 		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
 		response.Write(w, errBody, httpStatus)
 		return
 	}
-	defer r.Body.Close()
 
-	context := r.Context()
-	if err := ch.uc.UpdateConversion(context, conversionID, &conversion); err != nil {
+	patch, err := request.DecodeMergePatch(r.Body, conversionPatchSchema)
+	if err != nil {
 		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
 		response.Write(w, errBody, httpStatus)
 		return
 	}
+	defer r.Body.Close()
 
+	context := r.Context()
 	curr, err := ch.uc.GetConversion(context, conversionID)
 	if err != nil {
 		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
@@ -148,6 +165,39 @@ func (ch *ConversionHandler) UpdateConversion(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if version != 0 && version != curr.Version {
+		errBody, httpStatus := response.BuildErrorAndStatus(errors.New("Precondition Failed: conversion has been modified"), "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	if len(patch) > 0 {
+		conversion := *curr
+		conversion.Version = version
+		if raw, ok := patch["rate"]; ok {
+			if err := json.Unmarshal(raw, &conversion.Rate); err != nil {
+				errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+				response.Write(w, errBody, httpStatus)
+				return
+			}
+		}
+
+		if err := ch.uc.UpdateConversion(context, conversionID, &conversion); err != nil {
+			errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+			response.Write(w, errBody, httpStatus)
+			return
+		}
+
+		curr, err = ch.uc.GetConversion(context, conversionID)
+		if err != nil {
+			errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+			response.Write(w, errBody, httpStatus)
+			return
+		}
+	}
+
+	w.Header().Set("ETag", etag(curr.Version))
+
 	meta := response.MetaInfo{
 		HTTPStatus: http.StatusOK,
 	}
@@ -0,0 +1,82 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/usecase/conversion_quota"
+)
+
+type ConversionQuotaHandler struct {
+	uc conversion_quota.ConversionQuotaUsecase
+}
+
+func NewConversionQuotaHandler(usecase conversion_quota.ConversionQuotaUsecase) ConversionQuotaHandler {
+	return ConversionQuotaHandler{uc: usecase}
+}
+
+func (qh *ConversionQuotaHandler) Register(r *httprouter.Router) error {
+	if r == nil {
+		return errors.New("Passed router cannot be nil or empty")
+	}
+
+	r.GET("/v1/quota", auth.Require(auth.Convert, qh.GetOwnConversionQuota))
+	r.GET("/v1/quotas/:client_id", auth.Require(auth.ManageQuotas, qh.GetConversionQuota))
+	r.PUT("/v1/quotas/:client_id", auth.Require(auth.ManageQuotas, qh.SetConversionQuota))
+
+	return nil
+}
+
+func (qh *ConversionQuotaHandler) writeQuota(w http.ResponseWriter, r *http.Request, clientID string) {
+	quota, err := qh.uc.GetConversionQuota(r.Context(), clientID)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+	}
+	response.Write(w, response.BuildSuccess(quota, meta), http.StatusOK)
+}
+
+// GetOwnConversionQuota returns the quota of the calling client
+func (qh *ConversionQuotaHandler) GetOwnConversionQuota(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	qh.writeQuota(w, r, request.ClientID(r.Context()))
+}
+
+func (qh *ConversionQuotaHandler) GetConversionQuota(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	qh.writeQuota(w, r, p.ByName("client_id"))
+}
+
+func (qh *ConversionQuotaHandler) SetConversionQuota(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	decoder := json.NewDecoder(r.Body)
+	var body entity.ConversionQuota
+	if err := decoder.Decode(&body); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+	defer r.Body.Close()
+
+	context := r.Context()
+	quota, err := qh.uc.SetConversionQuota(context, p.ByName("client_id"), body.Limit)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+	}
+	response.Write(w, response.BuildSuccess(quota, meta), http.StatusOK)
+	return
+}
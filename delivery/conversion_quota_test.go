@@ -0,0 +1,55 @@
+package delivery_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/delivery"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestConversionQuotaRequest(t *testing.T) {
+	uc := new(mocks.ConversionQuotaUsecase)
+	quotaHandler := delivery.NewConversionQuotaHandler(uc)
+
+	uc.On("GetConversionQuota", mock.Anything, "pricing").Return(&entity.ConversionQuota{ClientID: "pricing", Limit: 100, Used: 3}, nil)
+	uc.On("GetConversionQuota", mock.Anything, "billing").Return(&entity.ConversionQuota{ClientID: "billing"}, nil)
+	uc.On("SetConversionQuota", mock.Anything, "billing", int64(500)).Return(&entity.ConversionQuota{ClientID: "billing", Limit: 500}, nil)
+	uc.On("SetConversionQuota", mock.Anything, "billing", int64(-1)).Return(nil, fmt.Errorf("Bad Request: limit cannot be negative"))
+
+	testCases := []struct {
+		name           string
+		role           auth.Role
+		method         string
+		endpoint       string
+		payload        []byte
+		expectedStatus int
+	}{
+		{"Get own quota", auth.RoleReader, "GET", "/v1/quota", nil, http.StatusOK},
+		{"Get client quota", auth.RoleAdmin, "GET", "/v1/quotas/billing", nil, http.StatusOK},
+		{"Get client quota as reader", auth.RoleReader, "GET", "/v1/quotas/billing", nil, http.StatusForbidden},
+		{"Set client quota", auth.RoleAdmin, "PUT", "/v1/quotas/billing", []byte(`{"limit":500}`), http.StatusOK},
+		{"Set negative quota", auth.RoleAdmin, "PUT", "/v1/quotas/billing", []byte(`{"limit":-1}`), http.StatusBadRequest},
+		{"Set own quota as reader", auth.RoleReader, "PUT", "/v1/quotas/pricing", []byte(`{"limit":500}`), http.StatusForbidden},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			h := handler.NewHandler(authenticateAs(testCase.role, "pricing"), &quotaHandler)
+			req := httptest.NewRequest(testCase.method, "http://localhost"+testCase.endpoint, bytes.NewBuffer(testCase.payload))
+			req.Header.Set("X-Client-ID", "billing")
+
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+			assert.Equal(t, testCase.expectedStatus, recorder.Code)
+		})
+	}
+}
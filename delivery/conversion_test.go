@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/bxcodec/faker"
+	"github.com/rbpermadi/whim_assignment/app/auth"
 	"github.com/rbpermadi/whim_assignment/delivery"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/handler"
@@ -37,7 +38,7 @@ func newConversionHandler() (http.Handler, *mocks.ConversionUsecase) {
 	uc := new(mocks.ConversionUsecase)
 	ConversionHandler := delivery.NewConversionHandler(uc)
 
-	h := handler.NewHandler(&ConversionHandler)
+	h := handler.NewHandler(authenticateAs(auth.RoleAdmin, "test"), &ConversionHandler)
 	return h, uc
 }
 
@@ -68,7 +69,9 @@ func TestConversionRequest(t *testing.T) {
 	uc.On("CreateConversion", mock.Anything, mock.Anything).Return(nil)
 	uc.On("GetConversions", mock.Anything, mock.Anything).Return(stubConversions, int64(len(stubConversions)), nil)
 	uc.On("GetConversion", mock.Anything, mock.AnythingOfType("int64")).Return(&singleConversion, nil)
-	uc.On("UpdateConversion", mock.Anything, mock.AnythingOfType("int64"), mock.Anything).Return(nil)
+	uc.On("UpdateConversion", mock.Anything, mock.AnythingOfType("int64"), mock.MatchedBy(func(c *entity.Conversion) bool {
+		return c.Rate == 14500.5 && c.CurrencyIDFrom == singleConversion.CurrencyIDFrom && c.CurrencyIDTo == singleConversion.CurrencyIDTo
+	})).Return(nil).Once()
 
 	testCases := []requestConversionTestCase{
 		{
@@ -94,9 +97,30 @@ func TestConversionRequest(t *testing.T) {
 			name:           "Update existing conversion",
 			method:         "PATCH",
 			endpoint:       fmt.Sprintf("/v1/conversions/%v", singleConversion.ID),
-			payload:        examplePayload,
+			payload:        []byte(`{"rate":14500.5}`),
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "Update conversion currency pair",
+			method:         "PATCH",
+			endpoint:       fmt.Sprintf("/v1/conversions/%v", singleConversion.ID),
+			payload:        []byte(`{"currency_id_from":3}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Update conversion with unknown field",
+			method:         "PATCH",
+			endpoint:       fmt.Sprintf("/v1/conversions/%v", singleConversion.ID),
+			payload:        []byte(`{"rat":1}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Update conversion with invalid rate",
+			method:         "PATCH",
+			endpoint:       fmt.Sprintf("/v1/conversions/%v", singleConversion.ID),
+			payload:        []byte(`{"rate":"high"}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/app/response"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/usecase/convert_currencies"
@@ -24,11 +27,83 @@ func (ch *ConvertCurrenciesHandler) Register(r *httprouter.Router) error {
 		return errors.New("Passed router cannot be nil or empty")
 	}
 
-	r.POST("/v1/convert-currencies", ch.CreateConvertCurrencies)
+	r.GET("/v1/convert-currencies", auth.Require(auth.Convert, ch.GetConvertCurrencies))
+	r.GET("/v1/convert-currencies/:id", auth.Require(auth.Convert, ch.GetConvertCurrency))
+	r.POST("/v1/convert-currencies", auth.Require(auth.Convert, ch.CreateConvertCurrencies))
 
 	return nil
 }
 
+func (ch *ConvertCurrenciesHandler) GetConvertCurrencies(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	helper := request.NewQueryHelper(r)
+
+	params := request.ConvertCurrenciesParameter{
+		Limit:          helper.GetLimit(),
+		Offset:         helper.GetOffset(),
+		CurrencyIDFrom: helper.GetInt64("currency_id_from", 0),
+		CurrencyIDTo:   helper.GetInt64("currency_id_to", 0),
+		ClientID:       helper.GetString("client_id", ""),
+		CreatedFrom:    helper.GetDate("created_from"),
+		CreatedTo:      helper.GetDate("created_to"),
+	}
+
+	context := r.Context()
+	if p := auth.PrincipalFromContext(context); !p.Allows(auth.AuditConversions) {
+		params.ClientID = p.ClientID
+	}
+	converts, total, err := ch.uc.GetConvertCurrencies(context, &params)
+
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	if len(converts) <= 0 {
+		m := response.MetaInfo{HTTPStatus: http.StatusNoContent}
+		response.Write(w, response.BuildSuccess(converts, m), http.StatusOK)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+		Offset:     params.Offset,
+		Limit:      params.Limit,
+		Total:      total,
+	}
+	response.Write(w, response.BuildSuccess(converts, meta), http.StatusOK)
+	return
+}
+
+func (ch *ConvertCurrenciesHandler) GetConvertCurrency(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	convertID, err := strconv.ParseInt(p.ByName("id"), 10, 64)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	context := r.Context()
+
+	convert, err := ch.uc.GetConvertCurrency(context, convertID)
+	if err == nil {
+		if p := auth.PrincipalFromContext(context); !p.Allows(auth.AuditConversions) && p.ClientID != convert.ClientID {
+			err = errors.New("Not Found")
+		}
+	}
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+	}
+	response.Write(w, response.BuildSuccess(convert, meta), http.StatusOK)
+	return
+}
+
 func (ch *ConvertCurrenciesHandler) CreateConvertCurrencies(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	decoder := json.NewDecoder(r.Body)
 	var convert entity.ConvertCurrencies
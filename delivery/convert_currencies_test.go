@@ -0,0 +1,124 @@
+package delivery_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/delivery"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newConvertCurrenciesHandler(role auth.Role) (http.Handler, *mocks.ConvertCurrenciesUsecase) {
+	uc := new(mocks.ConvertCurrenciesUsecase)
+	ConvertCurrenciesHandler := delivery.NewConvertCurrenciesHandler(uc)
+
+	h := handler.NewHandler(authenticateAs(role, "client-1"), &ConvertCurrenciesHandler)
+	return h, uc
+}
+
+func TestConvertCurrenciesRequest(t *testing.T) {
+	handler, uc := newConvertCurrenciesHandler(auth.RoleReader)
+	single := entity.ConvertCurrencies{ID: 1, ConversionID: 1, CurrencyIDFrom: 1, CurrencyIDTo: 2, Amount: 10, Rate: 2, Result: 20, ClientID: "client-1"}
+	examplePayload, err := json.Marshal(entity.ConvertCurrencies{CurrencyIDFrom: 1, CurrencyIDTo: 2, Amount: 10})
+	assert.NoError(t, err)
+
+	clientInContext := mock.MatchedBy(func(ctx context.Context) bool {
+		return request.ClientID(ctx) == "client-1" && request.IdempotencyKey(ctx) == "key-1"
+	})
+	uc.On("CreateConvertCurrencies", clientInContext, mock.Anything).Return(nil)
+	uc.On("GetConvertCurrencies", mock.Anything, mock.MatchedBy(func(p *request.ConvertCurrenciesParameter) bool {
+		return p.ClientID == "client-1" && p.CreatedFrom != nil
+	})).Return([]entity.ConvertCurrencies{single}, int64(1), nil)
+	uc.On("GetConvertCurrency", mock.Anything, int64(1)).Return(&single, nil)
+	uc.On("GetConvertCurrency", mock.Anything, int64(2)).Return(nil, fmt.Errorf("Not Found"))
+
+	testCases := []requestConversionTestCase{
+		{
+			name:           "Convert currencies",
+			method:         "POST",
+			endpoint:       "/v1/convert-currencies",
+			payload:        examplePayload,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "List past conversions",
+			method:         "GET",
+			endpoint:       "/v1/convert-currencies?client_id=client-1&created_from=2020-01-01T00:00:00Z",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Get past conversion",
+			method:         "GET",
+			endpoint:       "/v1/convert-currencies/1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Get missing conversion",
+			method:         "GET",
+			endpoint:       "/v1/convert-currencies/2",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stubRequest := NewConversionHTTPRequest(testCase.method, testCase.endpoint, "", testCase.payload)
+			stubRequest.Header.Set("X-Client-ID", "spoofed")
+			stubRequest.Header.Set("Idempotency-Key", "key-1")
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, stubRequest)
+			assert.Equal(t, testCase.expectedStatus, recorder.Code)
+		})
+	}
+
+	uc.AssertExpectations(t)
+}
+
+func TestConvertCurrenciesClientScope(t *testing.T) {
+	other := entity.ConvertCurrencies{ID: 3, ClientID: "client-2"}
+
+	t.Run("reader cannot read other clients", func(t *testing.T) {
+		handler, uc := newConvertCurrenciesHandler(auth.RoleReader)
+		uc.On("GetConvertCurrency", mock.Anything, int64(3)).Return(&other, nil)
+		uc.On("GetConvertCurrencies", mock.Anything, mock.MatchedBy(func(p *request.ConvertCurrenciesParameter) bool {
+			return p.ClientID == "client-1"
+		})).Return([]entity.ConvertCurrencies{}, int64(0), nil)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, NewConversionHTTPRequest("GET", "/v1/convert-currencies/3", "", nil))
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, NewConversionHTTPRequest("GET", "/v1/convert-currencies?client_id=client-2", "", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		uc.AssertExpectations(t)
+	})
+
+	t.Run("admin reads every client", func(t *testing.T) {
+		handler, uc := newConvertCurrenciesHandler(auth.RoleAdmin)
+		uc.On("GetConvertCurrency", mock.Anything, int64(3)).Return(&other, nil)
+		uc.On("GetConvertCurrencies", mock.Anything, mock.MatchedBy(func(p *request.ConvertCurrenciesParameter) bool {
+			return p.ClientID == "client-2"
+		})).Return([]entity.ConvertCurrencies{other}, int64(1), nil)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, NewConversionHTTPRequest("GET", "/v1/convert-currencies/3", "", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, NewConversionHTTPRequest("GET", "/v1/convert-currencies?client_id=client-2", "", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		uc.AssertExpectations(t)
+	})
+}
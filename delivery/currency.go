@@ -3,17 +3,27 @@ package delivery
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
 	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/app/response"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/usecase/currency"
 )
 
+// currencyPatchSchema lists the fields accepted by PATCH /v1/currencies/:id
+var currencyPatchSchema = request.PatchSchema{
+	"id":         request.PatchImmutable,
+	"tenant_id":  request.PatchImmutable,
+	"name":       request.PatchRequired,
+	"version":    request.PatchImmutable,
+	"created_at": request.PatchImmutable,
+	"updated_at": request.PatchImmutable,
+}
+
 type CurrencyHandler struct {
 	uc currency.CurrencyUsecase
 }
@@ -27,10 +37,10 @@ func (ch *CurrencyHandler) Register(r *httprouter.Router) error {
 		return errors.New("Passed router cannot be nil or empty")
 	}
 
-	r.GET("/v1/currencies", ch.GetCurrencies)
-	r.GET("/v1/currencies/:id", ch.GetCurrency)
-	r.POST("/v1/currencies", ch.CreateCurrency)
-	r.PATCH("/v1/currencies/:id", ch.UpdateCurrency)
+	r.GET("/v1/currencies", auth.Require(auth.ReadCurrencies, ch.GetCurrencies))
+	r.GET("/v1/currencies/:id", auth.Require(auth.ReadCurrencies, ch.GetCurrency))
+	r.POST("/v1/currencies", auth.Require(auth.WriteCurrencies, ch.CreateCurrency))
+	r.PATCH("/v1/currencies/:id", auth.Require(auth.WriteCurrencies, ch.UpdateCurrency))
 
 	return nil
 }
@@ -39,8 +49,8 @@ func (ch *CurrencyHandler) GetCurrencies(w http.ResponseWriter, r *http.Request,
 	helper := request.NewQueryHelper(r)
 
 	params := request.CurrencyParameter{
-		Limit:  helper.GetInt("limit", 10),
-		Offset: helper.GetInt("offset", 0),
+		Limit:  helper.GetLimit(),
+		Offset: helper.GetOffset(),
 		Query:  helper.GetString("query", ""),
 	}
 
@@ -48,7 +58,6 @@ func (ch *CurrencyHandler) GetCurrencies(w http.ResponseWriter, r *http.Request,
 	currencies, total, err := ch.uc.GetCurrencies(context, &params)
 
 	if err != nil {
-		fmt.Println(err)
 		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
 		response.Write(w, errBody, httpStatus)
 		return
@@ -86,6 +95,10 @@ func (ch *CurrencyHandler) GetCurrency(w http.ResponseWriter, r *http.Request, p
 		return
 	}
 
+	if notModified(w, r, currency.Version) {
+		return
+	}
+
 	meta := response.MetaInfo{
 		HTTPStatus: http.StatusOK,
 	}
@@ -124,23 +137,22 @@ func (ch *CurrencyHandler) UpdateCurrency(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	var currency entity.Currency
-	if err := decoder.Decode(&currency); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
 		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
 		response.Write(w, errBody, httpStatus)
 		return
 	}
-	defer r.Body.Close()
 
-	context := r.Context()
-	if err := ch.uc.UpdateCurrency(context, currencyID, &currency); err != nil {
-		fmt.Println(err)
+	patch, err := request.DecodeMergePatch(r.Body, currencyPatchSchema)
+	if err != nil {
 		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
 		response.Write(w, errBody, httpStatus)
 		return
 	}
+	defer r.Body.Close()
 
+	context := r.Context()
 	curr, err := ch.uc.GetCurrency(context, currencyID)
 	if err != nil {
 		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
@@ -148,6 +160,39 @@ func (ch *CurrencyHandler) UpdateCurrency(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if version != 0 && version != curr.Version {
+		errBody, httpStatus := response.BuildErrorAndStatus(errors.New("Precondition Failed: currency has been modified"), "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	if len(patch) > 0 {
+		currency := *curr
+		currency.Version = version
+		if raw, ok := patch["name"]; ok {
+			if err := json.Unmarshal(raw, &currency.Name); err != nil {
+				errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+				response.Write(w, errBody, httpStatus)
+				return
+			}
+		}
+
+		if err := ch.uc.UpdateCurrency(context, currencyID, &currency); err != nil {
+			errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+			response.Write(w, errBody, httpStatus)
+			return
+		}
+
+		curr, err = ch.uc.GetCurrency(context, currencyID)
+		if err != nil {
+			errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+			response.Write(w, errBody, httpStatus)
+			return
+		}
+	}
+
+	w.Header().Set("ETag", etag(curr.Version))
+
 	meta := response.MetaInfo{
 		HTTPStatus: http.StatusOK,
 	}
@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/bxcodec/faker"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/delivery"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/handler"
@@ -37,7 +39,7 @@ func newCurrencyHandler() (http.Handler, *mocks.CurrencyUsecase) {
 	uc := new(mocks.CurrencyUsecase)
 	CurrencyHandler := delivery.NewCurrencyHandler(uc)
 
-	h := handler.NewHandler(&CurrencyHandler)
+	h := handler.NewHandler(authenticateAs(auth.RoleAdmin, "test"), &CurrencyHandler)
 	return h, uc
 }
 
@@ -68,7 +70,9 @@ func TestCurrencyRequest(t *testing.T) {
 	uc.On("CreateCurrency", mock.Anything, mock.Anything).Return(nil)
 	uc.On("GetCurrencies", mock.Anything, mock.Anything).Return(stubCurrencies, int64(len(stubCurrencies)), nil)
 	uc.On("GetCurrency", mock.Anything, mock.AnythingOfType("int64")).Return(&singleCurrency, nil)
-	uc.On("UpdateCurrency", mock.Anything, mock.AnythingOfType("int64"), mock.Anything).Return(nil)
+	uc.On("UpdateCurrency", mock.Anything, mock.AnythingOfType("int64"), mock.MatchedBy(func(c *entity.Currency) bool {
+		return c.Name == "IDR" && c.ID == singleCurrency.ID && c.CreatedAt.Equal(singleCurrency.CreatedAt)
+	})).Return(nil).Once()
 
 	testCases := []requestCurrencyTestCases{
 		{
@@ -94,9 +98,30 @@ func TestCurrencyRequest(t *testing.T) {
 			name:           "Update existing currency",
 			method:         "PATCH",
 			endpoint:       fmt.Sprintf("/v1/currencies/%v", singleCurrency.ID),
-			payload:        examplePayload,
+			payload:        []byte(`{"name":"IDR"}`),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Update currency with empty merge patch",
+			method:         "PATCH",
+			endpoint:       fmt.Sprintf("/v1/currencies/%v", singleCurrency.ID),
+			payload:        []byte(`{}`),
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "Update currency with null name",
+			method:         "PATCH",
+			endpoint:       fmt.Sprintf("/v1/currencies/%v", singleCurrency.ID),
+			payload:        []byte(`{"name":null}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Update immutable currency field",
+			method:         "PATCH",
+			endpoint:       fmt.Sprintf("/v1/currencies/%v", singleCurrency.ID),
+			payload:        examplePayload,
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -113,3 +138,96 @@ func TestCurrencyRequest(t *testing.T) {
 
 	uc.AssertExpectations(t)
 }
+
+func TestCurrencyConditionalRequest(t *testing.T) {
+	handler, uc := newCurrencyHandler()
+	singleCurrency := entity.Currency{ID: 1, Name: "IDR", Version: 3}
+	payload := []byte(`{"name":"IDR"}`)
+
+	uc.On("GetCurrency", mock.Anything, int64(1)).Return(&singleCurrency, nil)
+	uc.On("UpdateCurrency", mock.Anything, int64(1), mock.MatchedBy(func(c *entity.Currency) bool { return c.Version == 3 })).Return(nil)
+	uc.On("UpdateCurrency", mock.Anything, int64(1), mock.MatchedBy(func(c *entity.Currency) bool { return c.Version == 2 })).
+		Return(fmt.Errorf("Precondition Failed: currency has been modified"))
+
+	testCases := []struct {
+		name           string
+		method         string
+		header         string
+		value          string
+		payload        []byte
+		expectedStatus int
+	}{
+		{
+			name:           "Get currency returns ETag",
+			method:         "GET",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Get unchanged currency",
+			method:         "GET",
+			header:         "If-None-Match",
+			value:          `"3"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "Get changed currency",
+			method:         "GET",
+			header:         "If-None-Match",
+			value:          `"2"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Update with current version",
+			method:         "PATCH",
+			header:         "If-Match",
+			value:          `"3"`,
+			payload:        payload,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Update with stale version",
+			method:         "PATCH",
+			header:         "If-Match",
+			value:          `"2"`,
+			payload:        payload,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "Update with malformed If-Match",
+			method:         "PATCH",
+			header:         "If-Match",
+			value:          `"abc"`,
+			payload:        payload,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stubRequest := NewCurrencyHTTPRequest(testCase.method, "/v1/currencies/1", "", testCase.payload)
+			if testCase.header != "" {
+				stubRequest.Header.Set(testCase.header, testCase.value)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, stubRequest)
+			assert.Equal(t, testCase.expectedStatus, recorder.Code)
+			if recorder.Code < http.StatusBadRequest {
+				assert.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestCurrenciesPage(t *testing.T) {
+	handler, uc := newCurrencyHandler()
+	uc.On("GetCurrencies", mock.Anything, &request.CurrencyParameter{Limit: request.MaxLimit, Offset: 0}).
+		Return([]entity.Currency{{ID: 1, Name: "IDR"}}, int64(1), nil).Once()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, NewCurrencyHTTPRequest("GET", "/v1/currencies?limit=100000&offset=-5", "", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"limit":100`, "the page is capped")
+
+	uc.AssertExpectations(t)
+}
@@ -0,0 +1,54 @@
+package delivery
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag formats a resource version as a strong entity tag
+func etag(version int64) string {
+	return fmt.Sprintf("%q", strconv.FormatInt(version, 10))
+}
+
+// matchETag reports whether the If-Match / If-None-Match header value matches version.
+// Weak validators are compared by their opaque tag as If-None-Match requires.
+func matchETag(header string, version int64) bool {
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the version required by the If-Match header, 0 if the header is
+// absent or "*", and an error if it does not name a version
+func ifMatchVersion(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(header, "W/"), `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("Precondition Failed: If-Match must be a single ETag returned by this API")
+	}
+	return version, nil
+}
+
+// notModified writes 304 when the If-None-Match header matches version
+func notModified(w http.ResponseWriter, r *http.Request, version int64) bool {
+	w.Header().Set("ETag", etag(version))
+
+	header := r.Header.Get("If-None-Match")
+	if header == "" || !matchETag(header, version) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
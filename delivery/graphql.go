@@ -0,0 +1,85 @@
+package delivery
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/conversion"
+	"github.com/rbpermadi/whim_assignment/usecase/convert_currencies"
+	"github.com/rbpermadi/whim_assignment/usecase/currency"
+)
+
+//go:embed graphql.graphql
+var graphQLSchema string
+
+type GraphQLHandler struct {
+	schema   *graphql.Schema
+	resolver *graphQLResolver
+}
+
+// NewGraphQLHandler returns the handler of /graphql, resolving the schema of graphql.graphql
+// with the same usecases as the REST handlers. The convert mutation takes its tokens from the
+// bucket of POST /v1/convert-currencies in limiter and stores its idempotency keys in
+// idempotencyKeys, like the REST route; it is not limited when limiter is nil and ignores
+// idempotency keys when idempotencyKeys is nil.
+func NewGraphQLHandler(currencyUC currency.CurrencyUsecase, conversionUC conversion.ConversionUsecase, convertUC convert_currencies.ConvertCurrenciesUsecase,
+	limiter *handler.RateLimiter, idempotencyKeys repository.IdempotencyKeyRepo) GraphQLHandler {
+	resolver := &graphQLResolver{
+		currency:        currencyUC,
+		conversion:      conversionUC,
+		convert:         convertUC,
+		limiter:         limiter,
+		idempotencyKeys: idempotencyKeys,
+	}
+	return GraphQLHandler{
+		schema:   graphql.MustParseSchema(graphQLSchema, resolver, graphql.MaxDepth(10)),
+		resolver: resolver,
+	}
+}
+
+func (gh *GraphQLHandler) Register(r *httprouter.Router) error {
+	if r == nil {
+		return errors.New("Passed router cannot be nil or empty")
+	}
+
+	r.POST("/graphql", gh.Serve)
+
+	return nil
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Serve executes a GraphQL request. Anonymous callers get 401, the permission of each
+// field is checked by its resolver, like the permission of the matching REST route.
+func (gh *GraphQLHandler) Serve(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if auth.PrincipalFromContext(r.Context()) == nil {
+		w.Header().Add("WWW-Authenticate", `ApiKey realm="whim"`)
+		w.Header().Add("WWW-Authenticate", `Bearer realm="whim"`)
+		response.Write(w, response.BuildError([]error{response.UnauthorizedError}), response.UnauthorizedError.HTTPCode)
+		return
+	}
+
+	var req graphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+	defer r.Body.Close()
+
+	ctx := context.WithValue(r.Context(), currencyLoaderKey{}, newCurrencyLoader(gh.resolver.currency))
+	response.Write(w, gh.schema.Exec(ctx, req.Query, req.OperationName, req.Variables), http.StatusOK)
+}
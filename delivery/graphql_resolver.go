@@ -0,0 +1,402 @@
+package delivery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/conversion"
+	"github.com/rbpermadi/whim_assignment/usecase/convert_currencies"
+	"github.com/rbpermadi/whim_assignment/usecase/currency"
+)
+
+type graphQLResolver struct {
+	currency        currency.CurrencyUsecase
+	conversion      conversion.ConversionUsecase
+	convert         convert_currencies.ConvertCurrenciesUsecase
+	limiter         *handler.RateLimiter
+	idempotencyKeys repository.IdempotencyKeyRepo
+}
+
+// graphQLError is the error of a field, with the code of the REST error. Unexpected errors
+// are reported without their message, like on the REST API.
+type graphQLError struct {
+	message string
+	code    int
+}
+
+func (e *graphQLError) Error() string {
+	return e.message
+}
+
+func (e *graphQLError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func toGraphQLError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var ce response.CustomError
+	if errors.As(err, &ce) {
+		return &graphQLError{message: ce.Message, code: ce.Code}
+	}
+	body, _ := response.BuildErrorAndStatus(err, "")
+	return &graphQLError{message: body.Errors[0].Message, code: body.Errors[0].Code}
+}
+
+// allow returns the error of a field the caller is not granted perm for
+func allow(ctx context.Context, perm auth.Permission) error {
+	if !auth.PrincipalFromContext(ctx).Allows(perm) {
+		return &graphQLError{message: response.ForbiddenError.Message, code: response.ForbiddenError.Code}
+	}
+	return nil
+}
+
+func parseID(id graphql.ID) (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0, &graphQLError{message: "Bad Request: invalid id " + strconv.Quote(string(id)), code: response.BadRequestError.Code}
+	}
+	return n, nil
+}
+
+func toID(id int64) graphql.ID {
+	return graphql.ID(strconv.FormatInt(id, 10))
+}
+
+func (r *graphQLResolver) Currency(ctx context.Context, args struct{ ID graphql.ID }) (*currencyResolver, error) {
+	if err := allow(ctx, auth.ReadCurrencies); err != nil {
+		return nil, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cry, err := r.currency.GetCurrency(ctx, id)
+	if err != nil && err.Error() == "Not Found" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	loaderFrom(ctx).Prime(*cry)
+	return &currencyResolver{root: r, c: *cry}, nil
+}
+
+func (r *graphQLResolver) Currencies(ctx context.Context, args struct {
+	Limit  int32
+	Offset int32
+	Query  *string
+}) ([]*currencyResolver, error) {
+	if err := allow(ctx, auth.ReadCurrencies); err != nil {
+		return nil, err
+	}
+
+	params := request.CurrencyParameter{}
+	params.Limit, params.Offset = request.Page(int(args.Limit), int(args.Offset))
+	if args.Query != nil {
+		params.Query = *args.Query
+	}
+	currencies, _, err := r.currency.GetCurrencies(ctx, &params)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	loaderFrom(ctx).Prime(currencies...)
+	resolvers := make([]*currencyResolver, len(currencies))
+	for i, c := range currencies {
+		resolvers[i] = &currencyResolver{root: r, c: c}
+	}
+	return resolvers, nil
+}
+
+func (r *graphQLResolver) Conversions(ctx context.Context, args struct {
+	Limit          int32
+	Offset         int32
+	CurrencyIDFrom *graphql.ID
+	CurrencyIDTo   *graphql.ID
+}) ([]*conversionResolver, error) {
+	params := request.ConversionParameter{}
+	params.Limit, params.Offset = request.Page(int(args.Limit), int(args.Offset))
+	for _, f := range []struct {
+		id  *graphql.ID
+		dst *int64
+	}{{args.CurrencyIDFrom, &params.CurrencyIDFrom}, {args.CurrencyIDTo, &params.CurrencyIDTo}} {
+		if f.id == nil {
+			continue
+		}
+		id, err := parseID(*f.id)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = id
+	}
+	return r.conversions(ctx, &params)
+}
+
+func (r *graphQLResolver) conversions(ctx context.Context, params *request.ConversionParameter) ([]*conversionResolver, error) {
+	if err := allow(ctx, auth.ReadConversions); err != nil {
+		return nil, err
+	}
+
+	conversions, _, err := r.conversion.GetConversions(ctx, params)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	loader := loaderFrom(ctx)
+	resolvers := make([]*conversionResolver, len(conversions))
+	for i, c := range conversions {
+		loader.Want(c.CurrencyIDFrom, c.CurrencyIDTo)
+		resolvers[i] = &conversionResolver{root: r, c: c}
+	}
+	return resolvers, nil
+}
+
+// Convert converts an amount like POST /v1/convert-currencies: it takes a token of the same
+// rate limit, and a conversion with an idempotencyKey is made once, the calls repeating the key
+// getting the first conversion. A failed conversion is not stored, so it can be retried.
+func (r *graphQLResolver) Convert(ctx context.Context, args struct {
+	CurrencyIDFrom graphql.ID
+	CurrencyIDTo   graphql.ID
+	Amount         float64
+	IdempotencyKey *string
+}) (*convertCurrenciesResolver, error) {
+	if err := allow(ctx, auth.Convert); err != nil {
+		return nil, err
+	}
+	from, err := parseID(args.CurrencyIDFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseID(args.CurrencyIDTo)
+	if err != nil {
+		return nil, err
+	}
+
+	// the caller is authenticated, so it is limited by its credentials and not its address
+	if r.limiter != nil {
+		if _, ok := r.limiter.Allow(ctx, http.MethodPost, "/v1/convert-currencies", ""); !ok {
+			return nil, toGraphQLError(response.TooManyRequestsError)
+		}
+	}
+
+	convert := entity.ConvertCurrencies{CurrencyIDFrom: from, CurrencyIDTo: to, Amount: args.Amount}
+	if args.IdempotencyKey == nil || r.idempotencyKeys == nil {
+		err = r.convert.CreateConvertCurrencies(ctx, &convert)
+	} else {
+		err = r.convertOnce(ctx, *args.IdempotencyKey, &convert)
+	}
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	loaderFrom(ctx).Want(from, to)
+	return &convertCurrenciesResolver{root: r, c: convert}, nil
+}
+
+// convertOnce makes the conversion of key, or sets convert to the conversion already made with it
+func (r *graphQLResolver) convertOnce(ctx context.Context, key string, convert *entity.ConvertCurrencies) error {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("mutation convert %d %d %v", convert.CurrencyIDFrom, convert.CurrencyIDTo, convert.Amount)))
+
+	var err error
+	stored, storeErr := handler.Idempotent(ctx, r.idempotencyKeys, key, hex.EncodeToString(hash[:]), func(ek *entity.IdempotencyKey) bool {
+		if err = r.convert.CreateConvertCurrencies(ctx, convert); err != nil {
+			return false
+		}
+		ek.ResponseStatus = http.StatusCreated
+		ek.ResponseBody, err = json.Marshal(convert)
+		return err == nil
+	})
+	if storeErr != nil {
+		return storeErr
+	}
+	if stored != nil {
+		return json.Unmarshal(stored.ResponseBody, convert)
+	}
+	return err
+}
+
+// loadCurrency resolves a currency referred to by a conversion, through the loader of the request
+func (r *graphQLResolver) loadCurrency(ctx context.Context, id int64) (*currencyResolver, error) {
+	if err := allow(ctx, auth.ReadCurrencies); err != nil {
+		return nil, err
+	}
+
+	cry, err := loaderFrom(ctx).Load(ctx, id)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	if cry == nil {
+		return nil, nil
+	}
+	return &currencyResolver{root: r, c: *cry}, nil
+}
+
+type currencyResolver struct {
+	root *graphQLResolver
+	c    entity.Currency
+}
+
+func (r *currencyResolver) ID() graphql.ID          { return toID(r.c.ID) }
+func (r *currencyResolver) TenantID() string        { return r.c.TenantID }
+func (r *currencyResolver) Name() string            { return r.c.Name }
+func (r *currencyResolver) Version() int32          { return int32(r.c.Version) }
+func (r *currencyResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.c.CreatedAt} }
+func (r *currencyResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.c.UpdatedAt} }
+
+func (r *currencyResolver) Conversions(ctx context.Context, args struct {
+	Limit  int32
+	Offset int32
+}) ([]*conversionResolver, error) {
+	params := request.ConversionParameter{CurrencyIDFrom: r.c.ID}
+	params.Limit, params.Offset = request.Page(int(args.Limit), int(args.Offset))
+	return r.root.conversions(ctx, &params)
+}
+
+type conversionResolver struct {
+	root *graphQLResolver
+	c    entity.Conversion
+}
+
+func (r *conversionResolver) ID() graphql.ID             { return toID(r.c.ID) }
+func (r *conversionResolver) TenantID() string           { return r.c.TenantID }
+func (r *conversionResolver) CurrencyIDFrom() graphql.ID { return toID(r.c.CurrencyIDFrom) }
+func (r *conversionResolver) CurrencyIDTo() graphql.ID   { return toID(r.c.CurrencyIDTo) }
+func (r *conversionResolver) Rate() float64              { return r.c.Rate }
+func (r *conversionResolver) Version() int32             { return int32(r.c.Version) }
+func (r *conversionResolver) CreatedAt() graphql.Time    { return graphql.Time{Time: r.c.CreatedAt} }
+func (r *conversionResolver) UpdatedAt() graphql.Time    { return graphql.Time{Time: r.c.UpdatedAt} }
+
+func (r *conversionResolver) From(ctx context.Context) (*currencyResolver, error) {
+	return r.root.loadCurrency(ctx, r.c.CurrencyIDFrom)
+}
+
+func (r *conversionResolver) To(ctx context.Context) (*currencyResolver, error) {
+	return r.root.loadCurrency(ctx, r.c.CurrencyIDTo)
+}
+
+type convertCurrenciesResolver struct {
+	root *graphQLResolver
+	c    entity.ConvertCurrencies
+}
+
+func (r *convertCurrenciesResolver) ID() graphql.ID             { return toID(r.c.ID) }
+func (r *convertCurrenciesResolver) ConversionID() graphql.ID   { return toID(r.c.ConversionID) }
+func (r *convertCurrenciesResolver) CurrencyIDFrom() graphql.ID { return toID(r.c.CurrencyIDFrom) }
+func (r *convertCurrenciesResolver) CurrencyIDTo() graphql.ID   { return toID(r.c.CurrencyIDTo) }
+func (r *convertCurrenciesResolver) Amount() float64            { return r.c.Amount }
+func (r *convertCurrenciesResolver) Rate() float64              { return r.c.Rate }
+func (r *convertCurrenciesResolver) Result() float64            { return r.c.Result }
+func (r *convertCurrenciesResolver) ClientID() string           { return r.c.ClientID }
+func (r *convertCurrenciesResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.c.CreatedAt}
+}
+
+func (r *convertCurrenciesResolver) From(ctx context.Context) (*currencyResolver, error) {
+	return r.root.loadCurrency(ctx, r.c.CurrencyIDFrom)
+}
+
+func (r *convertCurrenciesResolver) To(ctx context.Context) (*currencyResolver, error) {
+	return r.root.loadCurrency(ctx, r.c.CurrencyIDTo)
+}
+
+type currencyLoaderKey struct{}
+
+func loaderFrom(ctx context.Context) *currencyLoader {
+	l, _ := ctx.Value(currencyLoaderKey{}).(*currencyLoader)
+	return l
+}
+
+// currencyLoader batches the currency lookups of a GraphQL request. Resolvers returning
+// conversions announce the currencies they refer to with Want, and the first Load of a
+// currency not loaded yet fetches it with every announced one in a single GetCurrencies
+// call, instead of a GetCurrency call per conversion. Loaded currencies are kept for the
+// rest of the request.
+type currencyLoader struct {
+	uc currency.CurrencyUsecase
+
+	mu     sync.Mutex
+	loaded map[int64]*entity.Currency
+	wanted map[int64]bool
+}
+
+func newCurrencyLoader(uc currency.CurrencyUsecase) *currencyLoader {
+	return &currencyLoader{
+		uc:     uc,
+		loaded: map[int64]*entity.Currency{},
+		wanted: map[int64]bool{},
+	}
+}
+
+// Prime keeps currencies already fetched by a resolver
+func (l *currencyLoader) Prime(currencies ...entity.Currency) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range currencies {
+		c := currencies[i]
+		l.loaded[c.ID] = &c
+		delete(l.wanted, c.ID)
+	}
+}
+
+// Want announces currencies that are likely to be loaded
+func (l *currencyLoader) Want(ids ...int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range ids {
+		if _, ok := l.loaded[id]; !ok {
+			l.wanted[id] = true
+		}
+	}
+}
+
+// Load returns the currency with id, or nil if it does not exist or is not visible to the
+// caller. Concurrent loads wait for the batch in flight, which usually holds their currency.
+func (l *currencyLoader) Load(ctx context.Context, id int64) (*entity.Currency, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c, ok := l.loaded[id]; ok {
+		return c, nil
+	}
+
+	ids := []int64{id}
+	for w := range l.wanted {
+		if w != id {
+			ids = append(ids, w)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	currencies, _, err := l.uc.GetCurrencies(ctx, &request.CurrencyParameter{Limit: len(ids), IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		l.loaded[id] = nil
+		delete(l.wanted, id)
+	}
+	for i := range currencies {
+		c := currencies[i]
+		l.loaded[c.ID] = &c
+	}
+	return l.loaded[id], nil
+}
@@ -0,0 +1,234 @@
+package delivery_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/delivery"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/currency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+type graphQLUsecases struct {
+	currency   *mocks.CurrencyUsecase
+	conversion *mocks.ConversionUsecase
+	convert    *mocks.ConvertCurrenciesUsecase
+}
+
+func newGraphQLHandler(auth ...handler.Registration) (http.Handler, graphQLUsecases) {
+	return newLimitedGraphQLHandler(nil, nil, auth...)
+}
+
+// newLimitedGraphQLHandler is newGraphQLHandler with the convert mutation limited by limiter
+// and storing its idempotency keys in keys
+func newLimitedGraphQLHandler(limiter *handler.RateLimiter, keys repository.IdempotencyKeyRepo, auth ...handler.Registration) (http.Handler, graphQLUsecases) {
+	uc := graphQLUsecases{
+		currency:   new(mocks.CurrencyUsecase),
+		conversion: new(mocks.ConversionUsecase),
+		convert:    new(mocks.ConvertCurrenciesUsecase),
+	}
+	graphQLHandler := delivery.NewGraphQLHandler(uc.currency, uc.conversion, uc.convert, limiter, keys)
+	return handler.NewHandler(append(auth, &graphQLHandler)...), uc
+}
+
+func execGraphQL(t *testing.T, h http.Handler, query string, variables map[string]interface{}) graphQLResponse {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp graphQLResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestGraphQLBatchesCurrencies(t *testing.T) {
+	h, uc := newGraphQLHandler(authenticateAs(auth.RoleReader, "c1"))
+
+	uc.conversion.On("GetConversions", mock.Anything, &request.ConversionParameter{Limit: 10}).Return([]entity.Conversion{
+		{ID: 1, CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 15000},
+		{ID: 2, CurrencyIDFrom: 1, CurrencyIDTo: 3, Rate: 11000},
+		{ID: 3, CurrencyIDFrom: 3, CurrencyIDTo: 2, Rate: 1.4},
+	}, int64(3), nil).Once()
+	uc.currency.On("GetCurrencies", mock.Anything, &request.CurrencyParameter{Limit: 3, IDs: []int64{1, 2, 3}}).Return([]entity.Currency{
+		{ID: 1, Name: "USD"}, {ID: 2, Name: "IDR"}, {ID: 3, Name: "SGD"},
+	}, int64(3), nil).Once()
+
+	resp := execGraphQL(t, h, `{ conversions { id rate from { name } to { name } } }`, nil)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"conversions": [
+		{"id": "1", "rate": 15000, "from": {"name": "USD"}, "to": {"name": "IDR"}},
+		{"id": "2", "rate": 11000, "from": {"name": "USD"}, "to": {"name": "SGD"}},
+		{"id": "3", "rate": 1.4, "from": {"name": "SGD"}, "to": {"name": "IDR"}}
+	]}`, string(resp.Data))
+
+	uc.currency.AssertNumberOfCalls(t, "GetCurrencies", 1)
+	uc.currency.AssertNotCalled(t, "GetCurrency", mock.Anything, mock.Anything)
+}
+
+func TestGraphQLCurrencyWithConversions(t *testing.T) {
+	h, uc := newGraphQLHandler(authenticateAs(auth.RoleReader, "c1"))
+
+	uc.currency.On("GetCurrency", mock.Anything, int64(1)).Return(&entity.Currency{ID: 1, Name: "USD"}, nil).Once()
+	uc.conversion.On("GetConversions", mock.Anything, &request.ConversionParameter{Limit: 10, CurrencyIDFrom: 1}).Return([]entity.Conversion{
+		{ID: 1, CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 15000},
+		{ID: 2, CurrencyIDFrom: 1, CurrencyIDTo: 3, Rate: 1.35},
+	}, int64(2), nil).Once()
+	// the currency of the query is not fetched again
+	uc.currency.On("GetCurrencies", mock.Anything, &request.CurrencyParameter{Limit: 2, IDs: []int64{2, 3}}).Return([]entity.Currency{
+		{ID: 2, Name: "IDR"}, {ID: 3, Name: "SGD"},
+	}, int64(2), nil).Once()
+
+	resp := execGraphQL(t, h, `query ($id: ID!) { currency(id: $id) { name conversions { rate from { name } to { name } } } }`,
+		map[string]interface{}{"id": "1"})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"currency": {"name": "USD", "conversions": [
+		{"rate": 15000, "from": {"name": "USD"}, "to": {"name": "IDR"}},
+		{"rate": 1.35, "from": {"name": "USD"}, "to": {"name": "SGD"}}
+	]}}`, string(resp.Data))
+	uc.currency.AssertExpectations(t)
+}
+
+func TestGraphQLConvert(t *testing.T) {
+	h, uc := newGraphQLHandler(authenticateAs(auth.RoleReader, "c1"))
+
+	uc.convert.On("CreateConvertCurrencies", mock.Anything, &entity.ConvertCurrencies{CurrencyIDFrom: 1, CurrencyIDTo: 2, Amount: 10}).
+		Run(func(args mock.Arguments) {
+			c := args.Get(1).(*entity.ConvertCurrencies)
+			c.ID, c.Rate, c.Result = 5, 15000, 150000
+		}).Return(nil).Once()
+	uc.convert.On("CreateConvertCurrencies", mock.Anything, &entity.ConvertCurrencies{CurrencyIDFrom: 1, CurrencyIDTo: 9, Amount: 10}).
+		Return(errors.New("Not Found")).Once()
+	uc.currency.On("GetCurrencies", mock.Anything, &request.CurrencyParameter{Limit: 2, IDs: []int64{1, 2}}).Return([]entity.Currency{
+		{ID: 1, Name: "USD"}, {ID: 2, Name: "IDR"},
+	}, int64(2), nil).Once()
+
+	resp := execGraphQL(t, h, `mutation { convert(currencyIdFrom: "1", currencyIdTo: "2", amount: 10) { id result from { name } to { name } } }`, nil)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"convert": {"id": "5", "result": 150000, "from": {"name": "USD"}, "to": {"name": "IDR"}}}`, string(resp.Data))
+
+	resp = execGraphQL(t, h, `mutation { convert(currencyIdFrom: "1", currencyIdTo: "9", amount: 10) { id } }`, nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "Not Found", resp.Errors[0].Message)
+	assert.Equal(t, float64(10213), resp.Errors[0].Extensions["code"])
+}
+
+func TestGraphQLPage(t *testing.T) {
+	h, uc := newGraphQLHandler(authenticateAs(auth.RoleReader, "c1"))
+
+	uc.currency.On("GetCurrencies", mock.Anything, &request.CurrencyParameter{Limit: request.MaxLimit}).Return([]entity.Currency{}, int64(0), nil).Once()
+
+	resp := execGraphQL(t, h, `{ currencies(limit: 100000, offset: -3) { id } }`, nil)
+	require.Empty(t, resp.Errors)
+	uc.currency.AssertExpectations(t)
+}
+
+func TestGraphQLCurrenciesQueryIsBound(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// the query reaches the database as a bound LIKE pattern, not as SQL
+	dbMock.ExpectQuery("^SELECT COUNT(.+)").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1))
+	dbMock.ExpectQuery(`^SELECT id(.+) WHERE tenant_id = '' AND name LIKE \? ORDER BY`).WithArgs(`%' OR 1=1 -- \%%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}))
+
+	currencies := currency.NewService(&currency.Provider{Repo: repository.NewMysqlCurrency(db)})
+	graphQLHandler := delivery.NewGraphQLHandler(currencies, new(mocks.ConversionUsecase), new(mocks.ConvertCurrenciesUsecase), nil, nil)
+	h := handler.NewHandler(authenticateAs(auth.RoleReader, "c1"), &graphQLHandler)
+
+	resp := execGraphQL(t, h, `query($q: String) { currencies(query: $q) { id name } }`, map[string]interface{}{"q": "' OR 1=1 -- %"})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"currencies": []}`, string(resp.Data))
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestGraphQLConvertRateLimit(t *testing.T) {
+	limiter := handler.NewRateLimiter(handler.RateLimitRule{Method: "POST", Path: "/v1/convert-currencies", Rate: 0.001, Burst: 1})
+	h, uc := newLimitedGraphQLHandler(limiter, nil, authenticateAs(auth.RoleReader, "c1"))
+
+	uc.convert.On("CreateConvertCurrencies", mock.Anything, mock.Anything).Return(nil)
+
+	resp := execGraphQL(t, h, `mutation { convert(currencyIdFrom: "1", currencyIdTo: "2", amount: 10) { id } }`, nil)
+	require.Empty(t, resp.Errors)
+
+	// the second conversion of the document is over the limit of POST /v1/convert-currencies
+	resp = execGraphQL(t, h, `mutation { a: convert(currencyIdFrom: "1", currencyIdTo: "2", amount: 10) { id } }`, nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "Too Many Requests", resp.Errors[0].Message)
+	uc.convert.AssertNumberOfCalls(t, "CreateConvertCurrencies", 1)
+}
+
+func TestGraphQLConvertIdempotent(t *testing.T) {
+	keys := new(mocks.IdempotencyKeyRepo)
+	var stored entity.IdempotencyKey
+	keys.On("CreateIdempotencyKey", mock.Anything, mock.Anything).Return(nil).Once()
+	keys.On("CreateIdempotencyKey", mock.Anything, mock.Anything).Return(errors.New("Error 1062: Duplicate entry 'k1' for key 'PRIMARY'"))
+	keys.On("UpdateIdempotencyKey", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		stored = *args.Get(1).(*entity.IdempotencyKey)
+	}).Once()
+	keys.On("GetIdempotencyKey", mock.Anything, "c1", "k1").Return(func(context.Context, string, string) *entity.IdempotencyKey {
+		return &stored
+	}, nil)
+
+	h, uc := newLimitedGraphQLHandler(nil, keys, authenticateAs(auth.RoleReader, "c1"))
+	uc.convert.On("CreateConvertCurrencies", mock.Anything, &entity.ConvertCurrencies{CurrencyIDFrom: 1, CurrencyIDTo: 2, Amount: 10}).
+		Run(func(args mock.Arguments) {
+			c := args.Get(1).(*entity.ConvertCurrencies)
+			c.ID, c.Rate, c.Result, c.ClientID = 5, 15000, 150000, "c1"
+		}).Return(nil).Once()
+
+	for i := 0; i < 2; i++ {
+		resp := execGraphQL(t, h, `mutation { convert(currencyIdFrom: "1", currencyIdTo: "2", amount: 10, idempotencyKey: "k1") { id result } }`, nil)
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"convert": {"id": "5", "result": 150000}}`, string(resp.Data), "the conversion is made once")
+	}
+
+	resp := execGraphQL(t, h, `mutation { convert(currencyIdFrom: "1", currencyIdTo: "2", amount: 20, idempotencyKey: "k1") { id } }`, nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "Idempotency-Key has already been used with a different request", resp.Errors[0].Message)
+	uc.convert.AssertNumberOfCalls(t, "CreateConvertCurrencies", 1)
+}
+
+func TestGraphQLAuthorization(t *testing.T) {
+	h, _ := newGraphQLHandler()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", bytes.NewBufferString(`{"query": "{ currencies { id } }"}`)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// a token allowed to convert only
+	scoped := handler.WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := auth.WithPrincipal(r.Context(), &auth.Principal{Subject: "svc", Scopes: []string{"convert"}})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	h, uc := newGraphQLHandler(scoped)
+
+	resp := execGraphQL(t, h, `{ currencies { id } }`, nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "Forbidden", resp.Errors[0].Message)
+	uc.currency.AssertNotCalled(t, "GetCurrencies", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,106 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/usecase/quote"
+)
+
+type QuoteHandler struct {
+	uc quote.QuoteUsecase
+}
+
+func NewQuoteHandler(usecase quote.QuoteUsecase) QuoteHandler {
+	return QuoteHandler{uc: usecase}
+}
+
+func (qh *QuoteHandler) Register(r *httprouter.Router) error {
+	if r == nil {
+		return errors.New("Passed router cannot be nil or empty")
+	}
+
+	r.GET("/v1/quotes/:id", auth.Require(auth.Convert, qh.GetQuote))
+	r.POST("/v1/quotes", auth.Require(auth.Convert, qh.CreateQuote))
+	r.POST("/v1/quotes/:id/execute", auth.Require(auth.Convert, qh.ExecuteQuote))
+
+	return nil
+}
+
+func (qh *QuoteHandler) GetQuote(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	quoteID, err := strconv.ParseInt(p.ByName("id"), 10, 64)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	context := r.Context()
+
+	quote, err := qh.uc.GetQuote(context, quoteID)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+	}
+	response.Write(w, response.BuildSuccess(quote, meta), http.StatusOK)
+	return
+}
+
+func (qh *QuoteHandler) CreateQuote(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	decoder := json.NewDecoder(r.Body)
+	var quote entity.Quote
+	if err := decoder.Decode(&quote); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+	defer r.Body.Close()
+
+	context := r.Context()
+	if err := qh.uc.CreateQuote(context, &quote); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusCreated,
+	}
+	response.Write(w, response.BuildSuccess(quote, meta), http.StatusCreated)
+	return
+}
+
+func (qh *QuoteHandler) ExecuteQuote(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	quoteID, err := strconv.ParseInt(p.ByName("id"), 10, 64)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	context := r.Context()
+
+	quote, err := qh.uc.ExecuteQuote(context, quoteID)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+	}
+	response.Write(w, response.BuildSuccess(quote, meta), http.StatusOK)
+	return
+}
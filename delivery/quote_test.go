@@ -0,0 +1,90 @@
+package delivery_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/delivery"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newQuoteHandler() (http.Handler, *mocks.QuoteUsecase) {
+	uc := new(mocks.QuoteUsecase)
+	QuoteHandler := delivery.NewQuoteHandler(uc)
+
+	h := handler.NewHandler(authenticateAs(auth.RoleReader, "client-1"), &QuoteHandler)
+	return h, uc
+}
+
+func TestQuoteRequest(t *testing.T) {
+	handler, uc := newQuoteHandler()
+	executedAt := time.Now()
+	singleQuote := entity.Quote{ID: 1, CurrencyIDFrom: 1, CurrencyIDTo: 2, Amount: 10, Rate: 2, Result: 20, ExpiresAt: time.Now().Add(time.Minute)}
+	executedQuote := singleQuote
+	executedQuote.ExecutedAt = &executedAt
+	examplePayload, err := json.Marshal(entity.Quote{CurrencyIDFrom: 1, CurrencyIDTo: 2, Amount: 10})
+	assert.NoError(t, err)
+
+	uc.On("CreateQuote", mock.Anything, mock.Anything).Return(nil)
+	uc.On("GetQuote", mock.Anything, int64(1)).Return(&singleQuote, nil)
+	uc.On("ExecuteQuote", mock.Anything, int64(1)).Return(&executedQuote, nil).Once()
+	uc.On("ExecuteQuote", mock.Anything, int64(1)).Return(nil, fmt.Errorf("Conflict: quote has already been executed")).Once()
+	uc.On("ExecuteQuote", mock.Anything, int64(2)).Return(nil, fmt.Errorf("Gone: quote has expired"))
+
+	testCases := []requestConversionTestCase{
+		{
+			name:           "Create new quote",
+			method:         "POST",
+			endpoint:       "/v1/quotes",
+			payload:        examplePayload,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Get quote",
+			method:         "GET",
+			endpoint:       "/v1/quotes/1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Execute quote",
+			method:         "POST",
+			endpoint:       "/v1/quotes/1/execute",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Execute quote twice",
+			method:         "POST",
+			endpoint:       "/v1/quotes/1/execute",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Execute expired quote",
+			method:         "POST",
+			endpoint:       "/v1/quotes/2/execute",
+			expectedStatus: http.StatusGone,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stubRequest := NewConversionHTTPRequest(testCase.method, testCase.endpoint, "", testCase.payload)
+			stubRequest = stubRequest.WithContext(context.TODO())
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, stubRequest)
+			assert.Equal(t, testCase.expectedStatus, recorder.Code)
+		})
+	}
+
+	uc.AssertExpectations(t)
+}
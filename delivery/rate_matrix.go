@@ -0,0 +1,116 @@
+package delivery
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/usecase/rate_matrix"
+)
+
+type RateMatrixHandler struct {
+	uc rate_matrix.RateMatrixUsecase
+}
+
+func NewRateMatrixHandler(usecase rate_matrix.RateMatrixUsecase) RateMatrixHandler {
+	return RateMatrixHandler{uc: usecase}
+}
+
+func (mh *RateMatrixHandler) Register(r *httprouter.Router) error {
+	if r == nil {
+		return errors.New("Passed router cannot be nil or empty")
+	}
+
+	r.GET("/v1/rates/matrix", auth.Require(auth.ReadConversions, mh.GetRateMatrix))
+
+	return nil
+}
+
+// GetRateMatrix answers with JSON, or with CSV when format=csv or when the client accepts
+// text/csv and no format is given
+func (mh *RateMatrixHandler) GetRateMatrix(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	helper := request.NewQueryHelper(r)
+
+	format := helper.GetString("format", "")
+	if format == "" {
+		format = "json"
+		if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+			format = "csv"
+		}
+	}
+	if format != "json" && format != "csv" {
+		errBody, httpStatus := response.BuildErrorAndStatus(fmt.Errorf("Bad Request: format must be json or csv"), "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	params := request.RateMatrixParameter{
+		Base: helper.GetString("base", ""),
+	}
+	for _, c := range strings.Split(helper.GetString("currencies", ""), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			params.Currencies = append(params.Currencies, c)
+		}
+	}
+
+	matrix, err := mh.uc.GetRateMatrix(r.Context(), &params)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	if format == "csv" {
+		writeRateMatrixCSV(w, matrix)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+	}
+	response.Write(w, response.BuildSuccess(matrix, meta), http.StatusOK)
+}
+
+// writeRateMatrixCSV writes the matrix with a header row and a header column of the
+// currencies: the cell of a row and a column is the rate from the currency of the row to the
+// currency of the column, empty when it is unavailable
+func writeRateMatrixCSV(w http.ResponseWriter, matrix *entity.RateMatrix) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	header := []string{""}
+	for _, name := range matrix.Currencies {
+		header = append(header, csvCell(name))
+	}
+	cw.Write(header)
+
+	for i, row := range matrix.Rates {
+		record := []string{csvCell(matrix.Currencies[i])}
+		for _, cell := range row {
+			rate := ""
+			if cell.Type != entity.RateUnavailable {
+				rate = strconv.FormatFloat(cell.Rate, 'f', -1, 64)
+			}
+			record = append(record, rate)
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+}
+
+// csvCell prefixes the values a spreadsheet would evaluate as a formula with a quote
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package delivery_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/delivery"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newRateMatrixHandler() (http.Handler, *mocks.RateMatrixUsecase) {
+	uc := new(mocks.RateMatrixUsecase)
+	RateMatrixHandler := delivery.NewRateMatrixHandler(uc)
+
+	h := handler.NewHandler(authenticateAs(auth.RoleReader, "test"), &RateMatrixHandler)
+	return h, uc
+}
+
+func stubRateMatrix() *entity.RateMatrix {
+	return &entity.RateMatrix{
+		Base:       "IDR",
+		Currencies: []string{"IDR", "EUR"},
+		Rates: [][]entity.RateCell{
+			{
+				{From: "IDR", To: "IDR", Rate: 1, Type: entity.RateIdentity},
+				{From: "IDR", To: "EUR", Rate: 0.00006, Type: entity.RateDerived, Via: []string{"USD"}},
+			},
+			{
+				{From: "EUR", To: "IDR", Rate: 16666.5, Type: entity.RateDerived, Via: []string{"USD"}},
+				{From: "EUR", To: "EUR", Rate: 1, Type: entity.RateIdentity},
+			},
+		},
+	}
+}
+
+func TestRateMatrixJSON(t *testing.T) {
+	h, uc := newRateMatrixHandler()
+	uc.On("GetRateMatrix", mock.Anything, &request.RateMatrixParameter{Base: "IDR", Currencies: []string{"EUR", "USD"}}).
+		Return(stubRateMatrix(), nil)
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, NewConversionHTTPRequest("GET", "/v1/rates/matrix?base=IDR&currencies=EUR,%20USD,", "", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var body struct {
+		Data entity.RateMatrix `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, *stubRateMatrix(), body.Data)
+}
+
+func TestRateMatrixCSV(t *testing.T) {
+	h, uc := newRateMatrixHandler()
+	uc.On("GetRateMatrix", mock.Anything, mock.Anything).Return(stubRateMatrix(), nil)
+
+	for _, req := range []*http.Request{
+		NewConversionHTTPRequest("GET", "/v1/rates/matrix?base=IDR&format=csv", "", nil),
+		func() *http.Request {
+			r := NewConversionHTTPRequest("GET", "/v1/rates/matrix?base=IDR", "", nil)
+			r.Header.Set("Accept", "text/csv")
+			return r
+		}(),
+	} {
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+		records, err := csv.NewReader(recorder.Body).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"", "IDR", "EUR"},
+			{"IDR", "1", "0.00006"},
+			{"EUR", "16666.5", "1"},
+		}, records)
+	}
+}
+
+func TestRateMatrixCSVFormula(t *testing.T) {
+	h, uc := newRateMatrixHandler()
+	uc.On("GetRateMatrix", mock.Anything, mock.Anything).Return(&entity.RateMatrix{
+		Base:       "IDR",
+		Currencies: []string{"IDR", "=HYPERLINK(\"http://example.com\")"},
+		Rates: [][]entity.RateCell{
+			{{Rate: 1, Type: entity.RateIdentity}, {Type: entity.RateUnavailable}},
+			{{Type: entity.RateUnavailable}, {Rate: 1, Type: entity.RateIdentity}},
+		},
+	}, nil)
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, NewConversionHTTPRequest("GET", "/v1/rates/matrix?base=IDR&format=csv", "", nil))
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"", "IDR", "'=HYPERLINK(\"http://example.com\")"},
+		{"IDR", "1", ""},
+		{"'=HYPERLINK(\"http://example.com\")", "", "1"},
+	}, records, "a name is not evaluated as a formula")
+}
+
+func TestRateMatrixInvalid(t *testing.T) {
+	h, uc := newRateMatrixHandler()
+	uc.On("GetRateMatrix", mock.Anything, mock.Anything).Return(nil, fmt.Errorf(`Bad Request: unknown currency "XXX"`))
+
+	for _, endpoint := range []string{"/v1/rates/matrix?base=XXX", "/v1/rates/matrix?base=IDR&format=xml"} {
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, NewConversionHTTPRequest("GET", endpoint, "", nil))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, endpoint)
+	}
+	uc.AssertNumberOfCalls(t, "GetRateMatrix", 1)
+}
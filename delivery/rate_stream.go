@@ -0,0 +1,233 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/app/stream"
+)
+
+const (
+	// wsWriteWait limits the time taken by a write to a WebSocket
+	wsWriteWait = 10 * time.Second
+	// wsMaxMessageBytes limits the messages read from a WebSocket
+	wsMaxMessageBytes = 4096
+)
+
+// wsUpgrader accepts every origin: callers authenticate with headers, not cookies, so
+// another site cannot open a WebSocket on behalf of a user
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+type RateStreamHandler struct {
+	hub       *stream.Hub
+	heartbeat time.Duration
+}
+
+// NewRateStreamHandler returns the handler streaming the rate changes published to hub,
+// sending a heartbeat when no event has been sent for the heartbeat interval
+func NewRateStreamHandler(hub *stream.Hub, heartbeat time.Duration) RateStreamHandler {
+	return RateStreamHandler{hub: hub, heartbeat: heartbeat}
+}
+
+func (sh *RateStreamHandler) Register(r *httprouter.Router) error {
+	if r == nil {
+		return errors.New("Passed router cannot be nil or empty")
+	}
+
+	r.GET("/v1/rates/stream", auth.Require(auth.ReadConversions, sh.StreamRates))
+	r.GET("/v1/rates/ws", auth.Require(auth.ReadConversions, sh.StreamRatesWebSocket))
+
+	return nil
+}
+
+// StreamRates sends the rate changes of the pairs query parameter, such as 1-2,1-3, as
+// Server-Sent Events
+func (sh *RateStreamHandler) StreamRates(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	pairs, err := stream.ParsePairs(r.URL.Query().Get("pairs"))
+	if err == nil && len(pairs) == 0 {
+		err = errors.New("Bad Request: pairs is required")
+	}
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	// the stream outlives the write timeout of the server
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	context := r.Context()
+	sub := sh.hub.Subscribe(request.TenantID(context), pairs...)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	rc.Flush()
+
+	heartbeat := time.NewTicker(sh.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-context.Done():
+			return
+		case <-sub.Done():
+			writeEvent(w, "end", map[string]string{"reason": sub.Err().Error()})
+			rc.Flush()
+			return
+		case e := <-sub.Events():
+			err = writeEvent(w, "rate", e)
+			heartbeat.Reset(sh.heartbeat)
+		case t := <-heartbeat.C:
+			err = writeEvent(w, "heartbeat", map[string]time.Time{"time": t.UTC()})
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w io.Writer, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// wsMessage is a message of the WebSocket stream. Clients send subscribe and unsubscribe
+// messages with pairs, the server sends rate, heartbeat, subscribed and error messages.
+type wsMessage struct {
+	Type    string            `json:"type"`
+	Pairs   []stream.Pair     `json:"pairs,omitempty"`
+	Data    *stream.RateEvent `json:"data,omitempty"`
+	Time    *time.Time        `json:"time,omitempty"`
+	Message string            `json:"message,omitempty"`
+}
+
+// StreamRatesWebSocket sends the rate changes of the pairs query parameter over a WebSocket.
+// The client changes its pairs with subscribe and unsubscribe messages. A ping is sent
+// with each heartbeat, and the connection is closed when the client misses two pongs.
+func (sh *RateStreamHandler) StreamRatesWebSocket(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	pairs, err := stream.ParsePairs(r.URL.Query().Get("pairs"))
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has answered the client
+		return
+	}
+	defer conn.Close()
+
+	sub := sh.hub.Subscribe(request.TenantID(r.Context()), pairs...)
+	defer sub.Close()
+
+	replies := make(chan wsMessage, 8)
+	closed := make(chan struct{})
+	go sh.readWebSocket(conn, sub, replies, closed)
+
+	heartbeat := time.NewTicker(sh.heartbeat)
+	defer heartbeat.Stop()
+
+	send := func(msg wsMessage) error {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(msg)
+	}
+
+	if err := send(wsMessage{Type: "subscribed", Pairs: sub.Pairs()}); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-closed:
+			return
+		case <-sub.Done():
+			code := websocket.CloseGoingAway
+			if sub.Err() == stream.ErrSlowConsumer {
+				code = websocket.CloseTryAgainLater
+			}
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, sub.Err().Error()), time.Now().Add(wsWriteWait))
+			return
+		case msg := <-replies:
+			err = send(msg)
+		case e := <-sub.Events():
+			err = send(wsMessage{Type: "rate", Data: &e})
+		case t := <-heartbeat.C:
+			now := t.UTC()
+			err = send(wsMessage{Type: "heartbeat", Time: &now})
+			if err == nil {
+				err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readWebSocket applies the subscribe and unsubscribe messages of the client until the
+// connection fails or the client stops answering pings, then closes closed
+func (sh *RateStreamHandler) readWebSocket(conn *websocket.Conn, sub *stream.Subscription, replies chan<- wsMessage, closed chan<- struct{}) {
+	defer close(closed)
+
+	pongWait := 2*sh.heartbeat + wsWriteWait
+	conn.SetReadLimit(wsMaxMessageBytes)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		var msg wsMessage
+		if err := json.Unmarshal(b, &msg); err != nil {
+			msg.Type = ""
+		}
+
+		reply := wsMessage{Type: "subscribed"}
+		switch msg.Type {
+		case "subscribe":
+			sub.Add(msg.Pairs...)
+			reply.Pairs = sub.Pairs()
+		case "unsubscribe":
+			sub.Remove(msg.Pairs...)
+			reply.Pairs = sub.Pairs()
+		default:
+			reply = wsMessage{Type: "error", Message: `Bad Request: messages must be JSON objects with a type of "subscribe" or "unsubscribe" and pairs`}
+		}
+
+		select {
+		case replies <- reply:
+		case <-sub.Done():
+			return
+		}
+	}
+}
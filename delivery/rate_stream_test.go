@@ -0,0 +1,117 @@
+package delivery_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/stream"
+	"github.com/rbpermadi/whim_assignment/delivery"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateStreamServer(t *testing.T, heartbeat time.Duration) (*httptest.Server, *stream.Hub) {
+	hub := stream.NewHub(8)
+	rateStreamHandler := delivery.NewRateStreamHandler(hub, heartbeat)
+
+	srv := httptest.NewServer(handler.NewHandler(authenticateAs(auth.RoleReader, "dashboard"), &rateStreamHandler))
+	t.Cleanup(srv.Close)
+	t.Cleanup(hub.Close)
+	return srv, hub
+}
+
+// readEvent returns the name and data of the next Server-Sent Event
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamRates(t *testing.T) {
+	srv, hub := newRateStreamServer(t, 50*time.Millisecond)
+
+	resp, err := http.Get(srv.URL + "/v1/rates/stream")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "pairs is required")
+
+	resp, err = http.Get(srv.URL + "/v1/rates/stream?pairs=1-2")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	body := bufio.NewReader(resp.Body)
+	event, _ := readEvent(t, body)
+	assert.Equal(t, "heartbeat", event)
+
+	hub.Publish(stream.RateEvent{Type: stream.RateUpdated, ConversionID: 3, CurrencyIDFrom: 2, CurrencyIDTo: 1, Rate: 1})
+	hub.Publish(stream.RateEvent{Type: stream.RateUpdated, ConversionID: 4, CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 15000})
+	event, data := readEvent(t, body)
+	assert.Equal(t, "rate", event)
+	assert.JSONEq(t, `{"type": "rate.updated", "conversion_id": 4, "tenant_id": "", "currency_id_from": 1, "currency_id_to": 2,
+		"rate": 15000, "updated_at": "0001-01-01T00:00:00Z"}`, data)
+
+	hub.Close()
+	event, data = readEvent(t, body)
+	assert.Equal(t, "end", event)
+	assert.Contains(t, data, "shutting down")
+}
+
+func TestStreamRatesWebSocket(t *testing.T) {
+	srv, hub := newRateStreamServer(t, time.Hour)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/rates/ws?pairs=1-2", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	read := func() map[string]interface{} {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&msg))
+		return msg
+	}
+
+	msg := read()
+	assert.Equal(t, "subscribed", msg["type"])
+	assert.Len(t, msg["pairs"], 1)
+
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "subscribe", "pairs": []stream.Pair{{From: 3, To: 1}}}))
+	msg = read()
+	assert.Equal(t, "subscribed", msg["type"])
+	assert.Len(t, msg["pairs"], 2)
+
+	hub.Publish(stream.RateEvent{Type: stream.RateCreated, ConversionID: 7, CurrencyIDFrom: 3, CurrencyIDTo: 1, Rate: 0.7})
+	msg = read()
+	assert.Equal(t, "rate", msg["type"])
+	data, _ := json.Marshal(msg["data"])
+	assert.Contains(t, string(data), `"conversion_id":7`)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	assert.Equal(t, "error", read()["type"])
+
+	hub.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "%v", err)
+}
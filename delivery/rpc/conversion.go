@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	whimv1 "github.com/rbpermadi/whim_assignment/proto/whim/v1"
+	"github.com/rbpermadi/whim_assignment/usecase/conversion"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ConversionServer struct {
+	whimv1.UnimplementedConversionServiceServer
+	uc conversion.ConversionUsecase
+}
+
+func (s *ConversionServer) CreateConversion(ctx context.Context, req *whimv1.CreateConversionRequest) (*whimv1.Conversion, error) {
+	cnv := entity.Conversion{
+		CurrencyIDFrom: req.GetCurrencyIdFrom(),
+		CurrencyIDTo:   req.GetCurrencyIdTo(),
+		Rate:           req.GetRate(),
+	}
+	if err := s.uc.CreateConversion(ctx, &cnv); err != nil {
+		return nil, Status(err)
+	}
+	return conversionToProto(&cnv), nil
+}
+
+// UpdateConversion reads the conversion from the primary database, like UpdateCurrency
+func (s *ConversionServer) UpdateConversion(ctx context.Context, req *whimv1.UpdateConversionRequest) (*whimv1.Conversion, error) {
+	ctx = request.WithReadPrimary(ctx)
+	curr, err := s.uc.GetConversion(ctx, req.GetId())
+	if err != nil {
+		return nil, Status(err)
+	}
+
+	if req.GetVersion() != 0 && req.GetVersion() != curr.Version {
+		return nil, Status(errors.New("Precondition Failed: conversion has been modified"))
+	}
+
+	cnv := *curr
+	cnv.Rate = req.GetRate()
+	cnv.Version = req.GetVersion()
+	if err := s.uc.UpdateConversion(ctx, req.GetId(), &cnv); err != nil {
+		return nil, Status(err)
+	}
+
+	curr, err = s.uc.GetConversion(ctx, req.GetId())
+	if err != nil {
+		return nil, Status(err)
+	}
+	return conversionToProto(curr), nil
+}
+
+func (s *ConversionServer) GetConversion(ctx context.Context, req *whimv1.GetConversionRequest) (*whimv1.Conversion, error) {
+	cnv, err := s.uc.GetConversion(ctx, req.GetId())
+	if err != nil {
+		return nil, Status(err)
+	}
+	return conversionToProto(cnv), nil
+}
+
+func (s *ConversionServer) ListConversions(ctx context.Context, req *whimv1.ListConversionsRequest) (*whimv1.ListConversionsResponse, error) {
+	limit, offset := page(req.GetPage())
+	params := request.ConversionParameter{
+		Limit:          limit,
+		Offset:         offset,
+		CurrencyIDFrom: req.GetCurrencyIdFrom(),
+		CurrencyIDTo:   req.GetCurrencyIdTo(),
+	}
+
+	conversions, total, err := s.uc.GetConversions(ctx, &params)
+	if err != nil {
+		return nil, Status(err)
+	}
+
+	resp := &whimv1.ListConversionsResponse{Total: total}
+	for i := range conversions {
+		resp.Conversions = append(resp.Conversions, conversionToProto(&conversions[i]))
+	}
+	return resp, nil
+}
+
+func conversionToProto(c *entity.Conversion) *whimv1.Conversion {
+	return &whimv1.Conversion{
+		Id:             c.ID,
+		TenantId:       c.TenantID,
+		CurrencyIdFrom: c.CurrencyIDFrom,
+		CurrencyIdTo:   c.CurrencyIDTo,
+		Rate:           c.Rate,
+		Version:        c.Version,
+		CreatedAt:      timestamppb.New(c.CreatedAt),
+		UpdatedAt:      timestamppb.New(c.UpdatedAt),
+	}
+}
@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	whimv1 "github.com/rbpermadi/whim_assignment/proto/whim/v1"
+	"github.com/rbpermadi/whim_assignment/usecase/convert_currencies"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ConvertServer struct {
+	whimv1.UnimplementedConvertServiceServer
+	uc convert_currencies.ConvertCurrenciesUsecase
+}
+
+func (s *ConvertServer) Convert(ctx context.Context, req *whimv1.ConvertRequest) (*whimv1.ConvertCurrencies, error) {
+	convert := entity.ConvertCurrencies{
+		CurrencyIDFrom: req.GetCurrencyIdFrom(),
+		CurrencyIDTo:   req.GetCurrencyIdTo(),
+		Amount:         req.GetAmount(),
+	}
+	if err := s.uc.CreateConvertCurrencies(ctx, &convert); err != nil {
+		return nil, Status(err)
+	}
+	return convertCurrenciesToProto(&convert), nil
+}
+
+// GetConvertCurrencies returns a conversion of an amount, callers who may not audit the
+// conversions of every client only find their own
+func (s *ConvertServer) GetConvertCurrencies(ctx context.Context, req *whimv1.GetConvertCurrenciesRequest) (*whimv1.ConvertCurrencies, error) {
+	convert, err := s.uc.GetConvertCurrency(ctx, req.GetId())
+	if err == nil {
+		if p := auth.PrincipalFromContext(ctx); !p.Allows(auth.AuditConversions) && p.ClientID != convert.ClientID {
+			err = errors.New("Not Found")
+		}
+	}
+	if err != nil {
+		return nil, Status(err)
+	}
+	return convertCurrenciesToProto(convert), nil
+}
+
+func (s *ConvertServer) ListConvertCurrencies(ctx context.Context, req *whimv1.ListConvertCurrenciesRequest) (*whimv1.ListConvertCurrenciesResponse, error) {
+	limit, offset := page(req.GetPage())
+	params := request.ConvertCurrenciesParameter{
+		Limit:          limit,
+		Offset:         offset,
+		CurrencyIDFrom: req.GetCurrencyIdFrom(),
+		CurrencyIDTo:   req.GetCurrencyIdTo(),
+		ClientID:       req.GetClientId(),
+		CreatedFrom:    timeOf(req.GetCreatedFrom()),
+		CreatedTo:      timeOf(req.GetCreatedTo()),
+	}
+
+	if p := auth.PrincipalFromContext(ctx); !p.Allows(auth.AuditConversions) {
+		params.ClientID = p.ClientID
+	}
+
+	converts, total, err := s.uc.GetConvertCurrencies(ctx, &params)
+	if err != nil {
+		return nil, Status(err)
+	}
+
+	resp := &whimv1.ListConvertCurrenciesResponse{Total: total}
+	for i := range converts {
+		resp.ConvertCurrencies = append(resp.ConvertCurrencies, convertCurrenciesToProto(&converts[i]))
+	}
+	return resp, nil
+}
+
+func convertCurrenciesToProto(c *entity.ConvertCurrencies) *whimv1.ConvertCurrencies {
+	return &whimv1.ConvertCurrencies{
+		Id:             c.ID,
+		ConversionId:   c.ConversionID,
+		QuoteId:        c.QuoteID,
+		CurrencyIdFrom: c.CurrencyIDFrom,
+		CurrencyIdTo:   c.CurrencyIDTo,
+		Amount:         c.Amount,
+		Rate:           c.Rate,
+		Result:         c.Result,
+		ClientId:       c.ClientID,
+		CreatedAt:      timestamppb.New(c.CreatedAt),
+	}
+}
+
+func timeOf(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	whimv1 "github.com/rbpermadi/whim_assignment/proto/whim/v1"
+	"github.com/rbpermadi/whim_assignment/usecase/currency"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type CurrencyServer struct {
+	whimv1.UnimplementedCurrencyServiceServer
+	uc currency.CurrencyUsecase
+}
+
+func (s *CurrencyServer) CreateCurrency(ctx context.Context, req *whimv1.CreateCurrencyRequest) (*whimv1.Currency, error) {
+	cry := entity.Currency{Name: req.GetName()}
+	if err := s.uc.CreateCurrency(ctx, &cry); err != nil {
+		return nil, Status(err)
+	}
+	return currencyToProto(&cry), nil
+}
+
+// UpdateCurrency reads the currency from the primary database, so the version checked and the
+// currency returned are not those of a replica lagging behind the update
+func (s *CurrencyServer) UpdateCurrency(ctx context.Context, req *whimv1.UpdateCurrencyRequest) (*whimv1.Currency, error) {
+	ctx = request.WithReadPrimary(ctx)
+	curr, err := s.uc.GetCurrency(ctx, req.GetId())
+	if err != nil {
+		return nil, Status(err)
+	}
+
+	if req.GetVersion() != 0 && req.GetVersion() != curr.Version {
+		return nil, Status(errors.New("Precondition Failed: currency has been modified"))
+	}
+
+	cry := *curr
+	cry.Name = req.GetName()
+	cry.Version = req.GetVersion()
+	if err := s.uc.UpdateCurrency(ctx, req.GetId(), &cry); err != nil {
+		return nil, Status(err)
+	}
+
+	curr, err = s.uc.GetCurrency(ctx, req.GetId())
+	if err != nil {
+		return nil, Status(err)
+	}
+	return currencyToProto(curr), nil
+}
+
+func (s *CurrencyServer) GetCurrency(ctx context.Context, req *whimv1.GetCurrencyRequest) (*whimv1.Currency, error) {
+	cry, err := s.uc.GetCurrency(ctx, req.GetId())
+	if err != nil {
+		return nil, Status(err)
+	}
+	return currencyToProto(cry), nil
+}
+
+func (s *CurrencyServer) ListCurrencies(ctx context.Context, req *whimv1.ListCurrenciesRequest) (*whimv1.ListCurrenciesResponse, error) {
+	limit, offset := page(req.GetPage())
+	params := request.CurrencyParameter{
+		Limit:  limit,
+		Offset: offset,
+		Query:  req.GetQuery(),
+	}
+
+	currencies, total, err := s.uc.GetCurrencies(ctx, &params)
+	if err != nil {
+		return nil, Status(err)
+	}
+
+	resp := &whimv1.ListCurrenciesResponse{Total: total}
+	for i := range currencies {
+		resp.Currencies = append(resp.Currencies, currencyToProto(&currencies[i]))
+	}
+	return resp, nil
+}
+
+func currencyToProto(c *entity.Currency) *whimv1.Currency {
+	return &whimv1.Currency{
+		Id:        c.ID,
+		TenantId:  c.TenantID,
+		Name:      c.Name,
+		Version:   c.Version,
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+	}
+}
+
+// page returns the limit and offset of a list, bounded like the REST API does
+func page(p *whimv1.Page) (int, int) {
+	return request.Page(int(p.GetLimit()), int(p.GetOffset()))
+}
@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Status converts an error of the usecases to a gRPC status, following the same error
+// kinds as response.BuildErrorAndStatus does for HTTP statuses. Unexpected errors are
+// reported as INTERNAL without their message, which may hold details of the database.
+func Status(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	msg := err.Error()
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, msg)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, msg)
+	case strings.Contains(msg, "Not Found"):
+		return status.Error(codes.NotFound, msg)
+	case strings.Contains(msg, "Duplicate entry"):
+		return status.Error(codes.AlreadyExists, "Record conflict")
+	case strings.Contains(msg, "Conflict"):
+		return status.Error(codes.Aborted, msg)
+	case strings.Contains(msg, "Precondition Failed"):
+		return status.Error(codes.FailedPrecondition, msg)
+	case strings.Contains(msg, "Gone"):
+		return status.Error(codes.FailedPrecondition, msg)
+	case strings.Contains(msg, "Too Many Requests"):
+		return status.Error(codes.ResourceExhausted, msg)
+	case strings.Contains(msg, "Bad Request"), strings.Contains(msg, "cannot be null"):
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return status.Error(codes.Internal, "Unexpected server error")
+}
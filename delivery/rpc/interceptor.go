@@ -0,0 +1,227 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/logger"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/rbpermadi/whim_assignment/handler"
+	whimv1 "github.com/rbpermadi/whim_assignment/proto/whim/v1"
+	"github.com/rbpermadi/whim_assignment/usecase/api_key"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// permissions lists the access each method requires, methods missing from it are denied
+var permissions = map[string]auth.Permission{
+	whimv1.CurrencyService_CreateCurrency_FullMethodName:       auth.WriteCurrencies,
+	whimv1.CurrencyService_UpdateCurrency_FullMethodName:       auth.WriteCurrencies,
+	whimv1.CurrencyService_GetCurrency_FullMethodName:          auth.ReadCurrencies,
+	whimv1.CurrencyService_ListCurrencies_FullMethodName:       auth.ReadCurrencies,
+	whimv1.ConversionService_CreateConversion_FullMethodName:   auth.WriteConversions,
+	whimv1.ConversionService_UpdateConversion_FullMethodName:   auth.WriteConversions,
+	whimv1.ConversionService_GetConversion_FullMethodName:      auth.ReadConversions,
+	whimv1.ConversionService_ListConversions_FullMethodName:    auth.ReadConversions,
+	whimv1.ConvertService_Convert_FullMethodName:               auth.Convert,
+	whimv1.ConvertService_GetConvertCurrencies_FullMethodName:  auth.Convert,
+	whimv1.ConvertService_ListConvertCurrencies_FullMethodName: auth.Convert,
+}
+
+type route struct {
+	method string
+	path   string
+}
+
+// routes lists the REST route of each method, so a method shares the rate limit of its route
+var routes = map[string]route{
+	whimv1.CurrencyService_CreateCurrency_FullMethodName:       {"POST", "/v1/currencies"},
+	whimv1.CurrencyService_UpdateCurrency_FullMethodName:       {"PATCH", "/v1/currencies/:id"},
+	whimv1.CurrencyService_GetCurrency_FullMethodName:          {"GET", "/v1/currencies/:id"},
+	whimv1.CurrencyService_ListCurrencies_FullMethodName:       {"GET", "/v1/currencies"},
+	whimv1.ConversionService_CreateConversion_FullMethodName:   {"POST", "/v1/conversions"},
+	whimv1.ConversionService_UpdateConversion_FullMethodName:   {"PATCH", "/v1/conversions/:id"},
+	whimv1.ConversionService_GetConversion_FullMethodName:      {"GET", "/v1/conversions/:id"},
+	whimv1.ConversionService_ListConversions_FullMethodName:    {"GET", "/v1/conversions"},
+	whimv1.ConvertService_Convert_FullMethodName:               {"POST", "/v1/convert-currencies"},
+	whimv1.ConvertService_GetConvertCurrencies_FullMethodName:  {"GET", "/v1/convert-currencies/:id"},
+	whimv1.ConvertService_ListConvertCurrencies_FullMethodName: {"GET", "/v1/convert-currencies"},
+}
+
+// Recover reports a panic of a method as INTERNAL instead of crashing the server
+func Recover() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				slog.ErrorContext(ctx, "panic", slog.Any("panic", p), slog.String("stack", string(debug.Stack())))
+				err = status.Error(codes.Internal, "Unexpected server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// AccessLog starts a server span for each call, continuing the trace of the traceparent
+// metadata when the caller sent one, and logs one record per call once it has been served
+func AccessLog() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+		ctx, span := tracing.Start(ctx, strings.TrimPrefix(info.FullMethod, "/"),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("rpc.system", "grpc"),
+				attribute.String("rpc.method", info.FullMethod),
+			),
+		)
+		defer span.End()
+
+		id := first(md, "x-request-id")
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+		ctx = request.WithRequestID(logger.NewContext(ctx), id)
+		logger.AddFields(ctx, slog.String("request_id", id))
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		level := slog.LevelInfo
+		if code == codes.Internal || code == codes.Unknown {
+			level = slog.LevelError
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		slog.LogAttrs(ctx, level, "rpc",
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+		return resp, err
+	}
+}
+
+// Authenticate identifies the caller by the x-api-key metadata, or by a bearer token in the
+// authorization metadata when verifier is set. Calls with an invalid key or token fail with
+// UNAUTHENTICATED, calls without credentials continue anonymously.
+func Authenticate(keys api_key.APIKeyUsecase, verifier *auth.JWTVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		var p *auth.Principal
+		if key := first(md, "x-api-key"); key != "" && keys != nil {
+			ek, err := keys.Authenticate(ctx, key)
+			if err != nil && err.Error() == "Not Found" {
+				return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+			}
+			if err != nil {
+				return nil, Status(err)
+			}
+			p = &auth.Principal{
+				Subject:  ek.Prefix,
+				ClientID: ek.ClientID,
+				TenantID: ek.TenantID,
+				Role:     auth.Role(ek.Role),
+			}
+		} else if header := first(md, "authorization"); verifier != nil && len(header) >= 7 && strings.EqualFold(header[:7], "Bearer ") {
+			var err error
+			if p, err = verifier.Verify(strings.TrimSpace(header[7:])); err != nil {
+				return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+			}
+		}
+
+		if p != nil {
+			ctx = auth.WithPrincipal(ctx, p)
+			ctx = request.WithClientID(ctx, p.ClientID)
+			ctx = request.WithTenantID(ctx, p.TenantID)
+			logger.AddFields(ctx, slog.String("client_id", p.ClientID))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Authorize fails anonymous calls with UNAUTHENTICATED and calls of callers without the
+// permission of the method with PERMISSION_DENIED
+func Authorize() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		p := auth.PrincipalFromContext(ctx)
+		if p == nil {
+			return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+		}
+
+		perm, ok := permissions[info.FullMethod]
+		if !ok || !p.Allows(perm) {
+			return nil, status.Error(codes.PermissionDenied, "Forbidden")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimit fails the calls of callers exceeding the rule of the REST route of the method with
+// RESOURCE_EXHAUSTED, the retry-after header telling in how many seconds to retry. The calls
+// take their tokens from the buckets of the REST API, so a caller has the same limit on both.
+func RateLimit(l *handler.RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var addr string
+		if p, ok := peer.FromContext(ctx); ok {
+			addr = p.Addr.String()
+		}
+
+		rt := routes[info.FullMethod]
+		if wait, ok := l.Allow(ctx, rt.method, rt.path, addr); !ok {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+			return nil, status.Error(codes.ResourceExhausted, "Too Many Requests")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// metadataCarrier adapts incoming metadata to the propagation of trace context
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	return first(metadata.MD(c), key)
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,47 @@
+// Package rpc serves the currencies, conversions and conversions of amounts over gRPC,
+// calling the same usecases as the REST handlers of package delivery
+package rpc
+
+import (
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/handler"
+	whimv1 "github.com/rbpermadi/whim_assignment/proto/whim/v1"
+	"github.com/rbpermadi/whim_assignment/usecase/api_key"
+	"github.com/rbpermadi/whim_assignment/usecase/conversion"
+	"github.com/rbpermadi/whim_assignment/usecase/convert_currencies"
+	"github.com/rbpermadi/whim_assignment/usecase/currency"
+	"google.golang.org/grpc"
+)
+
+// Services holds the usecases served over gRPC and the ones authenticating the callers.
+// Bearer tokens are not accepted when Verifier is nil, and calls are not rate limited when
+// Limiter is nil.
+type Services struct {
+	Currency          currency.CurrencyUsecase
+	Conversion        conversion.ConversionUsecase
+	ConvertCurrencies convert_currencies.ConvertCurrenciesUsecase
+	APIKey            api_key.APIKeyUsecase
+	Verifier          *auth.JWTVerifier
+	Limiter           *handler.RateLimiter
+}
+
+// NewServer returns a gRPC server with the services of whim.v1 registered. Callers are
+// authenticated, authorized and rate limited like on the REST API before the methods are called.
+func NewServer(svc Services, opts ...grpc.ServerOption) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{
+		AccessLog(),
+		Recover(),
+		Authenticate(svc.APIKey, svc.Verifier),
+		Authorize(),
+	}
+	if svc.Limiter != nil {
+		interceptors = append(interceptors, RateLimit(svc.Limiter))
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(interceptors...))
+	s := grpc.NewServer(opts...)
+
+	whimv1.RegisterCurrencyServiceServer(s, &CurrencyServer{uc: svc.Currency})
+	whimv1.RegisterConversionServiceServer(s, &ConversionServer{uc: svc.Conversion})
+	whimv1.RegisterConvertServiceServer(s, &ConvertServer{uc: svc.ConvertCurrencies})
+	return s
+}
@@ -0,0 +1,327 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/delivery/rpc"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	whimv1 "github.com/rbpermadi/whim_assignment/proto/whim/v1"
+	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/currency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type usecases struct {
+	currency   *mocks.CurrencyUsecase
+	conversion *mocks.ConversionUsecase
+	convert    *mocks.ConvertCurrenciesUsecase
+}
+
+// serve runs the gRPC server on an in-process listener and returns a connection to it.
+// The API keys "admin-key" and "reader-key" authenticate an admin of tenant acme and a
+// reader with client id c1, other keys are unknown.
+func serve(t *testing.T) (*grpc.ClientConn, usecases) {
+	return serveLimited(t, nil)
+}
+
+// serveLimited is serve with the calls rate limited by limiter
+func serveLimited(t *testing.T, limiter *handler.RateLimiter) (*grpc.ClientConn, usecases) {
+	uc := usecases{
+		currency:   new(mocks.CurrencyUsecase),
+		conversion: new(mocks.ConversionUsecase),
+		convert:    new(mocks.ConvertCurrenciesUsecase),
+	}
+
+	conn := serveServices(t, rpc.Services{
+		Currency:          uc.currency,
+		Conversion:        uc.conversion,
+		ConvertCurrencies: uc.convert,
+		Limiter:           limiter,
+	})
+	return conn, uc
+}
+
+// serveServices is serve with the usecases of services
+func serveServices(t *testing.T, services rpc.Services) *grpc.ClientConn {
+	keys := new(mocks.APIKeyUsecase)
+	keys.On("Authenticate", mock.Anything, "admin-key").Return(&entity.APIKey{Prefix: "admin", ClientID: "ops", TenantID: "acme", Role: "admin"}, nil)
+	keys.On("Authenticate", mock.Anything, "reader-key").Return(&entity.APIKey{Prefix: "reader", ClientID: "c1", Role: "reader"}, nil)
+	keys.On("Authenticate", mock.Anything, mock.Anything).Return(nil, errors.New("Not Found"))
+
+	services.APIKey = keys
+	s := rpc.NewServer(services)
+
+	lis := bufconn.Listen(1 << 20)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func TestAuthentication(t *testing.T) {
+	conn, uc := serve(t)
+	client := whimv1.NewCurrencyServiceClient(conn)
+	uc.currency.On("GetCurrency", mock.Anything, int64(1)).Return(&entity.Currency{ID: 1}, nil)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		call func(ctx context.Context) error
+		want codes.Code
+	}{
+		{"anonymous", context.Background(), func(ctx context.Context) error {
+			_, err := client.GetCurrency(ctx, &whimv1.GetCurrencyRequest{Id: 1})
+			return err
+		}, codes.Unauthenticated},
+		{"unknown key", withKey("other-key"), func(ctx context.Context) error {
+			_, err := client.GetCurrency(ctx, &whimv1.GetCurrencyRequest{Id: 1})
+			return err
+		}, codes.Unauthenticated},
+		{"role without the permission", withKey("reader-key"), func(ctx context.Context) error {
+			_, err := client.CreateCurrency(ctx, &whimv1.CreateCurrencyRequest{Name: "IDR"})
+			return err
+		}, codes.PermissionDenied},
+		{"role with the permission", withKey("reader-key"), func(ctx context.Context) error {
+			_, err := client.GetCurrency(ctx, &whimv1.GetCurrencyRequest{Id: 1})
+			return err
+		}, codes.OK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, status.Code(tc.call(tc.ctx)))
+		})
+	}
+	uc.currency.AssertNotCalled(t, "CreateCurrency", mock.Anything, mock.Anything)
+}
+
+func TestStatus(t *testing.T) {
+	conn, uc := serve(t)
+	client := whimv1.NewCurrencyServiceClient(conn)
+
+	tests := []struct {
+		err     error
+		want    codes.Code
+		message string
+	}{
+		{errors.New("Not Found"), codes.NotFound, "Not Found"},
+		{errors.New("Error 1062: Duplicate entry 'IDR' for key 'name'"), codes.AlreadyExists, "Record conflict"},
+		{errors.New("Precondition Failed: currency has been modified"), codes.FailedPrecondition, "Precondition Failed: currency has been modified"},
+		{errors.New("Bad Request"), codes.InvalidArgument, "Bad Request"},
+		{errors.New("Too Many Requests: monthly conversion quota exceeded"), codes.ResourceExhausted, "Too Many Requests: monthly conversion quota exceeded"},
+		{errors.New("dial tcp 10.0.0.1:3306: connection refused"), codes.Internal, "Unexpected server error"},
+	}
+
+	for i, tc := range tests {
+		id := int64(i + 1)
+		uc.currency.On("GetCurrency", mock.Anything, id).Return((*entity.Currency)(nil), tc.err)
+
+		_, err := client.GetCurrency(withKey("admin-key"), &whimv1.GetCurrencyRequest{Id: id})
+		st, _ := status.FromError(err)
+		assert.Equal(t, tc.want, st.Code(), tc.err.Error())
+		assert.Equal(t, tc.message, st.Message())
+	}
+}
+
+func TestCurrencyService(t *testing.T) {
+	conn, uc := serve(t)
+	client := whimv1.NewCurrencyServiceClient(conn)
+	ctx := withKey("admin-key")
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	inTenant := mock.MatchedBy(func(ctx context.Context) bool { return request.TenantID(ctx) == "acme" })
+
+	t.Run("create", func(t *testing.T) {
+		uc.currency.On("CreateCurrency", inTenant, mock.MatchedBy(func(c *entity.Currency) bool { return c.Name == "IDR" })).
+			Run(func(args mock.Arguments) {
+				c := args.Get(1).(*entity.Currency)
+				c.ID, c.TenantID, c.Version, c.CreatedAt, c.UpdatedAt = 7, "acme", 1, now, now
+			}).Return(nil).Once()
+
+		got, err := client.CreateCurrency(ctx, &whimv1.CreateCurrencyRequest{Name: "IDR"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), got.GetId())
+		assert.Equal(t, "acme", got.GetTenantId())
+		assert.Equal(t, now, got.GetCreatedAt().AsTime())
+	})
+
+	t.Run("list with the default page", func(t *testing.T) {
+		uc.currency.On("GetCurrencies", inTenant, &request.CurrencyParameter{Limit: 10, Query: "ID"}).
+			Return([]entity.Currency{{ID: 7, Name: "IDR"}, {ID: 8, Name: "IDK"}}, int64(12), nil).Once()
+
+		got, err := client.ListCurrencies(ctx, &whimv1.ListCurrenciesRequest{Query: "ID"})
+		require.NoError(t, err)
+		assert.Len(t, got.GetCurrencies(), 2)
+		assert.Equal(t, int64(12), got.GetTotal())
+	})
+
+	t.Run("update a modified currency", func(t *testing.T) {
+		uc.currency.On("GetCurrency", mock.Anything, int64(7)).Return(&entity.Currency{ID: 7, Name: "IDR", Version: 3}, nil).Once()
+
+		_, err := client.UpdateCurrency(ctx, &whimv1.UpdateCurrencyRequest{Id: 7, Name: "USD", Version: 2})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		uc.currency.AssertNotCalled(t, "UpdateCurrency", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("list with a page too large", func(t *testing.T) {
+		uc.currency.On("GetCurrencies", inTenant, &request.CurrencyParameter{Limit: request.MaxLimit}).
+			Return([]entity.Currency{}, int64(0), nil).Once()
+
+		_, err := client.ListCurrencies(ctx, &whimv1.ListCurrenciesRequest{Page: &whimv1.Page{Limit: 100000, Offset: -5}})
+		require.NoError(t, err)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		// the version is checked and the currency read back on the primary, not on a lagging replica
+		fromPrimary := mock.MatchedBy(request.ReadPrimary)
+		uc.currency.On("GetCurrency", fromPrimary, int64(7)).Return(&entity.Currency{ID: 7, Name: "IDR", Version: 3}, nil).Once()
+		uc.currency.On("UpdateCurrency", mock.Anything, int64(7), &entity.Currency{ID: 7, Name: "USD", Version: 3}).Return(nil).Once()
+		uc.currency.On("GetCurrency", fromPrimary, int64(7)).Return(&entity.Currency{ID: 7, Name: "USD", Version: 4}, nil).Once()
+
+		got, err := client.UpdateCurrency(ctx, &whimv1.UpdateCurrencyRequest{Id: 7, Name: "USD", Version: 3})
+		require.NoError(t, err)
+		assert.Equal(t, "USD", got.GetName())
+		assert.Equal(t, int64(4), got.GetVersion())
+	})
+}
+
+func TestListCurrenciesQueryIsBound(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// the query reaches the database as a bound LIKE pattern, not as SQL
+	dbMock.ExpectQuery("^SELECT COUNT(.+)").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1))
+	dbMock.ExpectQuery(`^SELECT id(.+) WHERE tenant_id = '' AND name LIKE \? ORDER BY`).WithArgs(`%' OR 1=1 -- \%%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}))
+
+	conn := serveServices(t, rpc.Services{
+		Currency:          currency.NewService(&currency.Provider{Repo: repository.NewMysqlCurrency(db)}),
+		Conversion:        new(mocks.ConversionUsecase),
+		ConvertCurrencies: new(mocks.ConvertCurrenciesUsecase),
+	})
+
+	got, err := whimv1.NewCurrencyServiceClient(conn).ListCurrencies(withKey("reader-key"), &whimv1.ListCurrenciesRequest{Query: "' OR 1=1 -- %"})
+	require.NoError(t, err)
+	assert.Empty(t, got.GetCurrencies())
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestConversionService(t *testing.T) {
+	conn, uc := serve(t)
+	client := whimv1.NewConversionServiceClient(conn)
+
+	uc.conversion.On("CreateConversion", mock.Anything, &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 15000}).
+		Return(errors.New("Bad Request")).Once()
+
+	_, err := client.CreateConversion(withKey("admin-key"), &whimv1.CreateConversionRequest{CurrencyIdFrom: 1, CurrencyIdTo: 2, Rate: 15000})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	uc.conversion.On("GetConversions", mock.Anything, &request.ConversionParameter{Limit: 5, Offset: 5, CurrencyIDFrom: 1}).
+		Return([]entity.Conversion{{ID: 3, CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 15000}}, int64(6), nil).Once()
+
+	got, err := client.ListConversions(withKey("reader-key"), &whimv1.ListConversionsRequest{Page: &whimv1.Page{Limit: 5, Offset: 5}, CurrencyIdFrom: 1})
+	require.NoError(t, err)
+	require.Len(t, got.GetConversions(), 1)
+	assert.Equal(t, 15000.0, got.GetConversions()[0].GetRate())
+}
+
+func TestConvertService(t *testing.T) {
+	conn, uc := serve(t)
+	client := whimv1.NewConvertServiceClient(conn)
+
+	t.Run("convert", func(t *testing.T) {
+		inClient := mock.MatchedBy(func(ctx context.Context) bool { return request.ClientID(ctx) == "c1" })
+		uc.convert.On("CreateConvertCurrencies", inClient, &entity.ConvertCurrencies{CurrencyIDFrom: 1, CurrencyIDTo: 2, Amount: 10}).
+			Run(func(args mock.Arguments) {
+				c := args.Get(1).(*entity.ConvertCurrencies)
+				c.ID, c.Rate, c.Result, c.ClientID = 9, 15000, 150000, "c1"
+			}).Return(nil).Once()
+
+		got, err := client.Convert(withKey("reader-key"), &whimv1.ConvertRequest{CurrencyIdFrom: 1, CurrencyIdTo: 2, Amount: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 150000.0, got.GetResult())
+	})
+
+	t.Run("list is limited to the conversions of the client", func(t *testing.T) {
+		uc.convert.On("GetConvertCurrencies", mock.Anything, &request.ConvertCurrenciesParameter{Limit: 10, ClientID: "c1"}).
+			Return([]entity.ConvertCurrencies{{ID: 9, ClientID: "c1"}}, int64(1), nil).Once()
+
+		got, err := client.ListConvertCurrencies(withKey("reader-key"), &whimv1.ListConvertCurrenciesRequest{ClientId: "c2"})
+		require.NoError(t, err)
+		assert.Len(t, got.GetConvertCurrencies(), 1)
+	})
+
+	t.Run("conversions of other clients are not found", func(t *testing.T) {
+		uc.convert.On("GetConvertCurrency", mock.Anything, int64(10)).Return(&entity.ConvertCurrencies{ID: 10, ClientID: "c2"}, nil)
+
+		_, err := client.GetConvertCurrencies(withKey("reader-key"), &whimv1.GetConvertCurrenciesRequest{Id: 10})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		got, err := client.GetConvertCurrencies(withKey("admin-key"), &whimv1.GetConvertCurrenciesRequest{Id: 10})
+		require.NoError(t, err)
+		assert.Equal(t, "c2", got.GetClientId())
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := handler.NewRateLimiter(handler.RateLimitRule{Method: "POST", Path: "/v1/convert-currencies", Rate: 0.001, Burst: 1})
+	conn, uc := serveLimited(t, limiter)
+	client := whimv1.NewConvertServiceClient(conn)
+
+	uc.convert.On("CreateConvertCurrencies", mock.Anything, mock.Anything).Return(nil)
+	uc.convert.On("GetConvertCurrencies", mock.Anything, mock.Anything).Return([]entity.ConvertCurrencies{}, int64(0), nil)
+
+	_, err := client.Convert(withKey("reader-key"), &whimv1.ConvertRequest{CurrencyIdFrom: 1, CurrencyIdTo: 2, Amount: 10})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.Convert(withKey("reader-key"), &whimv1.ConvertRequest{CurrencyIdFrom: 1, CurrencyIdTo: 2, Amount: 10}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NotEmpty(t, header.Get("retry-after"))
+	uc.convert.AssertNumberOfCalls(t, "CreateConvertCurrencies", 1)
+
+	_, err = client.Convert(withKey("admin-key"), &whimv1.ConvertRequest{CurrencyIdFrom: 1, CurrencyIdTo: 2, Amount: 10})
+	assert.NoError(t, err, "each caller has its own bucket")
+
+	_, err = client.ListConvertCurrencies(withKey("reader-key"), &whimv1.ListConvertCurrenciesRequest{})
+	assert.NoError(t, err, "the other methods are not limited by the rule of the conversions")
+
+	// the REST route takes its tokens from the same bucket
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/v1/convert-currencies", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{Subject: "reader"}))
+	handler.RateLimit(limiter)(http.NotFoundHandler()).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
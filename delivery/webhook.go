@@ -0,0 +1,179 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/usecase/webhook"
+)
+
+type WebhookHandler struct {
+	uc webhook.WebhookUsecase
+}
+
+func NewWebhookHandler(usecase webhook.WebhookUsecase) WebhookHandler {
+	return WebhookHandler{uc: usecase}
+}
+
+func (wh *WebhookHandler) Register(r *httprouter.Router) error {
+	if r == nil {
+		return errors.New("Passed router cannot be nil or empty")
+	}
+
+	r.GET("/v1/webhooks", auth.Require(auth.ManageWebhooks, wh.GetSubscriptions))
+	r.POST("/v1/webhooks", auth.Require(auth.ManageWebhooks, wh.CreateSubscription))
+	r.GET("/v1/webhooks/:id", auth.Require(auth.ManageWebhooks, wh.GetSubscription))
+	r.DELETE("/v1/webhooks/:id", auth.Require(auth.ManageWebhooks, wh.DeleteSubscription))
+	r.GET("/v1/webhooks/:id/deliveries", auth.Require(auth.ManageWebhooks, wh.GetDeliveries))
+
+	return nil
+}
+
+func (wh *WebhookHandler) GetSubscriptions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	helper := request.NewQueryHelper(r)
+
+	params := request.WebhookParameter{
+		Limit:  helper.GetLimit(),
+		Offset: helper.GetOffset(),
+	}
+
+	context := r.Context()
+	subs, total, err := wh.uc.GetSubscriptions(context, &params)
+
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	if len(subs) <= 0 {
+		m := response.MetaInfo{HTTPStatus: http.StatusNoContent}
+		response.Write(w, response.BuildSuccess(subs, m), http.StatusOK)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+		Offset:     params.Offset,
+		Limit:      params.Limit,
+		Total:      total,
+	}
+	response.Write(w, response.BuildSuccess(subs, meta), http.StatusOK)
+	return
+}
+
+func (wh *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	decoder := json.NewDecoder(r.Body)
+	var ws entity.WebhookSubscription
+	if err := decoder.Decode(&ws); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+	defer r.Body.Close()
+
+	context := r.Context()
+	if err := wh.uc.CreateSubscription(context, &ws); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusCreated,
+	}
+	response.Write(w, response.BuildSuccess(ws, meta), http.StatusCreated)
+	return
+}
+
+func (wh *WebhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	subscriptionID, err := strconv.ParseInt(p.ByName("id"), 10, 64)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	context := r.Context()
+	ws, err := wh.uc.GetSubscription(context, subscriptionID)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+	}
+	response.Write(w, response.BuildSuccess(ws, meta), http.StatusOK)
+	return
+}
+
+func (wh *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	subscriptionID, err := strconv.ParseInt(p.ByName("id"), 10, 64)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	context := r.Context()
+	if err := wh.uc.DeleteSubscription(context, subscriptionID); err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return
+}
+
+// GetDeliveries returns the delivery log of a subscription, the latest deliveries first
+func (wh *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	subscriptionID, err := strconv.ParseInt(p.ByName("id"), 10, 64)
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	helper := request.NewQueryHelper(r)
+
+	params := request.WebhookDeliveryParameter{
+		Limit:          helper.GetLimit(),
+		Offset:         helper.GetOffset(),
+		SubscriptionID: subscriptionID,
+		Status:         helper.GetString("status", ""),
+	}
+
+	context := r.Context()
+	deliveries, total, err := wh.uc.GetDeliveries(context, &params)
+
+	if err != nil {
+		errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+		response.Write(w, errBody, httpStatus)
+		return
+	}
+
+	if len(deliveries) <= 0 {
+		m := response.MetaInfo{HTTPStatus: http.StatusNoContent}
+		response.Write(w, response.BuildSuccess(deliveries, m), http.StatusOK)
+		return
+	}
+
+	meta := response.MetaInfo{
+		HTTPStatus: http.StatusOK,
+		Offset:     params.Offset,
+		Limit:      params.Limit,
+		Total:      total,
+	}
+	response.Write(w, response.BuildSuccess(deliveries, meta), http.StatusOK)
+	return
+}
@@ -0,0 +1,72 @@
+package delivery_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/delivery"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookRequest(t *testing.T) {
+	uc := new(mocks.WebhookUsecase)
+	webhookHandler := delivery.NewWebhookHandler(uc)
+
+	stubSubscriptions := []entity.WebhookSubscription{{ID: 1, URL: "https://example.com/hooks", Pairs: "1-2"}}
+	stubDeliveries := []entity.WebhookDelivery{{ID: 3, SubscriptionID: 1, EventType: "rate.updated", Status: entity.DeliveryFailed}}
+
+	uc.On("CreateSubscription", mock.Anything, mock.Anything).Return(nil)
+	uc.On("GetSubscriptions", mock.Anything, mock.Anything).Return(stubSubscriptions, int64(1), nil)
+	uc.On("GetSubscription", mock.Anything, int64(1)).Return(&stubSubscriptions[0], nil)
+	uc.On("GetSubscription", mock.Anything, int64(2)).Return((*entity.WebhookSubscription)(nil), fmt.Errorf("Not Found"))
+	uc.On("DeleteSubscription", mock.Anything, int64(1)).Return(nil)
+	uc.On("DeleteSubscription", mock.Anything, int64(2)).Return(fmt.Errorf("Not Found"))
+	uc.On("GetDeliveries", mock.Anything, mock.MatchedBy(func(p *request.WebhookDeliveryParameter) bool {
+		return p.SubscriptionID == 1 && p.Status == entity.DeliveryFailed
+	})).Return(stubDeliveries, int64(1), nil)
+
+	testCases := []struct {
+		name           string
+		role           auth.Role
+		method         string
+		endpoint       string
+		payload        []byte
+		expectedStatus int
+	}{
+		{"Create subscription", auth.RoleAdmin, "POST", "/v1/webhooks", []byte(`{"url":"https://example.com/hooks","pairs":"1-2"}`), http.StatusCreated},
+		{"List subscriptions", auth.RoleAdmin, "GET", "/v1/webhooks", nil, http.StatusOK},
+		{"Get subscription", auth.RoleAdmin, "GET", "/v1/webhooks/1", nil, http.StatusOK},
+		{"Get unknown subscription", auth.RoleAdmin, "GET", "/v1/webhooks/2", nil, http.StatusNotFound},
+		{"Delete subscription", auth.RoleAdmin, "DELETE", "/v1/webhooks/1", nil, http.StatusNoContent},
+		{"Delete unknown subscription", auth.RoleAdmin, "DELETE", "/v1/webhooks/2", nil, http.StatusNotFound},
+		{"Delivery log", auth.RoleAdmin, "GET", "/v1/webhooks/1/deliveries?status=failed", nil, http.StatusOK},
+		{"Create subscription as rate admin", auth.RoleRateAdmin, "POST", "/v1/webhooks", []byte(`{"url":"https://example.com/hooks"}`), http.StatusForbidden},
+		{"Delivery log anonymously", "", "GET", "/v1/webhooks/1/deliveries", nil, http.StatusUnauthorized},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			registrations := []handler.Registration{&webhookHandler}
+			if testCase.role != "" {
+				registrations = append(registrations, authenticateAs(testCase.role, "test"))
+			}
+			h := handler.NewHandler(registrations...)
+
+			stubRequest := NewConversionHTTPRequest(testCase.method, testCase.endpoint, "", testCase.payload)
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, stubRequest)
+			assert.Equal(t, testCase.expectedStatus, recorder.Code)
+		})
+	}
+
+	uc.AssertNumberOfCalls(t, "CreateSubscription", 1)
+	uc.AssertNumberOfCalls(t, "GetDeliveries", 1)
+}
@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+)
+
+//APIKey data. The plain key is only set when the key is issued, only its hash is stored.
+type APIKey struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	ClientID  string     `json:"client_id"`
+	TenantID  string     `json:"tenant_id"`
+	Role      string     `json:"role"`
+	Prefix    string     `json:"prefix"`
+	Key       string     `json:"key,omitempty"`
+	KeyHash   string     `json:"-"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -7,9 +7,11 @@ import (
 //Currency data
 type Conversion struct {
 	ID             int64     `json:"id"`
+	TenantID       string    `json:"tenant_id"`
 	CurrencyIDFrom int64     `json:"currency_id_from"`
 	CurrencyIDTo   int64     `json:"currency_id_to"`
 	Rate           float64   `json:"rate"`
+	Version        int64     `json:"version"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"time"
+)
+
+//ConversionQuota is the number of conversions a client may make in a calendar month (UTC).
+//A Limit of zero is unlimited.
+type ConversionQuota struct {
+	ClientID string    `json:"client_id"`
+	Period   string    `json:"period"`
+	Limit    int64     `json:"limit"`
+	Used     int64     `json:"used"`
+	ResetsAt time.Time `json:"resets_at"`
+}
@@ -1,9 +1,21 @@
 package entity
 
+import (
+	"time"
+)
+
 //Currency data
 type ConvertCurrencies struct {
-	CurrencyIDFrom int64   `json:"currency_id_from"`
-	CurrencyIDTo   int64   `json:"currency_id_to"`
-	Amount         float64 `json:"amount"`
-	Result         float64 `json:"result"`
+	ID             int64     `json:"id"`
+	TenantID       string    `json:"tenant_id"`
+	ConversionID   int64     `json:"conversion_id"`
+	QuoteID        int64     `json:"quote_id,omitempty"`
+	CurrencyIDFrom int64     `json:"currency_id_from"`
+	CurrencyIDTo   int64     `json:"currency_id_to"`
+	Amount         float64   `json:"amount"`
+	Rate           float64   `json:"rate"`
+	Result         float64   `json:"result"`
+	ClientID       string    `json:"client_id"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
 }
@@ -7,7 +7,9 @@ import (
 //Currency data
 type Currency struct {
 	ID        int64     `json:"id"`
+	TenantID  string    `json:"tenant_id"`
 	Name      string    `json:"name"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of the domain events, an event is named after the aggregate it changed
+const (
+	EventCurrencyCreated    = "currency.created"
+	EventCurrencyUpdated    = "currency.updated"
+	EventCurrencyDeleted    = "currency.deleted"
+	EventRateCreated        = "rate.created"
+	EventRateUpdated        = "rate.updated"
+	EventRateDeleted        = "rate.deleted"
+	EventConversionExecuted = "conversion.executed"
+)
+
+//DomainEvent data, a change recorded in the outbox in the same transaction as the change itself.
+//PublishedAt is set once the relay handed the event to the publisher.
+type DomainEvent struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	TenantID      string          `json:"tenant_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	PublishedAt   *time.Time      `json:"published_at"`
+}
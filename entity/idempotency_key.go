@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+)
+
+//IdempotencyKey data
+type IdempotencyKey struct {
+	Key             string              `json:"key"`
+	TenantID        string              `json:"tenant_id"`
+	ClientID        string              `json:"client_id"`
+	RequestHash     string              `json:"request_hash"`
+	ResponseStatus  int                 `json:"response_status"`
+	ResponseHeaders map[string][]string `json:"response_headers"`
+	ResponseBody    []byte              `json:"response_body"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+)
+
+//Quote data
+type Quote struct {
+	ID             int64      `json:"id"`
+	TenantID       string     `json:"tenant_id"`
+	ConversionID   int64      `json:"conversion_id"`
+	CurrencyIDFrom int64      `json:"currency_id_from"`
+	CurrencyIDTo   int64      `json:"currency_id_to"`
+	Rate           float64    `json:"rate"`
+	Amount         float64    `json:"amount"`
+	Result         float64    `json:"result"`
+	ClientID       string     `json:"client_id"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	ExecutedAt     *time.Time `json:"executed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package entity
+
+// Types of the cells of a rate matrix, by how their rate was found
+const (
+	RateIdentity    = "identity"
+	RateDirect      = "direct"
+	RateInverse     = "inverse"
+	RateDerived     = "derived"
+	RateUnavailable = "unavailable"
+)
+
+//RateMatrix data, the cross rates of Currencies, Base first. Rates[i][j] converts Currencies[i] to Currencies[j].
+type RateMatrix struct {
+	Base       string       `json:"base"`
+	Currencies []string     `json:"currencies"`
+	Rates      [][]RateCell `json:"rates"`
+}
+
+//RateCell data, the rate converting From to To. A derived rate goes through the currencies of Via, in order.
+type RateCell struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Rate float64  `json:"rate"`
+	Type string   `json:"type"`
+	Via  []string `json:"via,omitempty"`
+}
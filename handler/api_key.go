@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/logger"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/usecase/api_key"
+)
+
+// APIKeyAuth authenticates requests carrying an X-API-Key header. Requests with an unknown
+// or revoked key are rejected, requests without a key continue anonymously and are
+// rejected by the routes that require a role.
+func APIKeyAuth(uc api_key.APIKeyUsecase) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("X-API-Key")
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			ek, err := uc.Authenticate(ctx, key)
+			if err != nil && err.Error() == "Not Found" {
+				w.Header().Set("WWW-Authenticate", `ApiKey realm="whim"`)
+				response.Write(w, response.BuildError([]error{response.UnauthorizedError}), response.UnauthorizedError.HTTPCode)
+				return
+			}
+			if err != nil {
+				errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+				response.Write(w, errBody, httpStatus)
+				return
+			}
+
+			ctx = auth.WithPrincipal(ctx, &auth.Principal{
+				Subject:  ek.Prefix,
+				ClientID: ek.ClientID,
+				TenantID: ek.TenantID,
+				Role:     auth.Role(ek.Role),
+			})
+			ctx = request.WithClientID(ctx, ek.ClientID)
+			ctx = request.WithTenantID(ctx, ek.TenantID)
+			logger.AddFields(ctx, slog.String("client_id", ek.ClientID))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
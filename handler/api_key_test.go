@@ -0,0 +1,66 @@
+package handler_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type whoAmIRegistration struct{}
+
+func (whoAmIRegistration) Register(r *httprouter.Router) error {
+	r.GET("/v1/whoami", auth.Require(auth.ReadCurrencies, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		p := auth.PrincipalFromContext(r.Context())
+		fmt.Fprintf(w, "%s %s %s", p.Role, p.ClientID, request.ClientID(r.Context()))
+	}))
+	return nil
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	uc := new(mocks.APIKeyUsecase)
+	uc.On("Authenticate", mock.Anything, "whim_valid").Return(&entity.APIKey{Prefix: "whim_val", ClientID: "pricing", Role: "reader"}, nil)
+	uc.On("Authenticate", mock.Anything, "whim_revoked").Return(nil, errors.New("Not Found"))
+	uc.On("Authenticate", mock.Anything, "whim_broken").Return(nil, errors.New("connection refused"))
+
+	h := handler.NewHandler(handler.WithMiddleware(handler.APIKeyAuth(uc)), whoAmIRegistration{})
+
+	tests := []struct {
+		name           string
+		key            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"valid key", "whim_valid", http.StatusOK, "reader pricing pricing"},
+		{"revoked key", "whim_revoked", http.StatusUnauthorized, ""},
+		{"lookup failure", "whim_broken", http.StatusInternalServerError, ""},
+		{"no key", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost/v1/whoami", nil)
+			req.Header.Set("X-Client-ID", "spoofed")
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/logger"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/response"
+)
+
+// BearerAuth authenticates requests carrying an "Authorization: Bearer" token issued by
+// the identity provider. Requests with an invalid token are rejected, requests without
+// a token continue so they can be authenticated by another scheme.
+func BearerAuth(verifier *auth.JWTVerifier) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p, err := verifier.Verify(strings.TrimSpace(header[7:]))
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="whim", error="invalid_token"`)
+				response.Write(w, response.BuildError([]error{response.UnauthorizedError}), response.UnauthorizedError.HTTPCode)
+				return
+			}
+
+			ctx := auth.WithPrincipal(r.Context(), p)
+			ctx = request.WithClientID(ctx, p.ClientID)
+			ctx = request.WithTenantID(ctx, p.TenantID)
+			logger.AddFields(ctx, slog.String("client_id", p.ClientID))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scopedRegistration struct{}
+
+func (scopedRegistration) Register(r *httprouter.Router) error {
+	r.POST("/v1/currencies", auth.Require(auth.WriteCurrencies, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		p := auth.PrincipalFromContext(r.Context())
+		fmt.Fprintf(w, "%s %s", p.Subject, request.ClientID(r.Context()))
+	}))
+	return nil
+}
+
+func TestBearerAuth(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "EC",
+		"kid": "k1",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, ioutil.WriteFile(path, jwks, 0600))
+
+	keys, err := auth.NewJWKS(path, 0)
+	require.NoError(t, err)
+	verifier := &auth.JWTVerifier{Keys: keys, Issuer: "idp", Audience: "whim"}
+
+	token := func(scope string) string {
+		tk := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+			"iss":       "idp",
+			"aud":       "whim",
+			"sub":       "svc-pricing",
+			"client_id": "pricing",
+			"exp":       time.Now().Add(time.Minute).Unix(),
+			"scope":     scope,
+		})
+		tk.Header["kid"] = "k1"
+		s, err := tk.SignedString(key)
+		require.NoError(t, err)
+		return s
+	}
+
+	h := handler.NewHandler(handler.WithMiddleware(handler.BearerAuth(verifier)), scopedRegistration{})
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"write scope", "Bearer " + token("currencies:read currencies:write"), http.StatusOK, "svc-pricing pricing"},
+		{"read scope only", "Bearer " + token("currencies:read"), http.StatusForbidden, ""},
+		{"malformed token", "Bearer not-a-token", http.StatusUnauthorized, ""},
+		{"no token", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "http://localhost/v1/currencies", nil)
+			req.Header.Set("X-Client-ID", "spoofed")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/rbpermadi/whim_assignment/app/response"
+)
+
+// LimitBody rejects request bodies larger than maxBytes with 413. Bodies announced larger
+// are rejected before being read, the others fail once maxBytes have been read. A maxBytes
+// of zero disables the limit.
+func LimitBody(maxBytes int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		if maxBytes <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				response.Write(w, response.BuildError([]error{response.RequestEntityTooLargeError}), response.RequestEntityTooLargeError.HTTPCode)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
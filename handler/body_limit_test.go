@@ -0,0 +1,57 @@
+package handler_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+type bodyRegistration struct{}
+
+func (bodyRegistration) Register(r *httprouter.Router) error {
+	r.POST("/v1/echo", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+			response.Write(w, errBody, httpStatus)
+			return
+		}
+		w.Write(body)
+	})
+	return nil
+}
+
+func TestLimitBody(t *testing.T) {
+	h := handler.NewHandler(handler.WithMiddleware(handler.LimitBody(8)), bodyRegistration{})
+
+	tests := []struct {
+		name       string
+		body       string
+		chunked    bool
+		wantStatus int
+	}{
+		{name: "within limit", body: "12345678", wantStatus: http.StatusOK},
+		{name: "announced too large", body: "123456789", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "read too large", body: "123456789", chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "http://localhost/v1/echo", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/metrics"
 	"github.com/rbpermadi/whim_assignment/app/response"
 	"github.com/rs/cors"
 )
@@ -14,6 +15,24 @@ type Registration interface {
 	Register(r *httprouter.Router) error
 }
 
+// Middleware wraps the router with additional behaviour
+type Middleware func(http.Handler) http.Handler
+
+// middlewareRegistration lets middlewares be passed to NewHandler next to route registrations
+type middlewareRegistration struct {
+	mw Middleware
+}
+
+func (m middlewareRegistration) Register(_ *httprouter.Router) error {
+	return nil
+}
+
+// WithMiddleware returns a Registration that makes NewHandler wrap the router with mw.
+// Middlewares are applied in the order they are passed, the first one being the outermost.
+func WithMiddleware(mw Middleware) Registration {
+	return middlewareRegistration{mw: mw}
+}
+
 func Healthz(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	fmt.Fprintln(w, "ok")
@@ -33,13 +52,26 @@ func NewHandler(registrations ...Registration) http.Handler {
 	router.HandleMethodNotAllowed = false
 
 	router.HandlerFunc("GET", "/healthz", Healthz)
+	router.HandlerFunc("GET", "/livez", Livez)
+	router.Handler("GET", "/metrics", metrics.Handler())
+	router.HandlerFunc("GET", "/openapi.json", OpenAPI)
 	// start route
+	var middlewares []Middleware
 	for _, reg := range registrations {
+		if m, ok := reg.(middlewareRegistration); ok {
+			middlewares = append(middlewares, m.mw)
+			continue
+		}
 		reg.Register(router)
 	}
 
 	router.NotFound = http.HandlerFunc(NotFound)
 
+	var h http.Handler = router
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+
 	co := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"GET", "POST", "PATCH", "DELETE", "PUT", "HEAD", "OPTIONS"},
@@ -47,5 +79,5 @@ func NewHandler(registrations ...Registration) http.Handler {
 		MaxAge:         86400,
 	})
 
-	return co.Handler(router)
+	return co.Handler(RequestContext(router)(Trace(AccessLog(Instrument(h)))))
 }
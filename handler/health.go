@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+// HealthCheck checks a dependency of the service, Check returns a detail shown in the
+// readiness report, or an error when the dependency is not usable
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) (string, error)
+}
+
+type checkResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type healthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+// Readiness serves GET /readyz, it answers 200 when every check passes and 503 otherwise
+type Readiness struct {
+	timeout  time.Duration
+	checks   []HealthCheck
+	draining atomic.Bool
+}
+
+// NewReadiness returns a Registration of /readyz running checks concurrently, each of them
+// failing when it takes longer than timeout
+func NewReadiness(timeout time.Duration, checks ...HealthCheck) *Readiness {
+	return &Readiness{timeout: timeout, checks: checks}
+}
+
+func (rd *Readiness) Register(r *httprouter.Router) error {
+	if r == nil {
+		return errors.New("Passed router cannot be nil or empty")
+	}
+
+	r.HandlerFunc("GET", "/readyz", rd.Readyz)
+	return nil
+}
+
+// Drain makes /readyz answer 503 so the load balancer stops sending requests before the
+// server shuts down
+func (rd *Readiness) Drain() {
+	rd.draining.Store(true)
+}
+
+func (rd *Readiness) Readyz(w http.ResponseWriter, r *http.Request) {
+	if rd.draining.Load() {
+		writeHealth(w, healthReport{Status: "draining"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), rd.timeout)
+	defer cancel()
+
+	report := healthReport{Status: "ok", Checks: map[string]checkResult{}}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range rd.checks {
+		wg.Add(1)
+		go func(c HealthCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			detail, err := c.Check(ctx)
+			result := checkResult{
+				Status:    "ok",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+				Detail:    detail,
+			}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.Name] = result
+			if err != nil {
+				report.Status = "fail"
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(w, report, status)
+}
+
+// Livez answers 200 as long as the process serves requests, it does not check dependencies
+// so an unavailable database does not get the service restarted
+func Livez(w http.ResponseWriter, _ *http.Request) {
+	writeHealth(w, healthReport{Status: "ok"}, http.StatusOK)
+}
+
+func writeHealth(w http.ResponseWriter, report healthReport, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// DatabaseCheck pings the database
+func DatabaseCheck(repo repository.HealthRepo) HealthCheck {
+	return HealthCheck{
+		Name: "database",
+		Check: func(ctx context.Context) (string, error) {
+			return "", repo.Ping(ctx)
+		},
+	}
+}
+
+// MigrationCheck fails when tables or columns of the schema have not been created
+func MigrationCheck(repo repository.HealthRepo) HealthCheck {
+	return HealthCheck{
+		Name: "migrations",
+		Check: func(ctx context.Context) (string, error) {
+			missing, err := repo.MissingSchema(ctx)
+			if err != nil {
+				return "", err
+			}
+			if len(missing) > 0 {
+				return "", fmt.Errorf("missing tables or columns: %s", strings.Join(missing, ", "))
+			}
+			return fmt.Sprintf("%d tables", len(repository.Schema)), nil
+		},
+	}
+}
+
+// RateFreshnessCheck reports when a conversion rate was last updated, it fails when no rate
+// has been updated within maxAge. A zero maxAge only reports the age.
+func RateFreshnessCheck(repo repository.HealthRepo, maxAge time.Duration) HealthCheck {
+	return HealthCheck{
+		Name: "rates",
+		Check: func(ctx context.Context) (string, error) {
+			updatedAt, err := repo.LatestRateUpdate(ctx)
+			if err != nil {
+				return "", err
+			}
+			if updatedAt.IsZero() {
+				if maxAge > 0 {
+					return "", errors.New("no conversion rate")
+				}
+				return "no conversion rate", nil
+			}
+
+			age := time.Since(updatedAt).Truncate(time.Second)
+			detail := fmt.Sprintf("last updated %s ago", age)
+			if maxAge > 0 && age > maxAge {
+				return detail, fmt.Errorf("rates are older than %s", maxAge)
+			}
+			return detail, nil
+		},
+	}
+}
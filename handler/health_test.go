@@ -0,0 +1,128 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type healthReport struct {
+	Status string `json:"status"`
+	Checks map[string]struct {
+		Status string `json:"status"`
+		Detail string `json:"detail"`
+		Error  string `json:"error"`
+	} `json:"checks"`
+}
+
+func serveHealth(t *testing.T, h http.Handler, path string) (int, healthReport) {
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest("GET", "http://localhost"+path, nil))
+
+	var report healthReport
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&report))
+	return recorder.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		pingErr    error
+		missing    []string
+		updatedAt  time.Time
+		wantStatus int
+		wantChecks map[string]string
+	}{
+		{
+			name:       "ready",
+			updatedAt:  time.Now().Add(-time.Minute),
+			wantStatus: http.StatusOK,
+			wantChecks: map[string]string{"database": "ok", "migrations": "ok", "rates": "ok"},
+		},
+		{
+			name:       "database down",
+			pingErr:    errors.New("connection refused"),
+			updatedAt:  time.Now(),
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"database": "fail", "migrations": "ok", "rates": "ok"},
+		},
+		{
+			name:       "pending migration and stale rates",
+			missing:    []string{"api_keys"},
+			updatedAt:  time.Now().Add(-2 * time.Hour),
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"database": "ok", "migrations": "fail", "rates": "fail"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.HealthRepo)
+			repo.On("Ping", mock.Anything).Return(tt.pingErr)
+			repo.On("MissingSchema", mock.Anything).Return(tt.missing, nil)
+			repo.On("LatestRateUpdate", mock.Anything).Return(tt.updatedAt, nil)
+
+			h := handler.NewHandler(handler.NewReadiness(time.Second,
+				handler.DatabaseCheck(repo),
+				handler.MigrationCheck(repo),
+				handler.RateFreshnessCheck(repo, time.Hour),
+			))
+
+			status, report := serveHealth(t, h, "/readyz")
+			assert.Equal(t, tt.wantStatus, status)
+			for name, want := range tt.wantChecks {
+				assert.Equal(t, want, report.Checks[name].Status, name)
+			}
+
+			status, report = serveHealth(t, h, "/livez")
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "ok", report.Status)
+		})
+	}
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	slow := handler.HealthCheck{
+		Name: "slow",
+		Check: func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	h := handler.NewHandler(handler.NewReadiness(10*time.Millisecond, slow))
+
+	status, report := serveHealth(t, h, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "fail", report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestReadinessDrain(t *testing.T) {
+	repo := new(mocks.HealthRepo)
+	repo.On("Ping", mock.Anything).Return(nil)
+
+	readiness := handler.NewReadiness(time.Second, handler.DatabaseCheck(repo))
+	h := handler.NewHandler(readiness)
+
+	status, _ := serveHealth(t, h, "/readyz")
+	assert.Equal(t, http.StatusOK, status)
+
+	readiness.Drain()
+
+	status, report := serveHealth(t, h, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "draining", report.Status)
+
+	status, _ = serveHealth(t, h, "/livez")
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+// IdempotencyKeyTTL is how long a stored response is replayed for the same key
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// MaxIdempotencyKeyLength is the length of the longest key that can be stored
+const MaxIdempotencyKeyLength = 255
+
+// perRequestHeaders are the response headers of a request that are not replayed
+var perRequestHeaders = []string{"X-Request-Id", "X-Ratelimit-Limit", "X-Ratelimit-Remaining", "X-Ratelimit-Reset", "Retry-After"}
+
+// responseRecorder keeps a copy of the status, headers and body written by the next handler
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.header = rr.ResponseWriter.Header().Clone()
+	for _, h := range perRequestHeaders {
+		rr.header.Del(h)
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.WriteHeader(http.StatusOK)
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+// Idempotent runs call once for key and the tenant and client of ctx. The calls repeating the key within
+// IdempotencyKeyTTL do not run call: they get the key as stored by the first call, to replay
+// its response, IdempotencyKeyReusedError when their requestHash differs from the first one,
+// or IdempotencyKeyInProgressError while the first call is running. call sets the response
+// of the key and returns false when it must not be stored, like a server error, so the call
+// can be retried with the same key. The key is also released when storing the response fails
+// or call panics. A key longer than MaxIdempotencyKeyLength is rejected with IdempotencyKeyTooLongError.
+func Idempotent(ctx context.Context, repo repository.IdempotencyKeyRepo, key, requestHash string, call func(ek *entity.IdempotencyKey) bool) (*entity.IdempotencyKey, error) {
+	if len(key) > MaxIdempotencyKeyLength {
+		return nil, response.IdempotencyKeyTooLongError
+	}
+
+	clientID := request.ClientID(ctx)
+	now := time.Now().UTC()
+
+	ek := &entity.IdempotencyKey{
+		Key:         key,
+		TenantID:    request.TenantID(ctx),
+		ClientID:    clientID,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err := repo.CreateIdempotencyKey(ctx, ek)
+	if err != nil && strings.Contains(err.Error(), "Duplicate entry") {
+		stored, err := repo.GetIdempotencyKey(ctx, clientID, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if now.Sub(stored.CreatedAt) <= IdempotencyKeyTTL {
+			switch {
+			case stored.RequestHash != requestHash:
+				return nil, response.IdempotencyKeyReusedError
+			case stored.ResponseStatus == 0:
+				return nil, response.IdempotencyKeyInProgressError
+			}
+			return stored, nil
+		}
+
+		if err := repo.DeleteIdempotencyKey(ctx, clientID, key); err != nil {
+			return nil, err
+		}
+		err = repo.CreateIdempotencyKey(ctx, ek)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stored := false
+	defer func() {
+		if stored {
+			return
+		}
+		// the key is released even when the request is canceled, so it is not in progress until it expires
+		if err := repo.DeleteIdempotencyKey(context.WithoutCancel(ctx), clientID, key); err != nil {
+			slog.ErrorContext(ctx, "releasing idempotency key", slog.String("key", key), slog.String("error", err.Error()))
+		}
+	}()
+
+	if !call(ek) {
+		return nil, nil
+	}
+
+	ek.UpdatedAt = time.Now().UTC()
+	if err := repo.UpdateIdempotencyKey(ctx, ek); err != nil {
+		// the response is already sent, a retry with the key runs call again
+		slog.ErrorContext(ctx, "storing idempotent response", slog.String("key", key), slog.String("error", err.Error()))
+		return nil, nil
+	}
+	stored = true
+	return nil, nil
+}
+
+// Idempotency replays the first response of a POST request for repeated requests
+// carrying the same Idempotency-Key header. Keys are scoped per tenant and client and reusing
+// a key with a different method, path or body is rejected.
+func Idempotency(repo repository.IdempotencyKeyRepo) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				response.Write(w, response.BuildError([]error{response.RequestEntityTooLargeError}), response.RequestEntityTooLargeError.HTTPCode)
+				return
+			}
+			if err != nil {
+				response.Write(w, response.BuildError([]error{response.BadRequestError}), response.BadRequestError.HTTPCode)
+				return
+			}
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + string(body)))
+			stored, err := Idempotent(r.Context(), repo, key, hex.EncodeToString(hash[:]), func(ek *entity.IdempotencyKey) bool {
+				rec := &responseRecorder{ResponseWriter: w}
+				next.ServeHTTP(rec, r)
+
+				// server errors are not stored so the client can retry with the same key
+				if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+					return false
+				}
+
+				ek.ResponseStatus = rec.status
+				ek.ResponseHeaders = rec.header
+				ek.ResponseBody = rec.body.Bytes()
+				return true
+			})
+
+			var ce response.CustomError
+			switch {
+			case errors.As(err, &ce):
+				response.Write(w, response.BuildError([]error{ce}), ce.HTTPCode)
+			case err != nil:
+				errBody, httpStatus := response.BuildErrorAndStatus(err, "")
+				response.Write(w, errBody, httpStatus)
+			case stored != nil:
+				replay(w, stored)
+			}
+		})
+	}
+}
+
+// replay writes the stored response with its headers, the responses stored without them are JSON
+func replay(w http.ResponseWriter, stored *entity.IdempotencyKey) {
+	for k, v := range stored.ResponseHeaders {
+		w.Header()[k] = v
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.ResponseStatus)
+	w.Write(stored.ResponseBody)
+}
@@ -0,0 +1,211 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryIdempotencyKeyRepo struct {
+	mu        sync.Mutex
+	keys      map[string]entity.IdempotencyKey
+	updateErr error
+}
+
+func (m *memoryIdempotencyKeyRepo) CreateIdempotencyKey(ctx context.Context, ek *entity.IdempotencyKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := request.TenantID(ctx) + "/" + ek.ClientID + "/" + ek.Key
+	if _, ok := m.keys[id]; ok {
+		return fmt.Errorf("Error 1062: Duplicate entry '%s' for key 'PRIMARY'", ek.Key)
+	}
+	m.keys[id] = *ek
+	return nil
+}
+
+func (m *memoryIdempotencyKeyRepo) UpdateIdempotencyKey(ctx context.Context, ek *entity.IdempotencyKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.updateErr != nil {
+		return m.updateErr
+	}
+	m.keys[request.TenantID(ctx)+"/"+ek.ClientID+"/"+ek.Key] = *ek
+	return nil
+}
+
+func (m *memoryIdempotencyKeyRepo) DeleteIdempotencyKey(ctx context.Context, clientID, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, request.TenantID(ctx)+"/"+clientID+"/"+key)
+	return nil
+}
+
+func (m *memoryIdempotencyKeyRepo) GetIdempotencyKey(ctx context.Context, clientID, key string) (*entity.IdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ek, ok := m.keys[request.TenantID(ctx)+"/"+clientID+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("Not Found")
+	}
+	return &ek, nil
+}
+
+type countingRegistration struct {
+	calls  int
+	status int
+}
+
+func (c *countingRegistration) Register(r *httprouter.Router) error {
+	r.POST("/v1/things", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		c.calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", fmt.Sprintf("/v1/things/%d", c.calls))
+		w.Header().Set("X-Request-ID", fmt.Sprintf("request-%d", c.calls))
+		w.WriteHeader(c.status)
+		fmt.Fprintf(w, `{"call":%d}`, c.calls)
+	})
+	return nil
+}
+
+func postThing(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "http://localhost/v1/things", bytes.NewBufferString(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency(t *testing.T) {
+	repo := &memoryIdempotencyKeyRepo{keys: map[string]entity.IdempotencyKey{}}
+	reg := &countingRegistration{status: http.StatusCreated}
+	h := handler.NewHandler(handler.WithMiddleware(handler.Idempotency(repo)), reg)
+
+	first := postThing(h, "key-1", `{"name":"IDR"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, `{"call":1}`, first.Body.String())
+
+	replayed := postThing(h, "key-1", `{"name":"IDR"}`)
+	assert.Equal(t, http.StatusCreated, replayed.Code)
+	assert.Equal(t, `{"call":1}`, replayed.Body.String())
+	assert.Equal(t, "true", replayed.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "/v1/things/1", replayed.Header().Get("Location"), "the headers of the response are replayed")
+	assert.Equal(t, "application/json", replayed.Header().Get("Content-Type"))
+	assert.NotEqual(t, "request-1", replayed.Header().Get("X-Request-ID"), "the id of the first request is not replayed")
+	assert.Equal(t, 1, reg.calls)
+
+	reused := postThing(h, "key-1", `{"name":"USD"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Equal(t, 1, reg.calls)
+
+	postThing(h, "", `{"name":"IDR"}`)
+	postThing(h, "", `{"name":"IDR"}`)
+	assert.Equal(t, 3, reg.calls)
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	repo := &memoryIdempotencyKeyRepo{keys: map[string]entity.IdempotencyKey{}}
+	reg := &countingRegistration{status: http.StatusCreated}
+	h := handler.NewHandler(handler.WithMiddleware(handler.Idempotency(repo)), reg)
+
+	assert.Equal(t, http.StatusCreated, postThing(h, strings.Repeat("k", handler.MaxIdempotencyKeyLength), `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, postThing(h, strings.Repeat("k", handler.MaxIdempotencyKeyLength+1), `{}`).Code)
+	assert.Equal(t, 1, reg.calls, "a key that cannot be stored is rejected before the request runs")
+}
+
+func TestIdempotentScopedByTenant(t *testing.T) {
+	repo := &memoryIdempotencyKeyRepo{keys: map[string]entity.IdempotencyKey{}}
+	calls := 0
+	call := func(ek *entity.IdempotencyKey) bool {
+		calls++
+		ek.ResponseStatus = http.StatusCreated
+		return true
+	}
+
+	// the client pricing of acme and the client pricing of globex are different clients
+	acme := request.WithClientID(request.WithTenantID(context.TODO(), "acme"), "pricing")
+	globex := request.WithClientID(request.WithTenantID(context.TODO(), "globex"), "pricing")
+
+	stored, err := handler.Idempotent(acme, repo, "key-1", "hash", call)
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+	stored, err = handler.Idempotent(globex, repo, "key-1", "other-hash", call)
+	assert.NoError(t, err)
+	assert.Nil(t, stored, "the key of another tenant is not replayed")
+	assert.Equal(t, 2, calls)
+
+	stored, err = handler.Idempotent(acme, repo, "key-1", "hash", call)
+	assert.NoError(t, err)
+	if assert.NotNil(t, stored) {
+		assert.Equal(t, "acme", stored.TenantID)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	repo := &memoryIdempotencyKeyRepo{keys: map[string]entity.IdempotencyKey{}}
+	reg := &countingRegistration{status: http.StatusInternalServerError}
+	h := handler.NewHandler(handler.WithMiddleware(handler.Idempotency(repo)), reg)
+
+	assert.Equal(t, http.StatusInternalServerError, postThing(h, "key-1", `{}`).Code)
+
+	reg.status = http.StatusCreated
+	assert.Equal(t, http.StatusCreated, postThing(h, "key-1", `{}`).Code)
+	assert.Equal(t, 2, reg.calls)
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	repo := &memoryIdempotencyKeyRepo{keys: map[string]entity.IdempotencyKey{}}
+	reg := &countingRegistration{status: http.StatusCreated}
+	h := handler.NewHandler(handler.WithMiddleware(handler.Idempotency(repo)), reg)
+
+	first := postThing(h, "key-1", `{}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	// emulate the first request still running
+	ek, _ := repo.GetIdempotencyKey(context.TODO(), "", "key-1")
+	ek.ResponseStatus = 0
+	repo.UpdateIdempotencyKey(context.TODO(), ek)
+
+	assert.Equal(t, http.StatusConflict, postThing(h, "key-1", `{}`).Code)
+	assert.Equal(t, 1, reg.calls)
+}
+
+func TestIdempotencyReleasesKeyWhenNotStored(t *testing.T) {
+	repo := &memoryIdempotencyKeyRepo{keys: map[string]entity.IdempotencyKey{}, updateErr: fmt.Errorf("database is down")}
+	reg := &countingRegistration{status: http.StatusCreated}
+	h := handler.NewHandler(handler.WithMiddleware(handler.Idempotency(repo)), reg)
+
+	assert.Equal(t, http.StatusCreated, postThing(h, "key-1", `{}`).Code)
+	_, err := repo.GetIdempotencyKey(context.TODO(), "", "key-1")
+	assert.Error(t, err, "a key whose response is not stored is not left in progress")
+
+	repo.updateErr = nil
+	assert.Equal(t, http.StatusCreated, postThing(h, "key-1", `{}`).Code)
+	assert.Equal(t, 2, reg.calls)
+}
+
+func TestIdempotentReleasesKeyOnPanic(t *testing.T) {
+	repo := &memoryIdempotencyKeyRepo{keys: map[string]entity.IdempotencyKey{}}
+
+	assert.Panics(t, func() {
+		handler.Idempotent(context.TODO(), repo, "key-1", "hash", func(ek *entity.IdempotencyKey) bool {
+			panic("boom")
+		})
+	})
+
+	_, err := repo.GetIdempotencyKey(context.TODO(), "", "key-1")
+	assert.Error(t, err, "the key of a call that panicked is released")
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/request"
+)
+
+// validRequestID limits the X-Request-ID values accepted from callers, other values are replaced
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder keeps the status written by the next handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// code returns the status of the response, a handler writing nothing responds 200
+func (sr *statusRecorder) code() int {
+	if sr.status == 0 {
+		return http.StatusOK
+	}
+	return sr.status
+}
+
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// Hijack lets WebSocket handlers take over the connection, the response is then recorded as 101
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := sr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not implement http.Hijacker", sr.ResponseWriter)
+	}
+	if sr.status == 0 {
+		sr.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// routeProbe can only be matched by a parameter, httprouter does not allow ':' in static segments
+const routeProbe = ":"
+
+// routeOf returns the route pattern matching the request, like /v1/currencies/:id,
+// so requests to the same route are logged under one name. httprouter v1.3.0 does not
+// return the matched pattern, so a segment is named after a parameter only when the router
+// still matches it to that parameter once its value is replaced by routeProbe. A parameter
+// value equal to a static segment, like /v1/currencies/v1, is then labelled correctly.
+func routeOf(router *httprouter.Router, r *http.Request) string {
+	handle, params, _ := router.Lookup(r.Method, r.URL.Path)
+	if handle == nil {
+		return ""
+	}
+
+	segments := strings.Split(r.URL.Path, "/")
+	next := 0
+	for _, p := range params {
+		for i := next; i < len(segments); i++ {
+			if segments[i] != p.Value {
+				continue
+			}
+			probe := append([]string(nil), segments...)
+			probe[i] = routeProbe
+			if _, ps, _ := router.Lookup(r.Method, strings.Join(probe, "/")); ps.ByName(p.Key) == routeProbe {
+				segments[i] = ":" + p.Key
+				next = i + 1
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// AccessLog logs one record per request once it has been served
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(sr, r)
+
+		level := slog.LevelInfo
+		if sr.code() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", request.Route(r.Context())),
+			slog.Int("status", sr.code()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
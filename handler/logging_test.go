@@ -0,0 +1,89 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/logger"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type echoRegistration struct{}
+
+func (echoRegistration) Register(r *httprouter.Router) error {
+	r.GET("/v1/currencies/:id", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		slog.InfoContext(r.Context(), "loading currency")
+		w.WriteHeader(http.StatusTeapot)
+	})
+	return nil
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger.New(&buf, slog.LevelInfo))
+
+	keys := new(mocks.APIKeyUsecase)
+	keys.On("Authenticate", mock.Anything, "whim_valid").Return(&entity.APIKey{Prefix: "whim_val", ClientID: "pricing", Role: "reader"}, nil)
+	h := handler.NewHandler(handler.WithMiddleware(handler.APIKeyAuth(keys)), echoRegistration{})
+
+	t.Run("propagates the request id of the caller", func(t *testing.T) {
+		buf.Reset()
+		req := httptest.NewRequest("GET", "http://localhost/v1/currencies/7", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		req.Header.Set("X-API-Key", "whim_valid")
+		req.Header.Set("X-Client-ID", "spoofed")
+
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+		assert.Equal(t, "req-1", recorder.Header().Get("X-Request-ID"))
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		assert.Len(t, lines, 2)
+
+		var handled, access map[string]interface{}
+		assert.NoError(t, json.Unmarshal(lines[0], &handled))
+		assert.NoError(t, json.Unmarshal(lines[1], &access))
+
+		assert.Equal(t, "req-1", handled["request_id"])
+		assert.Equal(t, "request", access["msg"])
+		assert.Equal(t, "req-1", access["request_id"])
+		assert.Equal(t, "pricing", access["client_id"])
+		assert.Equal(t, "GET", access["method"])
+		assert.Equal(t, "/v1/currencies/:id", access["route"])
+		assert.Equal(t, float64(http.StatusTeapot), access["status"])
+		assert.Contains(t, access, "latency_ms")
+	})
+
+	t.Run("labels the route of a parameter equal to a static segment", func(t *testing.T) {
+		buf.Reset()
+		req := httptest.NewRequest("GET", "http://localhost/v1/currencies/v1", nil)
+		req.Header.Set("X-Client-ID", "spoofed")
+
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		var access map[string]interface{}
+		assert.NoError(t, json.Unmarshal(lines[len(lines)-1], &access))
+		assert.Equal(t, "/v1/currencies/:id", access["route"])
+		assert.NotContains(t, access, "client_id", "the client is only logged for an authenticated caller")
+	})
+
+	t.Run("replaces an invalid request id", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://localhost/v1/currencies/7", nil)
+		req.Header.Set("X-Request-ID", "bad id\nwith newline")
+
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+		assert.Len(t, recorder.Header().Get("X-Request-ID"), 32)
+	})
+}
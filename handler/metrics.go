@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/request"
+)
+
+// Instrument counts and times requests by route and status. Requests matching no route
+// are grouped under one route so unknown paths do not create new series.
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(sr, r)
+
+		route := request.Route(r.Context())
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(sr.code())
+
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,36 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	h := handler.NewHandler(okRegistration{})
+
+	matched := metrics.HTTPRequests.WithLabelValues("GET", "/v1/currencies", "200")
+	unmatched := metrics.HTTPRequests.WithLabelValues("GET", "unmatched", "404")
+	matchedBefore, unmatchedBefore := testutil.ToFloat64(matched), testutil.ToFloat64(unmatched)
+
+	for _, path := range []string{"/v1/currencies", "/v1/currencies", "/unknown/path"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost"+path, nil))
+	}
+
+	assert.Equal(t, matchedBefore+2, testutil.ToFloat64(matched))
+	assert.Equal(t, unmatchedBefore+1, testutil.ToFloat64(unmatched))
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest("GET", "http://localhost/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	body := recorder.Body.String()
+	assert.Contains(t, body, `whim_http_requests_total{method="GET",route="/v1/currencies",status="200"}`)
+	assert.Contains(t, body, `whim_http_request_duration_seconds_bucket{method="GET",route="/v1/currencies",status="200"`)
+	assert.Contains(t, body, "go_goroutines")
+}
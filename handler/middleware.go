@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/logger"
+	"github.com/rbpermadi/whim_assignment/app/request"
+)
+
+// RequestContext copies the Idempotency-Key header into the request context so usecases
+// can read it without depending on net/http. The client is not taken from a header, it is
+// set by the authentication middlewares from the authenticated caller. It also gives the
+// request an id, taken from a valid X-Request-ID header or generated, returned in the
+// response so callers can correlate their logs with ours, and resolves the route of the
+// request once for the tracing, logging and metrics middlewares.
+func RequestContext(router *httprouter.Router) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get("X-Request-ID")
+			if !validRequestID.MatchString(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set("X-Request-ID", requestID)
+
+			ctx := logger.NewContext(request.WithRequestID(r.Context(), requestID))
+			ctx = request.WithRoute(ctx, routeOf(router, r))
+			if key := r.Header.Get("Idempotency-Key"); key != "" {
+				ctx = request.WithIdempotencyKey(ctx, key)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/rbpermadi/whim_assignment/app/openapi"
+	"github.com/rbpermadi/whim_assignment/app/response"
+)
+
+// OpenAPI serves the OpenAPI document of the API
+func OpenAPI(w http.ResponseWriter, _ *http.Request) {
+	b, err := openapi.JSON()
+	if err != nil {
+		response.Write(w, response.BuildError([]error{response.UnexpectedServerError}), response.UnexpectedServerError.HTTPCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// ValidateRequests rejects requests whose parameters or body do not match the operation of
+// doc with 400. Requests to paths missing from doc are passed on, the router answers them.
+func ValidateRequests(doc *openapi.Document) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			template, op, pathParams := doc.Find(r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			err := validateParameters(r, doc.Parameters(template, op), pathParams)
+			if err == nil && op.RequestBody != nil {
+				err = validateBody(r, op.RequestBody)
+			}
+
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				response.Write(w, response.BuildError([]error{response.RequestEntityTooLargeError}), response.RequestEntityTooLargeError.HTTPCode)
+				return
+			}
+			if err != nil {
+				errBody, httpStatus := response.BuildErrorAndStatus(fmt.Errorf("Bad Request: %v", err), "")
+				response.Write(w, errBody, httpStatus)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func validateParameters(r *http.Request, params []*openapi.Parameter, pathParams map[string]string) error {
+	query := r.URL.Query()
+	for _, p := range params {
+		var (
+			raw     string
+			present bool
+		)
+		switch p.In {
+		case "path":
+			raw, present = pathParams[p.Name]
+		case "query":
+			raw, present = query.Get(p.Name), query.Has(p.Name)
+		case "header":
+			raw = r.Header.Get(p.Name)
+			present = raw != ""
+		}
+
+		if !present {
+			if p.Required {
+				return fmt.Errorf("%s is required", p.Name)
+			}
+			continue
+		}
+		if err := openapi.ValidateParameter(p, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBody checks the body against the schema of its media type, then restores it for
+// the handlers
+func validateBody(r *http.Request, rb *openapi.RequestBody) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	mt, ok := rb.Content[mediaType]
+	if !ok {
+		mediaType, mt = "application/json", rb.Content["application/json"]
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if rb.Required {
+			return errors.New("body is required")
+		}
+		return nil
+	}
+	if mt == nil || mt.Schema == nil {
+		return nil
+	}
+	return mt.Schema.ValidateBody(body, mediaType == "application/merge-patch+json" || r.Method == "PATCH")
+}
@@ -0,0 +1,134 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/openapi"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type conversionRegistration struct{}
+
+func (conversionRegistration) Register(r *httprouter.Router) error {
+	echo := func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}
+	r.POST("/v1/conversions", echo)
+	r.PATCH("/v1/conversions/:id", echo)
+	r.GET("/v1/conversions", echo)
+	return nil
+}
+
+func TestOpenAPI(t *testing.T) {
+	h := handler.NewHandler()
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest("GET", "http://localhost/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var doc map[string]interface{}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+}
+
+func TestValidateRequests(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	h := handler.NewHandler(handler.WithMiddleware(handler.ValidateRequests(doc)), conversionRegistration{})
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantError   string
+	}{
+		{
+			name:       "valid body reaches the handler untouched",
+			method:     "POST",
+			path:       "/v1/conversions",
+			body:       `{"currency_id_from":1,"currency_id_to":2,"rate":0.5}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "missing field",
+			method:     "POST",
+			path:       "/v1/conversions",
+			body:       `{"currency_id_from":1,"rate":0.5}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Bad Request: currency_id_to is required",
+		},
+		{
+			name:       "missing body",
+			method:     "POST",
+			path:       "/v1/conversions",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Bad Request: body is required",
+		},
+		{
+			name:        "merge patch",
+			method:      "PATCH",
+			path:        "/v1/conversions/3",
+			contentType: "application/merge-patch+json",
+			body:        `{"rate":2}`,
+			wantStatus:  http.StatusCreated,
+		},
+		{
+			name:       "invalid path parameter",
+			method:     "PATCH",
+			path:       "/v1/conversions/abc",
+			body:       `{"rate":2}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Bad Request: id must be an integer",
+		},
+		{
+			name:       "invalid query parameter",
+			method:     "GET",
+			path:       "/v1/conversions?limit=ten",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Bad Request: limit must be an integer",
+		},
+		{
+			name:       "undocumented path",
+			method:     "GET",
+			path:       "/v2/unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://localhost"+tt.path, strings.NewReader(tt.body))
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			req.Header.Set("Content-Type", contentType)
+
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			if tt.wantStatus == http.StatusCreated {
+				assert.Equal(t, tt.body, recorder.Body.String())
+			}
+			if tt.wantError != "" {
+				assert.Contains(t, recorder.Body.String(), tt.wantError)
+			}
+		})
+	}
+}
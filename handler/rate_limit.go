@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/response"
+)
+
+// RateLimitRule allows Burst requests at once, refilled at Rate requests per second, for
+// each caller of the routes it matches. Method and Path are matched like httprouter routes,
+// an empty Method or Path matches every method or path. A Rate of zero exempts the routes
+// from the rules after it.
+type RateLimitRule struct {
+	Method string
+	Path   string
+	Rate   float64
+	Burst  int
+}
+
+func (rule RateLimitRule) matches(method string, path []string) bool {
+	if rule.Method != "" && rule.Method != method {
+		return false
+	}
+	if rule.Path == "" {
+		return true
+	}
+
+	pattern := strings.Split(strings.Trim(rule.Path, "/"), "/")
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, segment := range pattern {
+		if !strings.HasPrefix(segment, ":") && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter keeps a token bucket per caller and rule
+type RateLimiter struct {
+	rules []RateLimitRule
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// NewRateLimiter returns a limiter applying the first of rules that matches a request.
+// Requests matching none of the rules are not limited.
+func NewRateLimiter(rules ...RateLimitRule) *RateLimiter {
+	return &RateLimiter{
+		rules:   rules,
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// take removes a token from the bucket of key, it returns the tokens left and, when the
+// bucket is empty, how long until the next token is available
+func (l *RateLimiter) take(key string, rule RateLimitRule) (remaining int, wait time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(rule.Burst), updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(rule.Burst), b.tokens+now.Sub(b.updated).Seconds()*rule.Rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+		return 0, wait, false
+	}
+
+	b.tokens--
+	return int(b.tokens), 0, true
+}
+
+// sweep drops the buckets that have been idle for a minute, a full bucket is the same as no bucket
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// match returns the rule limiting the route of method and path, and its index, ok is false
+// when no rule limits the route
+func (l *RateLimiter) match(method, path string) (i int, rule RateLimitRule, ok bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, rule := range l.rules {
+		if !rule.matches(method, segments) {
+			continue
+		}
+		return i, rule, rule.Rate > 0
+	}
+	return 0, RateLimitRule{}, false
+}
+
+// Allow takes a token for the call of the route of method and path, from the bucket of the
+// caller of ctx or, when it is anonymous, of addr. The buckets are those of the REST API, so
+// the other APIs share the limits of the routes they call the usecases of. When the bucket is
+// empty it returns false and how long until the next token is available.
+func (l *RateLimiter) Allow(ctx context.Context, method, path, addr string) (time.Duration, bool) {
+	i, rule, ok := l.match(method, path)
+	if !ok {
+		return 0, true
+	}
+
+	_, wait, ok := l.take(fmt.Sprintf("%d:%s", i, callerKeyOf(ctx, addr)), rule)
+	return wait, ok
+}
+
+// callerKey identifies the caller of r by its credentials, or by its address when it is anonymous
+func callerKey(r *http.Request) string {
+	return callerKeyOf(r.Context(), r.RemoteAddr)
+}
+
+// callerKeyOf identifies the caller of ctx by its credentials, or by addr when it is anonymous
+func callerKeyOf(ctx context.Context, addr string) string {
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		return "principal:" + p.Subject
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return "ip:" + host
+}
+
+// RateLimit rejects callers exceeding the rule of the route with 429. It has to be placed
+// after the authentication middlewares so callers are limited per API key or token.
+func RateLimit(l *RateLimiter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if i, rule, ok := l.match(r.Method, r.URL.Path); ok {
+				remaining, wait, ok := l.take(fmt.Sprintf("%d:%s", i, callerKey(r)), rule)
+				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rule.Burst))
+				w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+				w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(float64(rule.Burst-remaining)/rule.Rate))))
+
+				if !ok {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+					response.Write(w, response.BuildError([]error{response.TooManyRequestsError}), response.TooManyRequestsError.HTTPCode)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
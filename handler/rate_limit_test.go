@@ -0,0 +1,91 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+type okRegistration struct{}
+
+func (okRegistration) Register(r *httprouter.Router) error {
+	ok := func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	}
+	r.GET("/v1/currencies", ok)
+	r.POST("/v1/convert-currencies", ok)
+	r.POST("/v1/quotes/:id/execute", ok)
+	return nil
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := handler.NewRateLimiter(
+		handler.RateLimitRule{Method: "GET", Path: "/v1/currencies", Rate: 0},
+		handler.RateLimitRule{Method: "POST", Path: "/v1/convert-currencies", Rate: 0.01, Burst: 2},
+		handler.RateLimitRule{Method: "POST", Path: "/v1/quotes/:id/execute", Rate: 0.01, Burst: 1},
+		handler.RateLimitRule{Rate: 0.01, Burst: 5},
+	)
+
+	asClient := func(subject string) handler.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if subject != "" {
+					r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{Subject: subject}))
+				}
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	serve := func(subject, method, path, remoteAddr string) *httptest.ResponseRecorder {
+		h := handler.NewHandler(handler.WithMiddleware(asClient(subject)), handler.WithMiddleware(handler.RateLimit(limiter)), okRegistration{})
+		req := httptest.NewRequest(method, "http://localhost"+path, nil)
+		req.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	t.Run("limits a client per route", func(t *testing.T) {
+		first := serve("pricing", "POST", "/v1/convert-currencies", "10.0.0.1:1000")
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", first.Header().Get("X-RateLimit-Remaining"))
+
+		assert.Equal(t, http.StatusOK, serve("pricing", "POST", "/v1/convert-currencies", "10.0.0.1:1000").Code)
+
+		limited := serve("pricing", "POST", "/v1/convert-currencies", "10.0.0.1:1000")
+		assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+		assert.Equal(t, "0", limited.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "100", limited.Header().Get("Retry-After"))
+		assert.NotEmpty(t, limited.Header().Get("X-RateLimit-Reset"))
+	})
+
+	t.Run("other clients have their own bucket", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("billing", "POST", "/v1/convert-currencies", "10.0.0.1:1000").Code)
+	})
+
+	t.Run("route parameters share a bucket", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("pricing", "POST", "/v1/quotes/1/execute", "10.0.0.1:1000").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve("pricing", "POST", "/v1/quotes/2/execute", "10.0.0.1:1000").Code)
+	})
+
+	t.Run("anonymous callers are limited by address", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("", "POST", "/v1/quotes/1/execute", "10.0.0.2:1000").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve("", "POST", "/v1/quotes/1/execute", "10.0.0.2:2000").Code)
+		assert.Equal(t, http.StatusOK, serve("", "POST", "/v1/quotes/1/execute", "10.0.0.3:1000").Code)
+	})
+
+	t.Run("exempt route", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			recorder := serve("pricing", "GET", "/v1/currencies", "10.0.0.1:1000")
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Empty(t, recorder.Header().Get("X-RateLimit-Limit"))
+		}
+	})
+}
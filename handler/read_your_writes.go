@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+)
+
+// WriteTracker remembers the callers that changed data recently, so their reads can go to the
+// primary database until the replicas caught up with their changes. The writes are remembered
+// in process: a read served by another instance, or over gRPC, may not see a write made right
+// before it. GraphQL requests are sent with POST, so they always read from the primary.
+type WriteTracker struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	writes map[string]time.Time
+	swept  time.Time
+}
+
+// NewWriteTracker returns a tracker sending the reads of a caller to the primary for window
+// after its last write
+func NewWriteTracker(window time.Duration) *WriteTracker {
+	return &WriteTracker{
+		window: window,
+		writes: map[string]time.Time{},
+	}
+}
+
+func (t *WriteTracker) wrote(key string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.writes[key] = now
+	if now.Sub(t.swept) < time.Minute {
+		return
+	}
+	for k, at := range t.writes {
+		if now.Sub(at) > t.window {
+			delete(t.writes, k)
+		}
+	}
+	t.swept = now
+}
+
+func (t *WriteTracker) wroteRecently(key string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	at, ok := t.writes[key]
+	return ok && now.Sub(at) <= t.window
+}
+
+// ReadYourWrites sends the queries of the requests changing data, and the reads of their
+// caller for a while after, to the primary database. It has to be placed after the
+// authentication middlewares so callers are told apart by API key or token.
+func ReadYourWrites(t *WriteTracker) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := callerKey(r)
+			now := time.Now()
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				if !t.wroteRecently(key, now) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			default:
+				t.wrote(key, now)
+			}
+
+			next.ServeHTTP(w, r.WithContext(request.WithReadPrimary(r.Context())))
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	var readPrimary bool
+	h := handler.ReadYourWrites(handler.NewWriteTracker(50 * time.Millisecond))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		readPrimary = request.ReadPrimary(r.Context())
+	}))
+
+	serve := func(subject, method string) bool {
+		req := httptest.NewRequest(method, "http://localhost/v1/currencies", nil)
+		req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{Subject: subject}))
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		return readPrimary
+	}
+
+	assert.False(t, serve("pricing", http.MethodGet), "reads go to the replicas")
+	assert.True(t, serve("pricing", http.MethodPost), "writes go to the primary")
+	assert.True(t, serve("pricing", http.MethodGet), "the reads of the writer go to the primary")
+	assert.False(t, serve("reporting", http.MethodGet), "the reads of the other callers go to the replicas")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, serve("pricing", http.MethodGet), "the replicas caught up with the write")
+}
@@ -0,0 +1,84 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/rbpermadi/whim_assignment/handler"
+	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/currency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type currencyRegistration struct {
+	uc currency.CurrencyUsecase
+}
+
+func (c currencyRegistration) Register(r *httprouter.Router) error {
+	r.GET("/v1/currencies/:id", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if _, err := c.uc.GetCurrency(r.Context(), 7); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	return nil
+}
+
+func TestTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM currencies WHERE id = 7 AND tenant_id = ''")).
+		WillReturnError(assert.AnError)
+
+	uc := currency.NewService(&currency.Provider{Repo: repository.NewMysqlCurrency(db)})
+	h := handler.NewHandler(currencyRegistration{uc})
+
+	req := httptest.NewRequest("GET", "http://localhost/v1/currencies/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	query, usecase, server := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "GET /v1/currencies/:id", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.True(t, server.Parent().IsRemote())
+	assert.Contains(t, server.Attributes(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+	assert.Equal(t, codes.Error, server.Status().Code)
+
+	assert.Equal(t, "currency.GetCurrency", usecase.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), usecase.Parent().SpanID())
+
+	assert.Equal(t, "SELECT currencies", query.Name())
+	assert.Equal(t, trace.SpanKindClient, query.SpanKind())
+	assert.Equal(t, usecase.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Contains(t, query.Attributes(), attribute.String("db.system", "mysql"))
+	assert.Contains(t, query.Attributes(), attribute.String("db.statement", "SELECT id, tenant_id, name, version, updated_at, created_at FROM currencies WHERE id = ? AND tenant_id = ?"))
+	assert.Equal(t, codes.Error, query.Status().Code)
+}
@@ -0,0 +1,115 @@
+package mocks
+
+import (
+	context "context"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type APIKeyRepo struct {
+	mock.Mock
+}
+
+func (_m *APIKeyRepo) CreateAPIKey(ctx context.Context, ek *entity.APIKey) error {
+	ret := _m.Called(ctx, ek)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.APIKey) error); ok {
+		r0 = rf(ctx, ek)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *APIKeyRepo) RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error {
+	ret := _m.Called(ctx, id, revokedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) error); ok {
+		r0 = rf(ctx, id, revokedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAPIKey provides a mock function with given fields: ctx, id
+func (_m *APIKeyRepo) GetAPIKey(ctx context.Context, id int64) (*entity.APIKey, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *entity.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.APIKey); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIKeyByHash provides a mock function with given fields: ctx, keyHash
+func (_m *APIKeyRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	ret := _m.Called(ctx, keyHash)
+
+	var r0 *entity.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.APIKey); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *APIKeyRepo) GetAPIKeys(ctx context.Context, p *request.APIKeyParameter) ([]entity.APIKey, int64, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 []entity.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, *request.APIKeyParameter) []entity.APIKey); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.APIKey)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, *request.APIKeyParameter) int64); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *request.APIKeyParameter) error); ok {
+		r2 = rf(ctx, p)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -0,0 +1,103 @@
+package mocks
+
+import (
+	context "context"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type APIKeyUsecase struct {
+	mock.Mock
+}
+
+func (_m *APIKeyUsecase) IssueAPIKey(ctx context.Context, ek *entity.APIKey) error {
+	ret := _m.Called(ctx, ek)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.APIKey) error); ok {
+		r0 = rf(ctx, ek)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *APIKeyUsecase) RevokeAPIKey(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *APIKeyUsecase) GetAPIKeys(ctx context.Context, p *request.APIKeyParameter) ([]entity.APIKey, int64, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 []entity.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, *request.APIKeyParameter) []entity.APIKey); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.APIKey)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, *request.APIKeyParameter) int64); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *request.APIKeyParameter) error); ok {
+		r2 = rf(ctx, p)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+func (_m *APIKeyUsecase) Authenticate(ctx context.Context, key string) (*entity.APIKey, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *entity.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.APIKey); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *APIKeyUsecase) BootstrapAPIKey(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,96 @@
+package mocks
+
+import (
+	context "context"
+	"time"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+type ConversionQuotaRepo struct {
+	mock.Mock
+}
+
+// GetQuotaLimit provides a mock function with given fields: ctx, clientID
+func (_m *ConversionQuotaRepo) GetQuotaLimit(ctx context.Context, clientID string) (int64, error) {
+	ret := _m.Called(ctx, clientID)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, clientID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetQuotaLimit provides a mock function with given fields: ctx, clientID, limit, now
+func (_m *ConversionQuotaRepo) SetQuotaLimit(ctx context.Context, clientID string, limit int64, now time.Time) error {
+	ret := _m.Called(ctx, clientID, limit, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, time.Time) error); ok {
+		r0 = rf(ctx, clientID, limit, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetQuotaUsage provides a mock function with given fields: ctx, clientID, period
+func (_m *ConversionQuotaRepo) GetQuotaUsage(ctx context.Context, clientID, period string) (int64, error) {
+	ret := _m.Called(ctx, clientID, period)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(ctx, clientID, period)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, clientID, period)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementQuotaUsage provides a mock function with given fields: ctx, clientID, period, limit, now
+func (_m *ConversionQuotaRepo) IncrementQuotaUsage(ctx context.Context, clientID, period string, limit int64, now time.Time) error {
+	ret := _m.Called(ctx, clientID, period, limit, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, time.Time) error); ok {
+		r0 = rf(ctx, clientID, period, limit, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DecrementQuotaUsage provides a mock function with given fields: ctx, clientID, period, now
+func (_m *ConversionQuotaRepo) DecrementQuotaUsage(ctx context.Context, clientID, period string, now time.Time) error {
+	ret := _m.Called(ctx, clientID, period, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) error); ok {
+		r0 = rf(ctx, clientID, period, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
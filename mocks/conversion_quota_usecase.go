@@ -0,0 +1,86 @@
+package mocks
+
+import (
+	context "context"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type ConversionQuotaUsecase struct {
+	mock.Mock
+}
+
+// ConsumeConversion provides a mock function with given fields: ctx
+func (_m *ConversionQuotaUsecase) ConsumeConversion(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseConversion provides a mock function with given fields: ctx
+func (_m *ConversionQuotaUsecase) ReleaseConversion(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetConversionQuota provides a mock function with given fields: ctx, clientID
+func (_m *ConversionQuotaUsecase) GetConversionQuota(ctx context.Context, clientID string) (*entity.ConversionQuota, error) {
+	ret := _m.Called(ctx, clientID)
+
+	var r0 *entity.ConversionQuota
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.ConversionQuota); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ConversionQuota)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, clientID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetConversionQuota provides a mock function with given fields: ctx, clientID, limit
+func (_m *ConversionQuotaUsecase) SetConversionQuota(ctx context.Context, clientID string, limit int64) (*entity.ConversionQuota, error) {
+	ret := _m.Called(ctx, clientID, limit)
+
+	var r0 *entity.ConversionQuota
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *entity.ConversionQuota); ok {
+		r0 = rf(ctx, clientID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ConversionQuota)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, clientID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -3,6 +3,7 @@ package mocks
 import (
 	context "context"
 
+	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/entity"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -23,3 +24,54 @@ func (_m *ConvertCurrenciesUsecase) CreateConvertCurrencies(ctx context.Context,
 
 	return r0
 }
+
+func (_m *ConvertCurrenciesUsecase) GetConvertCurrency(ctx context.Context, id int64) (*entity.ConvertCurrencies, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *entity.ConvertCurrencies
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.ConvertCurrencies); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ConvertCurrencies)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *ConvertCurrenciesUsecase) GetConvertCurrencies(ctx context.Context, p *request.ConvertCurrenciesParameter) ([]entity.ConvertCurrencies, int64, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 []entity.ConvertCurrencies
+	if rf, ok := ret.Get(0).(func(context.Context, *request.ConvertCurrenciesParameter) []entity.ConvertCurrencies); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.ConvertCurrencies)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, *request.ConvertCurrenciesParameter) int64); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *request.ConvertCurrenciesParameter) error); ok {
+		r2 = rf(ctx, p)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
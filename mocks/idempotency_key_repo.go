@@ -0,0 +1,73 @@
+package mocks
+
+import (
+	context "context"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type IdempotencyKeyRepo struct {
+	mock.Mock
+}
+
+func (_m *IdempotencyKeyRepo) CreateIdempotencyKey(ctx context.Context, ek *entity.IdempotencyKey) error {
+	ret := _m.Called(ctx, ek)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.IdempotencyKey) error); ok {
+		r0 = rf(ctx, ek)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *IdempotencyKeyRepo) UpdateIdempotencyKey(ctx context.Context, ek *entity.IdempotencyKey) error {
+	ret := _m.Called(ctx, ek)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.IdempotencyKey) error); ok {
+		r0 = rf(ctx, ek)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *IdempotencyKeyRepo) DeleteIdempotencyKey(ctx context.Context, clientID string, key string) error {
+	ret := _m.Called(ctx, clientID, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, clientID, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *IdempotencyKeyRepo) GetIdempotencyKey(ctx context.Context, clientID string, key string) (*entity.IdempotencyKey, error) {
+	ret := _m.Called(ctx, clientID, key)
+
+	var r0 *entity.IdempotencyKey
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *entity.IdempotencyKey); ok {
+		r0 = rf(ctx, clientID, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.IdempotencyKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, clientID, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,68 @@
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type OutboxRepo struct {
+	mock.Mock
+}
+
+func (_m *OutboxRepo) ClaimEvents(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]entity.DomainEvent, error) {
+	ret := _m.Called(ctx, owner, now, lease, limit)
+
+	var r0 []entity.DomainEvent
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Duration, int) []entity.DomainEvent); ok {
+		r0 = rf(ctx, owner, now, lease, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.DomainEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Duration, int) error); ok {
+		r1 = rf(ctx, owner, now, lease, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *OutboxRepo) MarkPublished(ctx context.Context, ids []int64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *OutboxRepo) PurgePublished(ctx context.Context, before time.Time, limit int) (int64, error) {
+	ret := _m.Called(ctx, before, limit)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int64); ok {
+		r0 = rf(ctx, before, limit)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
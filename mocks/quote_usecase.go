@@ -0,0 +1,69 @@
+package mocks
+
+import (
+	context "context"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type QuoteUsecase struct {
+	mock.Mock
+}
+
+func (_m *QuoteUsecase) CreateQuote(ctx context.Context, eq *entity.Quote) error {
+	ret := _m.Called(ctx, eq)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.Quote) error); ok {
+		r0 = rf(ctx, eq)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *QuoteUsecase) GetQuote(ctx context.Context, id int64) (*entity.Quote, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *entity.Quote
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.Quote); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Quote)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *QuoteUsecase) ExecuteQuote(ctx context.Context, id int64) (*entity.Quote, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *entity.Quote
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.Quote); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Quote)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
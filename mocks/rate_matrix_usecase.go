@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	context "context"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type RateMatrixUsecase struct {
+	mock.Mock
+}
+
+func (_m *RateMatrixUsecase) GetRateMatrix(ctx context.Context, p *request.RateMatrixParameter) (*entity.RateMatrix, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 *entity.RateMatrix
+	if rf, ok := ret.Get(0).(func(context.Context, *request.RateMatrixParameter) *entity.RateMatrix); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RateMatrix)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *request.RateMatrixParameter) error); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,210 @@
+package mocks
+
+import (
+	context "context"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type WebhookRepo struct {
+	mock.Mock
+}
+
+func (_m *WebhookRepo) CreateSubscription(ctx context.Context, ws *entity.WebhookSubscription) error {
+	ret := _m.Called(ctx, ws)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.WebhookSubscription) error); ok {
+		r0 = rf(ctx, ws)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *WebhookRepo) GetSubscription(ctx context.Context, id int64) (*entity.WebhookSubscription, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *entity.WebhookSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.WebhookSubscription); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.WebhookSubscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *WebhookRepo) GetSubscriptions(ctx context.Context, p *request.WebhookParameter) ([]entity.WebhookSubscription, int64, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 []entity.WebhookSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, *request.WebhookParameter) []entity.WebhookSubscription); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookSubscription)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, *request.WebhookParameter) int64); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *request.WebhookParameter) error); ok {
+		r2 = rf(ctx, p)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+func (_m *WebhookRepo) DeleteSubscription(ctx context.Context, id int64, now time.Time) error {
+	ret := _m.Called(ctx, id, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) error); ok {
+		r0 = rf(ctx, id, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *WebhookRepo) MatchSubscriptions(ctx context.Context, tenantID string, currencyIDFrom int64, currencyIDTo int64) ([]entity.WebhookSubscription, error) {
+	ret := _m.Called(ctx, tenantID, currencyIDFrom, currencyIDTo)
+
+	var r0 []entity.WebhookSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int64) []entity.WebhookSubscription); ok {
+		r0 = rf(ctx, tenantID, currencyIDFrom, currencyIDTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookSubscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, int64) error); ok {
+		r1 = rf(ctx, tenantID, currencyIDFrom, currencyIDTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *WebhookRepo) CreateDeliveries(ctx context.Context, deliveries []entity.WebhookDelivery) error {
+	ret := _m.Called(ctx, deliveries)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []entity.WebhookDelivery) error); ok {
+		r0 = rf(ctx, deliveries)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *WebhookRepo) ClaimDeliveries(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]entity.WebhookDelivery, error) {
+	ret := _m.Called(ctx, owner, now, lease, limit)
+
+	var r0 []entity.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Duration, int) []entity.WebhookDelivery); ok {
+		r0 = rf(ctx, owner, now, lease, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Duration, int) error); ok {
+		r1 = rf(ctx, owner, now, lease, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *WebhookRepo) UpdateDelivery(ctx context.Context, wd *entity.WebhookDelivery) error {
+	ret := _m.Called(ctx, wd)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.WebhookDelivery) error); ok {
+		r0 = rf(ctx, wd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *WebhookRepo) GetDeliveries(ctx context.Context, p *request.WebhookDeliveryParameter) ([]entity.WebhookDelivery, int64, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 []entity.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, *request.WebhookDeliveryParameter) []entity.WebhookDelivery); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookDelivery)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, *request.WebhookDeliveryParameter) int64); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *request.WebhookDeliveryParameter) error); ok {
+		r2 = rf(ctx, p)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+func (_m *WebhookRepo) PurgeDeliveries(ctx context.Context, before time.Time, limit int) (int64, error) {
+	ret := _m.Called(ctx, before, limit)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int64); ok {
+		r0 = rf(ctx, before, limit)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,132 @@
+package mocks
+
+import (
+	context "context"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type WebhookUsecase struct {
+	mock.Mock
+}
+
+func (_m *WebhookUsecase) CreateSubscription(ctx context.Context, ws *entity.WebhookSubscription) error {
+	ret := _m.Called(ctx, ws)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.WebhookSubscription) error); ok {
+		r0 = rf(ctx, ws)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *WebhookUsecase) GetSubscription(ctx context.Context, id int64) (*entity.WebhookSubscription, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *entity.WebhookSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.WebhookSubscription); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.WebhookSubscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *WebhookUsecase) GetSubscriptions(ctx context.Context, p *request.WebhookParameter) ([]entity.WebhookSubscription, int64, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 []entity.WebhookSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, *request.WebhookParameter) []entity.WebhookSubscription); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookSubscription)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, *request.WebhookParameter) int64); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *request.WebhookParameter) error); ok {
+		r2 = rf(ctx, p)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+func (_m *WebhookUsecase) DeleteSubscription(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *WebhookUsecase) GetDeliveries(ctx context.Context, p *request.WebhookDeliveryParameter) ([]entity.WebhookDelivery, int64, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 []entity.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, *request.WebhookDeliveryParameter) []entity.WebhookDelivery); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookDelivery)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(context.Context, *request.WebhookDeliveryParameter) int64); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *request.WebhookDeliveryParameter) error); ok {
+		r2 = rf(ctx, p)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+func (_m *WebhookUsecase) Publish(ctx context.Context, events []entity.DomainEvent) error {
+	ret := _m.Called(ctx, events)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []entity.DomainEvent) error); ok {
+		r0 = rf(ctx, events)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
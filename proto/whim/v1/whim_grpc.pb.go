@@ -0,0 +1,636 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: whim/v1/whim.proto
+
+// The whim API over gRPC. It serves the same currencies, conversions and conversions of
+// amounts as the REST API, see README.md for how to regenerate the Go code.
+
+package whimv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CurrencyService_CreateCurrency_FullMethodName = "/whim.v1.CurrencyService/CreateCurrency"
+	CurrencyService_UpdateCurrency_FullMethodName = "/whim.v1.CurrencyService/UpdateCurrency"
+	CurrencyService_GetCurrency_FullMethodName    = "/whim.v1.CurrencyService/GetCurrency"
+	CurrencyService_ListCurrencies_FullMethodName = "/whim.v1.CurrencyService/ListCurrencies"
+)
+
+// CurrencyServiceClient is the client API for CurrencyService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CurrencyServiceClient interface {
+	CreateCurrency(ctx context.Context, in *CreateCurrencyRequest, opts ...grpc.CallOption) (*Currency, error)
+	// UpdateCurrency fails with FAILED_PRECONDITION when version is set and the currency has been modified since
+	UpdateCurrency(ctx context.Context, in *UpdateCurrencyRequest, opts ...grpc.CallOption) (*Currency, error)
+	GetCurrency(ctx context.Context, in *GetCurrencyRequest, opts ...grpc.CallOption) (*Currency, error)
+	ListCurrencies(ctx context.Context, in *ListCurrenciesRequest, opts ...grpc.CallOption) (*ListCurrenciesResponse, error)
+}
+
+type currencyServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCurrencyServiceClient(cc grpc.ClientConnInterface) CurrencyServiceClient {
+	return &currencyServiceClient{cc}
+}
+
+func (c *currencyServiceClient) CreateCurrency(ctx context.Context, in *CreateCurrencyRequest, opts ...grpc.CallOption) (*Currency, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Currency)
+	err := c.cc.Invoke(ctx, CurrencyService_CreateCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *currencyServiceClient) UpdateCurrency(ctx context.Context, in *UpdateCurrencyRequest, opts ...grpc.CallOption) (*Currency, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Currency)
+	err := c.cc.Invoke(ctx, CurrencyService_UpdateCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *currencyServiceClient) GetCurrency(ctx context.Context, in *GetCurrencyRequest, opts ...grpc.CallOption) (*Currency, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Currency)
+	err := c.cc.Invoke(ctx, CurrencyService_GetCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *currencyServiceClient) ListCurrencies(ctx context.Context, in *ListCurrenciesRequest, opts ...grpc.CallOption) (*ListCurrenciesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCurrenciesResponse)
+	err := c.cc.Invoke(ctx, CurrencyService_ListCurrencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CurrencyServiceServer is the server API for CurrencyService service.
+// All implementations must embed UnimplementedCurrencyServiceServer
+// for forward compatibility.
+type CurrencyServiceServer interface {
+	CreateCurrency(context.Context, *CreateCurrencyRequest) (*Currency, error)
+	// UpdateCurrency fails with FAILED_PRECONDITION when version is set and the currency has been modified since
+	UpdateCurrency(context.Context, *UpdateCurrencyRequest) (*Currency, error)
+	GetCurrency(context.Context, *GetCurrencyRequest) (*Currency, error)
+	ListCurrencies(context.Context, *ListCurrenciesRequest) (*ListCurrenciesResponse, error)
+	mustEmbedUnimplementedCurrencyServiceServer()
+}
+
+// UnimplementedCurrencyServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCurrencyServiceServer struct{}
+
+func (UnimplementedCurrencyServiceServer) CreateCurrency(context.Context, *CreateCurrencyRequest) (*Currency, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCurrency not implemented")
+}
+func (UnimplementedCurrencyServiceServer) UpdateCurrency(context.Context, *UpdateCurrencyRequest) (*Currency, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCurrency not implemented")
+}
+func (UnimplementedCurrencyServiceServer) GetCurrency(context.Context, *GetCurrencyRequest) (*Currency, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCurrency not implemented")
+}
+func (UnimplementedCurrencyServiceServer) ListCurrencies(context.Context, *ListCurrenciesRequest) (*ListCurrenciesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCurrencies not implemented")
+}
+func (UnimplementedCurrencyServiceServer) mustEmbedUnimplementedCurrencyServiceServer() {}
+func (UnimplementedCurrencyServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeCurrencyServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CurrencyServiceServer will
+// result in compilation errors.
+type UnsafeCurrencyServiceServer interface {
+	mustEmbedUnimplementedCurrencyServiceServer()
+}
+
+func RegisterCurrencyServiceServer(s grpc.ServiceRegistrar, srv CurrencyServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCurrencyServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CurrencyService_ServiceDesc, srv)
+}
+
+func _CurrencyService_CreateCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCurrencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CurrencyServiceServer).CreateCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CurrencyService_CreateCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CurrencyServiceServer).CreateCurrency(ctx, req.(*CreateCurrencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CurrencyService_UpdateCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCurrencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CurrencyServiceServer).UpdateCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CurrencyService_UpdateCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CurrencyServiceServer).UpdateCurrency(ctx, req.(*UpdateCurrencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CurrencyService_GetCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCurrencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CurrencyServiceServer).GetCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CurrencyService_GetCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CurrencyServiceServer).GetCurrency(ctx, req.(*GetCurrencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CurrencyService_ListCurrencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCurrenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CurrencyServiceServer).ListCurrencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CurrencyService_ListCurrencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CurrencyServiceServer).ListCurrencies(ctx, req.(*ListCurrenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CurrencyService_ServiceDesc is the grpc.ServiceDesc for CurrencyService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CurrencyService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "whim.v1.CurrencyService",
+	HandlerType: (*CurrencyServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateCurrency",
+			Handler:    _CurrencyService_CreateCurrency_Handler,
+		},
+		{
+			MethodName: "UpdateCurrency",
+			Handler:    _CurrencyService_UpdateCurrency_Handler,
+		},
+		{
+			MethodName: "GetCurrency",
+			Handler:    _CurrencyService_GetCurrency_Handler,
+		},
+		{
+			MethodName: "ListCurrencies",
+			Handler:    _CurrencyService_ListCurrencies_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "whim/v1/whim.proto",
+}
+
+const (
+	ConversionService_CreateConversion_FullMethodName = "/whim.v1.ConversionService/CreateConversion"
+	ConversionService_UpdateConversion_FullMethodName = "/whim.v1.ConversionService/UpdateConversion"
+	ConversionService_GetConversion_FullMethodName    = "/whim.v1.ConversionService/GetConversion"
+	ConversionService_ListConversions_FullMethodName  = "/whim.v1.ConversionService/ListConversions"
+)
+
+// ConversionServiceClient is the client API for ConversionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConversionServiceClient interface {
+	CreateConversion(ctx context.Context, in *CreateConversionRequest, opts ...grpc.CallOption) (*Conversion, error)
+	// UpdateConversion fails with FAILED_PRECONDITION when version is set and the conversion has been modified since
+	UpdateConversion(ctx context.Context, in *UpdateConversionRequest, opts ...grpc.CallOption) (*Conversion, error)
+	GetConversion(ctx context.Context, in *GetConversionRequest, opts ...grpc.CallOption) (*Conversion, error)
+	ListConversions(ctx context.Context, in *ListConversionsRequest, opts ...grpc.CallOption) (*ListConversionsResponse, error)
+}
+
+type conversionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConversionServiceClient(cc grpc.ClientConnInterface) ConversionServiceClient {
+	return &conversionServiceClient{cc}
+}
+
+func (c *conversionServiceClient) CreateConversion(ctx context.Context, in *CreateConversionRequest, opts ...grpc.CallOption) (*Conversion, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Conversion)
+	err := c.cc.Invoke(ctx, ConversionService_CreateConversion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conversionServiceClient) UpdateConversion(ctx context.Context, in *UpdateConversionRequest, opts ...grpc.CallOption) (*Conversion, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Conversion)
+	err := c.cc.Invoke(ctx, ConversionService_UpdateConversion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conversionServiceClient) GetConversion(ctx context.Context, in *GetConversionRequest, opts ...grpc.CallOption) (*Conversion, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Conversion)
+	err := c.cc.Invoke(ctx, ConversionService_GetConversion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conversionServiceClient) ListConversions(ctx context.Context, in *ListConversionsRequest, opts ...grpc.CallOption) (*ListConversionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListConversionsResponse)
+	err := c.cc.Invoke(ctx, ConversionService_ListConversions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConversionServiceServer is the server API for ConversionService service.
+// All implementations must embed UnimplementedConversionServiceServer
+// for forward compatibility.
+type ConversionServiceServer interface {
+	CreateConversion(context.Context, *CreateConversionRequest) (*Conversion, error)
+	// UpdateConversion fails with FAILED_PRECONDITION when version is set and the conversion has been modified since
+	UpdateConversion(context.Context, *UpdateConversionRequest) (*Conversion, error)
+	GetConversion(context.Context, *GetConversionRequest) (*Conversion, error)
+	ListConversions(context.Context, *ListConversionsRequest) (*ListConversionsResponse, error)
+	mustEmbedUnimplementedConversionServiceServer()
+}
+
+// UnimplementedConversionServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConversionServiceServer struct{}
+
+func (UnimplementedConversionServiceServer) CreateConversion(context.Context, *CreateConversionRequest) (*Conversion, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateConversion not implemented")
+}
+func (UnimplementedConversionServiceServer) UpdateConversion(context.Context, *UpdateConversionRequest) (*Conversion, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateConversion not implemented")
+}
+func (UnimplementedConversionServiceServer) GetConversion(context.Context, *GetConversionRequest) (*Conversion, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConversion not implemented")
+}
+func (UnimplementedConversionServiceServer) ListConversions(context.Context, *ListConversionsRequest) (*ListConversionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListConversions not implemented")
+}
+func (UnimplementedConversionServiceServer) mustEmbedUnimplementedConversionServiceServer() {}
+func (UnimplementedConversionServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeConversionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConversionServiceServer will
+// result in compilation errors.
+type UnsafeConversionServiceServer interface {
+	mustEmbedUnimplementedConversionServiceServer()
+}
+
+func RegisterConversionServiceServer(s grpc.ServiceRegistrar, srv ConversionServiceServer) {
+	// If the following call pancis, it indicates UnimplementedConversionServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConversionService_ServiceDesc, srv)
+}
+
+func _ConversionService_CreateConversion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateConversionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionServiceServer).CreateConversion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionService_CreateConversion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionServiceServer).CreateConversion(ctx, req.(*CreateConversionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConversionService_UpdateConversion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateConversionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionServiceServer).UpdateConversion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionService_UpdateConversion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionServiceServer).UpdateConversion(ctx, req.(*UpdateConversionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConversionService_GetConversion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConversionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionServiceServer).GetConversion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionService_GetConversion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionServiceServer).GetConversion(ctx, req.(*GetConversionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConversionService_ListConversions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListConversionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionServiceServer).ListConversions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionService_ListConversions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionServiceServer).ListConversions(ctx, req.(*ListConversionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ConversionService_ServiceDesc is the grpc.ServiceDesc for ConversionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConversionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "whim.v1.ConversionService",
+	HandlerType: (*ConversionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateConversion",
+			Handler:    _ConversionService_CreateConversion_Handler,
+		},
+		{
+			MethodName: "UpdateConversion",
+			Handler:    _ConversionService_UpdateConversion_Handler,
+		},
+		{
+			MethodName: "GetConversion",
+			Handler:    _ConversionService_GetConversion_Handler,
+		},
+		{
+			MethodName: "ListConversions",
+			Handler:    _ConversionService_ListConversions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "whim/v1/whim.proto",
+}
+
+const (
+	ConvertService_Convert_FullMethodName               = "/whim.v1.ConvertService/Convert"
+	ConvertService_GetConvertCurrencies_FullMethodName  = "/whim.v1.ConvertService/GetConvertCurrencies"
+	ConvertService_ListConvertCurrencies_FullMethodName = "/whim.v1.ConvertService/ListConvertCurrencies"
+)
+
+// ConvertServiceClient is the client API for ConvertService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConvertServiceClient interface {
+	Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConvertCurrencies, error)
+	GetConvertCurrencies(ctx context.Context, in *GetConvertCurrenciesRequest, opts ...grpc.CallOption) (*ConvertCurrencies, error)
+	ListConvertCurrencies(ctx context.Context, in *ListConvertCurrenciesRequest, opts ...grpc.CallOption) (*ListConvertCurrenciesResponse, error)
+}
+
+type convertServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConvertServiceClient(cc grpc.ClientConnInterface) ConvertServiceClient {
+	return &convertServiceClient{cc}
+}
+
+func (c *convertServiceClient) Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConvertCurrencies, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertCurrencies)
+	err := c.cc.Invoke(ctx, ConvertService_Convert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *convertServiceClient) GetConvertCurrencies(ctx context.Context, in *GetConvertCurrenciesRequest, opts ...grpc.CallOption) (*ConvertCurrencies, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertCurrencies)
+	err := c.cc.Invoke(ctx, ConvertService_GetConvertCurrencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *convertServiceClient) ListConvertCurrencies(ctx context.Context, in *ListConvertCurrenciesRequest, opts ...grpc.CallOption) (*ListConvertCurrenciesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListConvertCurrenciesResponse)
+	err := c.cc.Invoke(ctx, ConvertService_ListConvertCurrencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConvertServiceServer is the server API for ConvertService service.
+// All implementations must embed UnimplementedConvertServiceServer
+// for forward compatibility.
+type ConvertServiceServer interface {
+	Convert(context.Context, *ConvertRequest) (*ConvertCurrencies, error)
+	GetConvertCurrencies(context.Context, *GetConvertCurrenciesRequest) (*ConvertCurrencies, error)
+	ListConvertCurrencies(context.Context, *ListConvertCurrenciesRequest) (*ListConvertCurrenciesResponse, error)
+	mustEmbedUnimplementedConvertServiceServer()
+}
+
+// UnimplementedConvertServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConvertServiceServer struct{}
+
+func (UnimplementedConvertServiceServer) Convert(context.Context, *ConvertRequest) (*ConvertCurrencies, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Convert not implemented")
+}
+func (UnimplementedConvertServiceServer) GetConvertCurrencies(context.Context, *GetConvertCurrenciesRequest) (*ConvertCurrencies, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConvertCurrencies not implemented")
+}
+func (UnimplementedConvertServiceServer) ListConvertCurrencies(context.Context, *ListConvertCurrenciesRequest) (*ListConvertCurrenciesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListConvertCurrencies not implemented")
+}
+func (UnimplementedConvertServiceServer) mustEmbedUnimplementedConvertServiceServer() {}
+func (UnimplementedConvertServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeConvertServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConvertServiceServer will
+// result in compilation errors.
+type UnsafeConvertServiceServer interface {
+	mustEmbedUnimplementedConvertServiceServer()
+}
+
+func RegisterConvertServiceServer(s grpc.ServiceRegistrar, srv ConvertServiceServer) {
+	// If the following call pancis, it indicates UnimplementedConvertServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConvertService_ServiceDesc, srv)
+}
+
+func _ConvertService_Convert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConvertServiceServer).Convert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConvertService_Convert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConvertServiceServer).Convert(ctx, req.(*ConvertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConvertService_GetConvertCurrencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConvertCurrenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConvertServiceServer).GetConvertCurrencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConvertService_GetConvertCurrencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConvertServiceServer).GetConvertCurrencies(ctx, req.(*GetConvertCurrenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConvertService_ListConvertCurrencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListConvertCurrenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConvertServiceServer).ListConvertCurrencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConvertService_ListConvertCurrencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConvertServiceServer).ListConvertCurrencies(ctx, req.(*ListConvertCurrenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ConvertService_ServiceDesc is the grpc.ServiceDesc for ConvertService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConvertService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "whim.v1.ConvertService",
+	HandlerType: (*ConvertServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Convert",
+			Handler:    _ConvertService_Convert_Handler,
+		},
+		{
+			MethodName: "GetConvertCurrencies",
+			Handler:    _ConvertService_GetConvertCurrencies_Handler,
+		},
+		{
+			MethodName: "ListConvertCurrencies",
+			Handler:    _ConvertService_ListConvertCurrencies_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "whim/v1/whim.proto",
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/cache"
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+type cachedConversion struct {
+	next  ConversionRepo
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// cachedConversions is a page of the conversions of a currency pair, as kept in the cache
+type cachedConversions struct {
+	Conversions []entity.Conversion `json:"conversions"`
+	Total       int64               `json:"total"`
+}
+
+//NewCachedConversion wraps a Conversion repository to keep the conversions of a currency pair in c for ttl.
+//The pages of a pair, of every tenant, are dropped when one of its conversions is created, updated or deleted.
+func NewCachedConversion(next ConversionRepo, c cache.Cache, ttl time.Duration) ConversionRepo {
+	return &cachedConversion{next: next, cache: c, ttl: ttl}
+}
+
+// pairKey names a currency pair the same way in both directions, as its conversions are
+// looked up both ways
+func pairKey(a, b int64) string {
+	if a > b {
+		a, b = b, a
+	}
+	return fmt.Sprintf("%d-%d", a, b)
+}
+
+func generationKey(pair string) string {
+	return "conversions:generation:" + pair
+}
+
+// generation returns the generation of the cached pages of pair, a page is only read from the
+// cache when it was stored under the current generation
+func (t *cachedConversion) generation(ctx context.Context, pair string) (string, error) {
+	gen, ok, err := t.cache.Get(ctx, generationKey(pair))
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(gen), nil
+	}
+
+	// a generation evicted from the cache is not started again, or stale pages would be read
+	return t.newGeneration(ctx, pair)
+}
+
+// newGeneration drops the cached pages of pair
+func (t *cachedConversion) newGeneration(ctx context.Context, pair string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	gen := hex.EncodeToString(b)
+	return gen, t.cache.Set(ctx, generationKey(pair), []byte(gen), 0)
+}
+
+// invalidate drops the cached pages of the pair of a and b once the change is committed
+func (t *cachedConversion) invalidate(ctx context.Context, a, b int64) {
+	afterCommit(ctx, func() {
+		ctx := context.WithoutCancel(ctx)
+		if _, err := t.newGeneration(ctx, pairKey(a, b)); err != nil {
+			slog.ErrorContext(ctx, "invalidating cached conversions", slog.String("error", err.Error()))
+		}
+	})
+}
+
+// GetConversions reads the conversions of a currency pair from the cache, and stores them on
+// a miss. The other lookups and those of a transaction, which must see its changes, are not cached.
+func (t *cachedConversion) GetConversions(ctx context.Context, p *request.ConversionParameter) ([]entity.Conversion, int64, error) {
+	if p.CurrencyIDFrom == 0 || p.CurrencyIDTo == 0 || inTransaction(ctx) {
+		return t.next.GetConversions(ctx, p)
+	}
+
+	pair := pairKey(p.CurrencyIDFrom, p.CurrencyIDTo)
+	gen, err := t.generation(ctx, pair)
+	if err != nil {
+		slog.WarnContext(ctx, "reading cached conversions", slog.String("error", err.Error()))
+		return t.next.GetConversions(ctx, p)
+	}
+
+	key := fmt.Sprintf("conversions:%s:%s:%s:%d:%d", pair, gen, strconv.Quote(request.TenantID(ctx)), p.Offset, p.Limit)
+	if b, ok, err := t.cache.Get(ctx, key); err == nil && ok {
+		var page cachedConversions
+		if json.Unmarshal(b, &page) == nil {
+			metrics.CacheRequests.WithLabelValues("conversions", "hit").Inc()
+			return page.Conversions, page.Total, nil
+		}
+	} else if err != nil {
+		slog.WarnContext(ctx, "reading cached conversions", slog.String("error", err.Error()))
+	}
+	metrics.CacheRequests.WithLabelValues("conversions", "miss").Inc()
+
+	// a replica lagging behind a change would have its rates cached until they expire
+	conversions, total, err := t.next.GetConversions(request.WithReadPrimary(ctx), p)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	b, err := json.Marshal(cachedConversions{conversions, total})
+	if err == nil {
+		err = t.cache.Set(ctx, key, b, t.ttl)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "caching conversions", slog.String("error", err.Error()))
+	}
+
+	return conversions, total, nil
+}
+
+func (t *cachedConversion) GetConversion(ctx context.Context, id int64) (*entity.Conversion, error) {
+	return t.next.GetConversion(ctx, id)
+}
+
+func (t *cachedConversion) CreateConversion(ctx context.Context, ec *entity.Conversion) error {
+	if err := t.next.CreateConversion(ctx, ec); err != nil {
+		return err
+	}
+
+	t.invalidate(ctx, ec.CurrencyIDFrom, ec.CurrencyIDTo)
+	return nil
+}
+
+func (t *cachedConversion) UpdateConversion(ctx context.Context, id int64, ec *entity.Conversion) error {
+	// the pair is looked up first, an update only carries the rate
+	current, err := t.next.GetConversion(ctx, id)
+	if err != nil {
+		return t.next.UpdateConversion(ctx, id, ec)
+	}
+
+	if err := t.next.UpdateConversion(ctx, id, ec); err != nil {
+		return err
+	}
+
+	t.invalidate(ctx, current.CurrencyIDFrom, current.CurrencyIDTo)
+	return nil
+}
+
+func (t *cachedConversion) DeleteConversion(ctx context.Context, id int64) error {
+	current, err := t.next.GetConversion(ctx, id)
+	if err != nil {
+		return t.next.DeleteConversion(ctx, id)
+	}
+
+	if err := t.next.DeleteConversion(ctx, id); err != nil {
+		return err
+	}
+
+	t.invalidate(ctx, current.CurrencyIDFrom, current.CurrencyIDTo)
+	return nil
+}
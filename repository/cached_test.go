@@ -0,0 +1,147 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/rbpermadi/whim_assignment/app/cache"
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+func pairParameter(from, to int64) *request.ConversionParameter {
+	return &request.ConversionParameter{Limit: 10, CurrencyIDFrom: from, CurrencyIDTo: to}
+}
+
+func TestCachedConversionHit(t *testing.T) {
+	next := new(mocks.ConversionRepo)
+	next.On("GetConversions", mock.Anything, mock.Anything).Return([]entity.Conversion{{ID: 3, CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5}}, int64(1), nil)
+
+	repo := repository.NewCachedConversion(next, cache.NewLRU(100), time.Minute)
+	hits := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("conversions", "hit"))
+	misses := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("conversions", "miss"))
+
+	acme := request.WithTenantID(context.TODO(), "acme")
+	for _, p := range []*request.ConversionParameter{pairParameter(1, 2), pairParameter(2, 1)} {
+		conversions, total, err := repo.GetConversions(acme, p)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, 1.5, conversions[0].Rate)
+	}
+	next.AssertNumberOfCalls(t, "GetConversions", 1)
+
+	_, _, err := repo.GetConversions(request.WithTenantID(context.TODO(), "other"), pairParameter(1, 2))
+	assert.NoError(t, err)
+	next.AssertNumberOfCalls(t, "GetConversions", 2)
+
+	_, _, err = repo.GetConversions(acme, &request.ConversionParameter{Limit: 10})
+	assert.NoError(t, err)
+	next.AssertNumberOfCalls(t, "GetConversions", 3)
+
+	assert.Equal(t, hits+1, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("conversions", "hit")))
+	assert.Equal(t, misses+2, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("conversions", "miss")),
+		"the conversions of another tenant are looked up, and listing every conversion is not cached")
+}
+
+func TestCachedConversionInvalidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(repo repository.ConversionRepo) error
+	}{
+		{name: "create", write: func(repo repository.ConversionRepo) error {
+			return repo.CreateConversion(context.TODO(), &entity.Conversion{CurrencyIDFrom: 2, CurrencyIDTo: 1, Rate: 0.5})
+		}},
+		{name: "update", write: func(repo repository.ConversionRepo) error {
+			return repo.UpdateConversion(context.TODO(), 3, &entity.Conversion{Rate: 1.6})
+		}},
+		{name: "delete", write: func(repo repository.ConversionRepo) error {
+			return repo.DeleteConversion(context.TODO(), 3)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := new(mocks.ConversionRepo)
+			next.On("GetConversions", mock.Anything, mock.Anything).Return([]entity.Conversion{}, int64(0), nil)
+			next.On("GetConversion", mock.Anything, int64(3)).Return(&entity.Conversion{ID: 3, CurrencyIDFrom: 1, CurrencyIDTo: 2}, nil)
+			next.On("CreateConversion", mock.Anything, mock.Anything).Return(nil)
+			next.On("UpdateConversion", mock.Anything, int64(3), mock.Anything).Return(nil)
+			next.On("DeleteConversion", mock.Anything, int64(3)).Return(nil)
+
+			repo := repository.NewCachedConversion(next, cache.NewLRU(100), time.Minute)
+			for _, p := range []*request.ConversionParameter{pairParameter(1, 2), pairParameter(1, 3)} {
+				_, _, err := repo.GetConversions(context.TODO(), p)
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, tt.write(repo))
+
+			for _, p := range []*request.ConversionParameter{pairParameter(1, 2), pairParameter(1, 3)} {
+				_, _, err := repo.GetConversions(context.TODO(), p)
+				assert.NoError(t, err)
+			}
+			next.AssertNumberOfCalls(t, "GetConversions", 3)
+			next.AssertCalled(t, "GetConversions", mock.Anything, pairParameter(1, 2))
+		})
+	}
+}
+
+func TestCachedConversionTransaction(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	next := new(mocks.ConversionRepo)
+	next.On("GetConversions", mock.Anything, mock.Anything).Return([]entity.Conversion{}, int64(0), nil)
+	next.On("CreateConversion", mock.Anything, mock.Anything).Return(nil)
+
+	repo := repository.NewCachedConversion(next, cache.NewLRU(100), time.Minute)
+	tx := repository.NewMysqlTransactor(db)
+	_, _, err = repo.GetConversions(context.TODO(), pairParameter(1, 2))
+	assert.NoError(t, err)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectRollback()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+
+	err = tx.WithinTx(context.TODO(), func(ctx context.Context) error {
+		assert.NoError(t, repo.CreateConversion(ctx, &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2}))
+		return assert.AnError
+	})
+	assert.Equal(t, assert.AnError, err)
+	_, _, err = repo.GetConversions(context.TODO(), pairParameter(1, 2))
+	assert.NoError(t, err)
+	// a rolled back change does not drop the cached conversions
+	next.AssertNumberOfCalls(t, "GetConversions", 1)
+
+	err = tx.WithinTx(context.TODO(), func(ctx context.Context) error {
+		_, _, err := repo.GetConversions(ctx, pairParameter(1, 2))
+		assert.NoError(t, err)
+		// the lookups of a transaction are not cached
+		next.AssertNumberOfCalls(t, "GetConversions", 2)
+
+		assert.NoError(t, repo.CreateConversion(ctx, &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2}))
+		_, _, err = repo.GetConversions(context.TODO(), pairParameter(1, 2))
+		assert.NoError(t, err)
+		// the cached conversions are kept until the commit
+		next.AssertNumberOfCalls(t, "GetConversions", 2)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	_, _, err = repo.GetConversions(context.TODO(), pairParameter(1, 2))
+	assert.NoError(t, err)
+	next.AssertNumberOfCalls(t, "GetConversions", 3)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,5 @@
+// Package repository keeps the entities in MySQL. Values supplied by API clients, like client
+// ids, idempotency keys, webhook URLs and payloads, are passed as query arguments, buildQuery
+// only formats the values the service controls. A few older queries still format names with
+// buildQuery, they are not to be copied.
+package repository
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+// observe records the duration of a repository method and whether it failed.
+// Not Found is an expected result, not a failure.
+func observe(repo, method string, start time.Time, err error) {
+	metrics.RepositoryQueryDuration.WithLabelValues(repo, method).Observe(time.Since(start).Seconds())
+	if err != nil && err.Error() != "Not Found" {
+		metrics.RepositoryErrors.WithLabelValues(repo, method).Inc()
+	}
+}
+
+type instrumentedCurrency struct {
+	next CurrencyRepo
+}
+
+//NewInstrumentedCurrency wraps a Currency repository to record the duration and errors of its methods
+func NewInstrumentedCurrency(next CurrencyRepo) CurrencyRepo {
+	return &instrumentedCurrency{next}
+}
+
+func (t *instrumentedCurrency) CreateCurrency(ctx context.Context, ec *entity.Currency) (err error) {
+	defer func(start time.Time) { observe("currency", "CreateCurrency", start, err) }(time.Now())
+	return t.next.CreateCurrency(ctx, ec)
+}
+
+func (t *instrumentedCurrency) UpdateCurrency(ctx context.Context, id int64, ec *entity.Currency) (err error) {
+	defer func(start time.Time) { observe("currency", "UpdateCurrency", start, err) }(time.Now())
+	return t.next.UpdateCurrency(ctx, id, ec)
+}
+
+func (t *instrumentedCurrency) DeleteCurrency(ctx context.Context, id int64) (err error) {
+	defer func(start time.Time) { observe("currency", "DeleteCurrency", start, err) }(time.Now())
+	return t.next.DeleteCurrency(ctx, id)
+}
+
+func (t *instrumentedCurrency) GetCurrency(ctx context.Context, id int64) (_ *entity.Currency, err error) {
+	defer func(start time.Time) { observe("currency", "GetCurrency", start, err) }(time.Now())
+	return t.next.GetCurrency(ctx, id)
+}
+
+func (t *instrumentedCurrency) GetCurrencies(ctx context.Context, p *request.CurrencyParameter) (_ []entity.Currency, _ int64, err error) {
+	defer func(start time.Time) { observe("currency", "GetCurrencies", start, err) }(time.Now())
+	return t.next.GetCurrencies(ctx, p)
+}
+
+type instrumentedConversion struct {
+	next ConversionRepo
+}
+
+//NewInstrumentedConversion wraps a Conversion repository to record the duration and errors of its methods
+func NewInstrumentedConversion(next ConversionRepo) ConversionRepo {
+	return &instrumentedConversion{next}
+}
+
+func (t *instrumentedConversion) CreateConversion(ctx context.Context, ec *entity.Conversion) (err error) {
+	defer func(start time.Time) { observe("conversion", "CreateConversion", start, err) }(time.Now())
+	return t.next.CreateConversion(ctx, ec)
+}
+
+func (t *instrumentedConversion) UpdateConversion(ctx context.Context, id int64, ec *entity.Conversion) (err error) {
+	defer func(start time.Time) { observe("conversion", "UpdateConversion", start, err) }(time.Now())
+	return t.next.UpdateConversion(ctx, id, ec)
+}
+
+func (t *instrumentedConversion) DeleteConversion(ctx context.Context, id int64) (err error) {
+	defer func(start time.Time) { observe("conversion", "DeleteConversion", start, err) }(time.Now())
+	return t.next.DeleteConversion(ctx, id)
+}
+
+func (t *instrumentedConversion) GetConversion(ctx context.Context, id int64) (_ *entity.Conversion, err error) {
+	defer func(start time.Time) { observe("conversion", "GetConversion", start, err) }(time.Now())
+	return t.next.GetConversion(ctx, id)
+}
+
+func (t *instrumentedConversion) GetConversions(ctx context.Context, p *request.ConversionParameter) (_ []entity.Conversion, _ int64, err error) {
+	defer func(start time.Time) { observe("conversion", "GetConversions", start, err) }(time.Now())
+	return t.next.GetConversions(ctx, p)
+}
@@ -0,0 +1,54 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+func TestInstrumentedCurrency(t *testing.T) {
+	next := new(mocks.CurrencyRepo)
+	next.On("GetCurrency", mock.Anything, int64(1)).Return(&entity.Currency{ID: 1}, nil)
+	next.On("GetCurrency", mock.Anything, int64(2)).Return(nil, errors.New("Not Found"))
+	next.On("GetCurrencies", mock.Anything, mock.Anything).Return(nil, int64(0), errors.New("connection refused"))
+
+	repo := repository.NewInstrumentedCurrency(next)
+	errorsBefore := testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("currency", "GetCurrency"))
+	listErrorsBefore := testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("currency", "GetCurrencies"))
+
+	cry, err := repo.GetCurrency(context.TODO(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), cry.ID)
+
+	_, err = repo.GetCurrency(context.TODO(), 2)
+	assert.EqualError(t, err, "Not Found")
+
+	_, _, err = repo.GetCurrencies(context.TODO(), &request.CurrencyParameter{})
+	assert.Error(t, err)
+
+	assert.Equal(t, errorsBefore, testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("currency", "GetCurrency")))
+	assert.Equal(t, listErrorsBefore+1, testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("currency", "GetCurrencies")))
+	assert.NotZero(t, testutil.CollectAndCount(metrics.RepositoryQueryDuration, "whim_repository_query_duration_seconds"))
+}
+
+func TestInstrumentedConversion(t *testing.T) {
+	next := new(mocks.ConversionRepo)
+	next.On("UpdateConversion", mock.Anything, int64(1), mock.Anything).Return(errors.New("Precondition Failed: conversion has been modified"))
+
+	repo := repository.NewInstrumentedConversion(next)
+	before := testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("conversion", "UpdateConversion"))
+
+	err := repo.UpdateConversion(context.TODO(), 1, &entity.Conversion{Version: 1})
+	assert.Error(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("conversion", "UpdateConversion")))
+}
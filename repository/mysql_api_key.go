@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+type mysqlAPIKey struct {
+	db *tracedDB
+}
+
+type APIKeyRepo interface {
+	CreateAPIKey(ctx context.Context, ek *entity.APIKey) error
+	RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error
+	GetAPIKey(ctx context.Context, id int64) (*entity.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+	GetAPIKeys(ctx context.Context, p *request.APIKeyParameter) ([]entity.APIKey, int64, error)
+}
+
+//NewMysqlAPIKey is a function to create implementation of mysql APIKey repository
+func NewMysqlAPIKey(db *sql.DB) APIKeyRepo {
+	return &mysqlAPIKey{traced(db)}
+}
+
+func (t *mysqlAPIKey) fetch(ctx context.Context, query string, args ...interface{}) ([]entity.APIKey, error) {
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]entity.APIKey, 0)
+	for rows.Next() {
+		k := entity.APIKey{}
+		err = rows.Scan(
+			&k.ID,
+			&k.Name,
+			&k.ClientID,
+			&k.TenantID,
+			&k.Role,
+			&k.Prefix,
+			&k.KeyHash,
+			&k.RevokedAt,
+			&k.UpdatedAt,
+			&k.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, k)
+	}
+
+	return result, nil
+}
+
+func (t *mysqlAPIKey) GetAPIKey(ctx context.Context, id int64) (*entity.APIKey, error) {
+	query := `SELECT id, name, client_id, tenant_id, role, prefix, key_hash, revoked_at, updated_at, created_at
+						  FROM api_keys WHERE id = %d`
+
+	list, err := t.fetch(ctx, buildQuery(query, id))
+	if err == sql.ErrNoRows || len(list) == 0 {
+		return nil, fmt.Errorf("Not Found")
+	}
+
+	return &list[0], nil
+}
+
+func (t *mysqlAPIKey) GetAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	query := `SELECT id, name, client_id, tenant_id, role, prefix, key_hash, revoked_at, updated_at, created_at
+						  FROM api_keys WHERE key_hash = ?`
+
+	list, err := t.fetch(ctx, query, keyHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("Not Found")
+	}
+
+	return &list[0], nil
+}
+
+// managedBy matches the keys an admin of tenantID manages, those of its own tenant, or every
+// key for an admin of the global tenant that operates the service
+func managedBy(tenantID string) (string, []interface{}) {
+	if tenantID == "" {
+		return "1 = 1", nil
+	}
+	return "tenant_id = ?", []interface{}{tenantID}
+}
+
+// GetAPIKeys returns the keys managed by the tenant of ctx
+func (t *mysqlAPIKey) GetAPIKeys(ctx context.Context, p *request.APIKeyParameter) ([]entity.APIKey, int64, error) {
+	var total int64
+
+	where, args := managedBy(request.TenantID(ctx))
+	if !p.IncludeRevoked {
+		where += " AND revoked_at IS NULL"
+	}
+
+	err := t.db.QueryRowContext(ctx, buildQuery("SELECT COUNT(id) FROM api_keys WHERE %s", where), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT id, name, client_id, tenant_id, role, prefix, key_hash, revoked_at, updated_at, created_at
+						FROM api_keys WHERE %s ORDER BY id LIMIT %d, %d `
+
+	result, err := t.fetch(ctx, buildQuery(query, where, p.Offset, p.Limit), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, total, err
+}
+
+func (t *mysqlAPIKey) CreateAPIKey(ctx context.Context, key *entity.APIKey) error {
+	query := `INSERT INTO api_keys (name, client_id, tenant_id, role, prefix, key_hash, updated_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := t.db.ExecContext(ctx, query,
+		key.Name,
+		key.ClientID,
+		key.TenantID,
+		key.Role,
+		key.Prefix,
+		key.KeyHash,
+		sqlTime(key.UpdatedAt),
+		sqlTime(key.CreatedAt),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	lastID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	key.ID = lastID
+	return nil
+}
+
+// RevokeAPIKey revokes the key id when the tenant of ctx manages it, return Not Found otherwise
+func (t *mysqlAPIKey) RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error {
+	where, args := managedBy(request.TenantID(ctx))
+	query := buildQuery(`UPDATE api_keys set revoked_at=?, updated_at=? WHERE id = ? AND revoked_at IS NULL AND %s`, where)
+
+	res, err := t.db.ExecContext(ctx, query, append([]interface{}{sqlTime(revokedAt), sqlTime(revokedAt), id}, args...)...)
+	if err != nil {
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affect == 0 {
+		err = fmt.Errorf("Not Found")
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+var apiKeyColumns = []string{"id", "name", "client_id", "tenant_id", "role", "prefix", "key_hash", "revoked_at", "updated_at", "created_at"}
+
+func Test_mysqlAPIKey_GetAPIKeyByHash(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	sample := entity.APIKey{ID: 1, Name: "pricing", ClientID: "pricing", Role: "reader", Prefix: "whim_0123abcd", KeyHash: "abc", CreatedAt: now, UpdatedAt: now}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id(.+) WHERE key_hash = \?`).WithArgs("abc").WillReturnRows(sqlmock.NewRows(apiKeyColumns).
+		AddRow(sample.ID, sample.Name, sample.ClientID, sample.TenantID, sample.Role, sample.Prefix, sample.KeyHash, nil, sample.UpdatedAt, sample.CreatedAt))
+	mock.ExpectQuery(`^SELECT id(.+) WHERE key_hash = \?`).WithArgs("def").WillReturnRows(sqlmock.NewRows(apiKeyColumns))
+	mock.ExpectQuery(`^SELECT id(.+)`).WillReturnError(errors.New("connection refused"))
+
+	repo := repository.NewMysqlAPIKey(db)
+
+	got, err := repo.GetAPIKeyByHash(context.TODO(), "abc")
+	if err != nil {
+		t.Fatalf("mysqlAPIKey.GetAPIKeyByHash() error = %v", err)
+	}
+	if diff := cmp.Diff(&sample, got); diff != "" {
+		t.Errorf("mysqlAPIKey.GetAPIKeyByHash() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := repo.GetAPIKeyByHash(context.TODO(), "def"); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlAPIKey.GetAPIKeyByHash() error = %v, want Not Found", err)
+	}
+	if _, err := repo.GetAPIKeyByHash(context.TODO(), "ghi"); err == nil || err.Error() == "Not Found" {
+		t.Errorf("mysqlAPIKey.GetAPIKeyByHash() error = %v, want database error", err)
+	}
+}
+
+func Test_mysqlAPIKey_GetAPIKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		tenantID  string
+		params    request.APIKeyParameter
+		wantWhere string
+		wantArgs  []driver.Value
+	}{
+		{"active keys", "", request.APIKeyParameter{Limit: 10}, "1 = 1 AND revoked_at IS NULL", nil},
+		{"all keys", "", request.APIKeyParameter{Limit: 10, IncludeRevoked: true}, "1 = 1", nil},
+		{"keys of the tenant", "acme", request.APIKeyParameter{Limit: 10}, "tenant_id = ? AND revoked_at IS NULL", []driver.Value{"acme"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery("^SELECT COUNT(.+) WHERE " + regexp.QuoteMeta(tt.wantWhere)).WithArgs(tt.wantArgs...).WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+			mock.ExpectQuery("^SELECT id(.+) WHERE " + regexp.QuoteMeta(tt.wantWhere)).WithArgs(tt.wantArgs...).WillReturnRows(sqlmock.NewRows(apiKeyColumns))
+
+			repo := repository.NewMysqlAPIKey(db)
+			if _, _, err := repo.GetAPIKeys(request.WithTenantID(context.TODO(), tt.tenantID), &tt.params); err != nil {
+				t.Errorf("mysqlAPIKey.GetAPIKeys() error = %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_mysqlAPIKey_CreateAndRevoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`^INSERT INTO api_keys(.+) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?\)`).
+		WithArgs(`pricing "quoted"`, `o'brien\`, "", "reader", "", "abc", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE api_keys set revoked_at=?, updated_at=? WHERE id = ? AND revoked_at IS NULL AND 1 = 1")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE api_keys(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewMysqlAPIKey(db)
+	// the values are passed as query arguments, not quoted into the statement
+	key := entity.APIKey{Name: `pricing "quoted"`, ClientID: `o'brien\`, Role: "reader", KeyHash: "abc"}
+	if err := repo.CreateAPIKey(context.TODO(), &key); err != nil || key.ID != 5 {
+		t.Errorf("mysqlAPIKey.CreateAPIKey() error = %v, id = %v", err, key.ID)
+	}
+	if err := repo.RevokeAPIKey(context.TODO(), 5, time.Now()); err != nil {
+		t.Errorf("mysqlAPIKey.RevokeAPIKey() error = %v", err)
+	}
+	if err := repo.RevokeAPIKey(context.TODO(), 5, time.Now()); err == nil {
+		t.Errorf("mysqlAPIKey.RevokeAPIKey() expected not found error for revoked key")
+	}
+}
+
+func Test_mysqlAPIKey_RevokeAPIKeyOfAnotherTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// the key 5 belongs to another tenant, so no row of acme matches
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id = ? AND revoked_at IS NULL AND tenant_id = ?")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 5, "acme").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewMysqlAPIKey(db)
+	if err := repo.RevokeAPIKey(request.WithTenantID(context.TODO(), "acme"), 5, time.Now()); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlAPIKey.RevokeAPIKey() error = %v, want Not Found", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
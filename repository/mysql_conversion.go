@@ -4,13 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/entity"
 )
 
+// mysqlConversion scopes every query by the tenant of the caller. A tenant's rate for a
+// currency pair overrides the global rate of the pair, in either direction, and a tenant can
+// only change its own rates.
 type mysqlConversion struct {
-	db *sql.DB
+	db *tracedDB
 }
 
 type ConversionRepo interface {
@@ -22,11 +26,12 @@ type ConversionRepo interface {
 }
 
 //NewMysqlConversion is a function to create implementation of mysql Conversion repository
-func NewMysqlConversion(db *sql.DB) ConversionRepo {
-	return &mysqlConversion{db}
+func NewMysqlConversion(db *sql.DB, replicas ...*sql.DB) ConversionRepo {
+	return &mysqlConversion{traced(db, replicas...)}
 }
 
 func (t *mysqlConversion) fetch(ctx context.Context, query string) ([]entity.Conversion, error) {
+	logQuery(ctx, query)
 	rows, err := t.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -39,9 +44,11 @@ func (t *mysqlConversion) fetch(ctx context.Context, query string) ([]entity.Con
 		cat := entity.Conversion{}
 		err = rows.Scan(
 			&cat.ID,
+			&cat.TenantID,
 			&cat.CurrencyIDFrom,
 			&cat.CurrencyIDTo,
 			&cat.Rate,
+			&cat.Version,
 			&cat.UpdatedAt,
 			&cat.CreatedAt,
 		)
@@ -55,11 +62,24 @@ func (t *mysqlConversion) fetch(ctx context.Context, query string) ([]entity.Con
 	return result, nil
 }
 
+// effectiveRates matches the rates of tenantID and the global rates it does not override
+func effectiveRates(tenantID string) string {
+	if tenantID == "" {
+		return "tenant_id = ''"
+	}
+
+	query := `(tenant_id = %q OR (tenant_id = '' AND NOT EXISTS (
+								SELECT 1 FROM conversions o WHERE o.tenant_id = %q AND (
+									(o.currency_id_from = conversions.currency_id_from AND o.currency_id_to = conversions.currency_id_to) OR
+									(o.currency_id_from = conversions.currency_id_to AND o.currency_id_to = conversions.currency_id_from)))))`
+	return buildQuery(query, tenantID, tenantID)
+}
+
 func (t *mysqlConversion) GetConversion(ctx context.Context, id int64) (*entity.Conversion, error) {
-	query := `SELECT id, currency_id_from, currency_id_to, rate, updated_at, created_at
-						  FROM conversions WHERE id = %d`
+	query := `SELECT id, tenant_id, currency_id_from, currency_id_to, rate, version, updated_at, created_at
+						  FROM conversions WHERE id = %d AND %s`
 
-	list, err := t.fetch(ctx, buildQuery(query, id))
+	list, err := t.fetch(ctx, buildQuery(query, id, visibleToTenant(request.TenantID(ctx))))
 	if err == sql.ErrNoRows || len(list) == 0 {
 		return nil, fmt.Errorf("Not Found")
 	}
@@ -72,28 +92,30 @@ func (t *mysqlConversion) GetConversions(ctx context.Context, p *request.Convers
 	var total int64
 	var queryString string
 
+	scope := effectiveRates(request.TenantID(ctx))
 	if p.CurrencyIDFrom != 0 && p.CurrencyIDTo != 0 {
-		queryCount := "SELECT COUNT(id) FROM conversions WHERE (currency_id_from = %d AND currency_id_to = %d) OR (currency_id_from = %d AND currency_id_to = %d)"
-		err := t.db.QueryRowContext(ctx, buildQuery(queryCount, p.CurrencyIDFrom, p.CurrencyIDTo, p.CurrencyIDTo, p.CurrencyIDFrom)).Scan(&total)
+		queryCount := "SELECT COUNT(id) FROM conversions WHERE %s AND ((currency_id_from = %d AND currency_id_to = %d) OR (currency_id_from = %d AND currency_id_to = %d))"
+		err := t.db.QueryRowContext(ctx, buildQuery(queryCount, scope, p.CurrencyIDFrom, p.CurrencyIDTo, p.CurrencyIDTo, p.CurrencyIDFrom)).Scan(&total)
 		if err != nil {
 			return nil, 0, err
 		}
 
 		query := `SELECT
-								id, currency_id_from, currency_id_to, rate, updated_at, created_at
+								id, tenant_id, currency_id_from, currency_id_to, rate, version, updated_at, created_at
 							FROM
 								conversions
-							WHERE (currency_id_from = %d AND currency_id_to = %d) OR (currency_id_from = %d AND currency_id_to = %d)
+							WHERE %s AND ((currency_id_from = %d AND currency_id_to = %d) OR (currency_id_from = %d AND currency_id_to = %d))
+							ORDER BY id
 							LIMIT %d, %d `
-		queryString = buildQuery(query, p.CurrencyIDFrom, p.CurrencyIDTo, p.CurrencyIDTo, p.CurrencyIDFrom, p.Offset, p.Limit)
+		queryString = buildQuery(query, scope, p.CurrencyIDFrom, p.CurrencyIDTo, p.CurrencyIDTo, p.CurrencyIDFrom, p.Offset, p.Limit)
 	} else {
-		err := t.db.QueryRowContext(ctx, "SELECT COUNT(id) FROM conversions").Scan(&total)
+		err := t.db.QueryRowContext(ctx, buildQuery("SELECT COUNT(id) FROM conversions WHERE %s", scope)).Scan(&total)
 		if err != nil {
 			return nil, 0, err
 		}
 
-		query := `SELECT id, currency_id_from, currency_id_to, rate, updated_at, created_at FROM conversions LIMIT %d, %d `
-		queryString = buildQuery(query, p.Offset, p.Limit)
+		query := `SELECT id, tenant_id, currency_id_from, currency_id_to, rate, version, updated_at, created_at FROM conversions WHERE %s ORDER BY id LIMIT %d, %d `
+		queryString = buildQuery(query, scope, p.Offset, p.Limit)
 	}
 
 	result, err := t.fetch(ctx, queryString)
@@ -105,71 +127,114 @@ func (t *mysqlConversion) GetConversions(ctx context.Context, p *request.Convers
 }
 
 func (t *mysqlConversion) CreateConversion(ctx context.Context, conversion *entity.Conversion) error {
-	query := `INSERT INTO conversions (currency_id_from, currency_id_to, rate, updated_at, created_at) VALUES (%d, %d, %f, %q, %q)`
-	res, err := t.db.ExecContext(ctx,
-		buildQuery(query,
-			conversion.CurrencyIDFrom,
-			conversion.CurrencyIDTo,
-			conversion.Rate,
-			sqlTime(conversion.UpdatedAt),
-			sqlTime(conversion.CreatedAt)),
-	)
+	query := `INSERT INTO conversions (tenant_id, currency_id_from, currency_id_to, rate, updated_at, created_at) VALUES (%q, %d, %d, %f, %q, %q)`
+	conversion.TenantID = request.TenantID(ctx)
+	return t.db.inTx(ctx, func(ex executor) error {
+		res, err := ex.ExecContext(ctx,
+			buildQuery(query,
+				conversion.TenantID,
+				conversion.CurrencyIDFrom,
+				conversion.CurrencyIDTo,
+				conversion.Rate,
+				sqlTime(conversion.UpdatedAt),
+				sqlTime(conversion.CreatedAt)),
+		)
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return err
+		}
 
-	lastID, err := res.LastInsertId()
-	if err != nil {
-		return err
-	}
-	conversion.ID = lastID
-	return nil
+		lastID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		conversion.ID = lastID
+		conversion.Version = 1
+
+		change := rateChange{
+			ID:             lastID,
+			TenantID:       conversion.TenantID,
+			CurrencyIDFrom: conversion.CurrencyIDFrom,
+			CurrencyIDTo:   conversion.CurrencyIDTo,
+			Rate:           conversion.Rate,
+			UpdatedAt:      conversion.UpdatedAt,
+		}
+		return appendEvent(ctx, ex, entity.EventRateCreated, "conversion", lastID, conversion.TenantID, change)
+	})
 }
 
+// UpdateConversion updates the rate and bumps the version. When Conversion.Version is set,
+// the update only succeeds if it still matches the stored version.
 func (t *mysqlConversion) UpdateConversion(ctx context.Context, id int64, Conversion *entity.Conversion) error {
-	query := `UPDATE conversions set rate=%f, updated_at=%q WHERE ID = %d`
-
-	res, err := t.db.ExecContext(ctx, buildQuery(query, Conversion.Rate, sqlTime(Conversion.UpdatedAt), id))
-	if err != nil {
-		return err
-	}
-	affect, err := res.RowsAffected()
-	if err != nil {
-		return err
+	query := `UPDATE conversions set rate=%f, version=version+1, updated_at=%q WHERE ID = %d AND tenant_id = %q`
+	queryString := buildQuery(query, Conversion.Rate, sqlTime(Conversion.UpdatedAt), id, request.TenantID(ctx))
+	if Conversion.Version > 0 {
+		queryString += buildQuery(" AND version = %d", Conversion.Version)
 	}
 
-	if affect == 0 {
-		err = fmt.Errorf("Not Found")
+	err := t.db.inTx(ctx, func(ex executor) error {
+		res, err := ex.ExecContext(ctx, queryString)
+		if err != nil {
+			return err
+		}
+		affect, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
 
-		return err
-	}
+		if affect == 0 && Conversion.Version > 0 {
+			return versionMismatch(ctx, ex, "conversions", id, "conversion")
+		}
+
+		if affect == 0 {
+			err = fmt.Errorf("Not Found")
+
+			return err
+		}
 
-	if affect != 1 {
-		err = fmt.Errorf("weird  behaviour. total affected: %d", affect)
+		if affect != 1 {
+			err = fmt.Errorf("weird  behaviour. total affected: %d", affect)
 
+			return err
+		}
+
+		change := rateChange{
+			ID:             id,
+			TenantID:       request.TenantID(ctx),
+			CurrencyIDFrom: Conversion.CurrencyIDFrom,
+			CurrencyIDTo:   Conversion.CurrencyIDTo,
+			Rate:           Conversion.Rate,
+			UpdatedAt:      Conversion.UpdatedAt,
+		}
+		return appendEvent(ctx, ex, entity.EventRateUpdated, "conversion", id, change.TenantID, change)
+	})
+	if err != nil {
 		return err
 	}
 
+	Conversion.Version++
 	return nil
 }
 
 func (t *mysqlConversion) DeleteConversion(ctx context.Context, id int64) error {
-	query := "DELETE FROM conversions WHERE id = %d"
+	query := "DELETE FROM conversions WHERE id = %d AND tenant_id = %q"
 
-	res, err := t.db.ExecContext(ctx, buildQuery(query, id))
-	if err != nil {
+	return t.db.inTx(ctx, func(ex executor) error {
+		res, err := ex.ExecContext(ctx, buildQuery(query, id, request.TenantID(ctx)))
+		if err != nil {
 
-		return err
-	}
-	rowsAfected, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rowsAfected != 1 {
-		err = fmt.Errorf("weird behaviour. total affected: %d", rowsAfected)
-		return err
-	}
+			return err
+		}
+		rowsAfected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAfected != 1 {
+			err = fmt.Errorf("weird behaviour. total affected: %d", rowsAfected)
+			return err
+		}
 
-	return nil
+		change := rateChange{ID: id, TenantID: request.TenantID(ctx), UpdatedAt: time.Now().UTC()}
+		return appendEvent(ctx, ex, entity.EventRateDeleted, "conversion", id, change.TenantID, change)
+	})
 }
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+)
+
+type mysqlConversionQuota struct {
+	db *tracedDB
+}
+
+type ConversionQuotaRepo interface {
+	GetQuotaLimit(ctx context.Context, clientID string) (int64, error)
+	SetQuotaLimit(ctx context.Context, clientID string, limit int64, now time.Time) error
+	GetQuotaUsage(ctx context.Context, clientID, period string) (int64, error)
+	IncrementQuotaUsage(ctx context.Context, clientID, period string, limit int64, now time.Time) error
+	DecrementQuotaUsage(ctx context.Context, clientID, period string, now time.Time) error
+}
+
+//NewMysqlConversionQuota is a function to create implementation of mysql ConversionQuota repository.
+//The quotas and usages are those of the clients of the tenant of the context, client ids are
+//only unique within a tenant.
+func NewMysqlConversionQuota(db *sql.DB) ConversionQuotaRepo {
+	return &mysqlConversionQuota{traced(db)}
+}
+
+// GetQuotaLimit returns the monthly limit set for clientID, return Not Found when the client uses the default limit
+func (t *mysqlConversionQuota) GetQuotaLimit(ctx context.Context, clientID string) (int64, error) {
+	var limit int64
+	err := t.db.QueryRowContext(ctx, "SELECT monthly_conversions FROM conversion_quotas WHERE tenant_id = ? AND client_id = ?", request.TenantID(ctx), clientID).Scan(&limit)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("Not Found")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return limit, nil
+}
+
+func (t *mysqlConversionQuota) SetQuotaLimit(ctx context.Context, clientID string, limit int64, now time.Time) error {
+	query := `INSERT INTO conversion_quotas (tenant_id, client_id, monthly_conversions, updated_at, created_at) VALUES (?, ?, ?, ?, ?)
+						ON DUPLICATE KEY UPDATE monthly_conversions = VALUES(monthly_conversions), updated_at = VALUES(updated_at)`
+
+	_, err := t.db.ExecContext(ctx, query, request.TenantID(ctx), clientID, limit, sqlTime(now), sqlTime(now))
+	return err
+}
+
+func (t *mysqlConversionQuota) GetQuotaUsage(ctx context.Context, clientID, period string) (int64, error) {
+	var used int64
+	err := t.db.QueryRowContext(ctx, "SELECT used FROM conversion_quota_usages WHERE tenant_id = ? AND client_id = ? AND period = ?", request.TenantID(ctx), clientID, period).Scan(&used)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return used, nil
+}
+
+// IncrementQuotaUsage counts a conversion of clientID in period. The usage is only
+// incremented while it is below limit, so concurrent conversions cannot exceed it.
+func (t *mysqlConversionQuota) IncrementQuotaUsage(ctx context.Context, clientID, period string, limit int64, now time.Time) error {
+	_, err := t.db.ExecContext(ctx,
+		"INSERT IGNORE INTO conversion_quota_usages (tenant_id, client_id, period, used, updated_at, created_at) VALUES (?, ?, ?, 0, ?, ?)",
+		request.TenantID(ctx), clientID, period, sqlTime(now), sqlTime(now))
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE conversion_quota_usages SET used = used + 1, updated_at = ? WHERE tenant_id = ? AND client_id = ? AND period = ?"
+	args := []interface{}{sqlTime(now), request.TenantID(ctx), clientID, period}
+	if limit > 0 {
+		query += " AND used < ?"
+		args = append(args, limit)
+	}
+
+	res, err := t.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affect == 0 {
+		return fmt.Errorf("Too Many Requests: monthly conversion quota exceeded")
+	}
+
+	return nil
+}
+
+// DecrementQuotaUsage gives back a conversion counted for a request that failed
+func (t *mysqlConversionQuota) DecrementQuotaUsage(ctx context.Context, clientID, period string, now time.Time) error {
+	query := "UPDATE conversion_quota_usages SET used = used - 1, updated_at = ? WHERE tenant_id = ? AND client_id = ? AND period = ? AND used > 0"
+
+	_, err := t.db.ExecContext(ctx, query, sqlTime(now), request.TenantID(ctx), clientID, period)
+	return err
+}
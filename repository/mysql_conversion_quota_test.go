@@ -0,0 +1,126 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+func Test_mysqlConversionQuota_GetQuotaLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT monthly_conversions FROM conversion_quotas").WithArgs("", "pricing").
+		WillReturnRows(sqlmock.NewRows([]string{"monthly_conversions"}).AddRow(1000))
+	mock.ExpectQuery("^SELECT monthly_conversions FROM conversion_quotas").WithArgs("", "billing").WillReturnError(sql.ErrNoRows)
+
+	repo := repository.NewMysqlConversionQuota(db)
+
+	limit, err := repo.GetQuotaLimit(context.TODO(), "pricing")
+	if err != nil || limit != 1000 {
+		t.Errorf("mysqlConversionQuota.GetQuotaLimit() = %d, %v, want 1000", limit, err)
+	}
+
+	if _, err := repo.GetQuotaLimit(context.TODO(), "billing"); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlConversionQuota.GetQuotaLimit() error = %v, want Not Found", err)
+	}
+}
+
+func Test_mysqlConversionQuota_QuotaOfAnotherTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// the client id pricing of acme is not the client id pricing of globex
+	mock.ExpectExec("^INSERT INTO conversion_quotas").WithArgs("acme", "pricing", 1000, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("^SELECT monthly_conversions FROM conversion_quotas WHERE tenant_id = \\? AND client_id = \\?").
+		WithArgs("globex", "pricing").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("^INSERT IGNORE INTO conversion_quota_usages").WithArgs("globex", "pricing", "2026-10", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE conversion_quota_usages SET used = used \\+ 1").WithArgs(sqlmock.AnyArg(), "globex", "pricing", "2026-10").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := repository.NewMysqlConversionQuota(db)
+	acme := request.WithTenantID(context.TODO(), "acme")
+	globex := request.WithTenantID(context.TODO(), "globex")
+
+	if err := repo.SetQuotaLimit(acme, "pricing", 1000, time.Now()); err != nil {
+		t.Errorf("mysqlConversionQuota.SetQuotaLimit() error = %v", err)
+	}
+	if _, err := repo.GetQuotaLimit(globex, "pricing"); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlConversionQuota.GetQuotaLimit() error = %v, want Not Found", err)
+	}
+	if err := repo.IncrementQuotaUsage(globex, "pricing", "2026-10", 0, time.Now()); err != nil {
+		t.Errorf("mysqlConversionQuota.IncrementQuotaUsage() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_mysqlConversionQuota_IncrementQuotaUsage(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name         string
+		limit        int64
+		wantQuery    string
+		rowsAffected int64
+		wantErr      string
+	}{
+		{
+			name:         "below limit",
+			limit:        10,
+			wantQuery:    `^UPDATE conversion_quota_usages SET used = used \+ 1(.+) AND used < \?$`,
+			rowsAffected: 1,
+		},
+		{
+			name:         "limit reached",
+			limit:        10,
+			wantQuery:    `^UPDATE conversion_quota_usages SET used = used \+ 1(.+) AND used < \?$`,
+			rowsAffected: 0,
+			wantErr:      "Too Many Requests: monthly conversion quota exceeded",
+		},
+		{
+			name:         "unlimited",
+			wantQuery:    `^UPDATE conversion_quota_usages SET used = used \+ 1(.+) AND period = \?$`,
+			rowsAffected: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectExec("^INSERT IGNORE INTO conversion_quota_usages").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(tt.wantQuery).WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+
+			repo := repository.NewMysqlConversionQuota(db)
+			err = repo.IncrementQuotaUsage(context.TODO(), "pricing", "2026-10", tt.limit, now)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("mysqlConversionQuota.IncrementQuotaUsage() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("mysqlConversionQuota.IncrementQuotaUsage() error = %v, want %s", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
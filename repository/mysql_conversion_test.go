@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/bxcodec/faker"
@@ -83,9 +85,9 @@ func Test_mysqlConversion_GetConversions(t *testing.T) {
 			}
 			defer db.Close()
 
-			rows := sqlmock.NewRows([]string{"id", "currency_id_from", "currency_id_to", "rate", "updated_at", "created_at"})
+			rows := sqlmock.NewRows([]string{"id", "tenant_id", "currency_id_from", "currency_id_to", "rate", "version", "updated_at", "created_at"})
 			for _, v := range tt.want {
-				rows = rows.AddRow(v.ID, v.CurrencyIDFrom, v.CurrencyIDTo, v.Rate, v.UpdatedAt, v.CreatedAt)
+				rows = rows.AddRow(v.ID, v.TenantID, v.CurrencyIDFrom, v.CurrencyIDTo, v.Rate, v.Version, v.UpdatedAt, v.CreatedAt)
 			}
 
 			rowCount := sqlmock.NewRows([]string{"total"}).AddRow(len(tt.want))
@@ -100,7 +102,7 @@ func Test_mysqlConversion_GetConversions(t *testing.T) {
 			if tt.selectErrQuery != nil {
 				mock.ExpectQuery("^SELECT id(.+)").WillReturnError(tt.selectErrQuery)
 			} else {
-				mock.ExpectQuery("^SELECT id(.+)").WillReturnRows(rows)
+				mock.ExpectQuery("^SELECT id(.+) ORDER BY id LIMIT").WillReturnRows(rows)
 			}
 
 			repo := repository.NewMysqlConversion(db)
@@ -170,8 +172,8 @@ func Test_mysqlConversion_GetConversion(t *testing.T) {
 			}
 			defer db.Close()
 			if tt.want != nil {
-				rows = sqlmock.NewRows([]string{"id", "currency_id_from", "currency_id_to", "rate", "updated_at", "created_at"}).
-					AddRow(tt.want.ID, tt.want.CurrencyIDFrom, tt.want.CurrencyIDTo, tt.want.Rate, tt.want.UpdatedAt, tt.want.CreatedAt)
+				rows = sqlmock.NewRows([]string{"id", "tenant_id", "currency_id_from", "currency_id_to", "rate", "version", "updated_at", "created_at"}).
+					AddRow(tt.want.ID, tt.want.TenantID, tt.want.CurrencyIDFrom, tt.want.CurrencyIDTo, tt.want.Rate, tt.want.Version, tt.want.UpdatedAt, tt.want.CreatedAt)
 			}
 
 			if tt.returnQuery != nil {
@@ -230,12 +232,14 @@ func Test_mysqlConversion_CreateConversion(t *testing.T) {
 				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 			}
 			defer db.Close()
+			mock.ExpectBegin()
 			prep := mock.ExpectExec("^INSERT INTO conversions(.+)")
 			if tt.returnErr != nil {
 				prep.WillReturnError(tt.returnErr)
 			} else {
 				prep.WillReturnResult(sqlmock.NewResult(2, 1))
 			}
+			expectEvent(mock, entity.EventRateCreated, !tt.wantErr)
 
 			repo := repository.NewMysqlConversion(db)
 			if err := repo.CreateConversion(tt.args.ctx, tt.args.category); (err != nil) != tt.wantErr {
@@ -291,6 +295,7 @@ func Test_mysqlConversion_UpdateConversion(t *testing.T) {
 			}
 			defer db.Close()
 
+			mock.ExpectBegin()
 			prep := mock.ExpectExec("^UPDATE conversions(.+)")
 
 			if tt.returnErr != nil {
@@ -298,6 +303,7 @@ func Test_mysqlConversion_UpdateConversion(t *testing.T) {
 			} else {
 				prep.WillReturnResult(sqlmock.NewResult(2, tt.rowsAffected))
 			}
+			expectEvent(mock, entity.EventRateUpdated, !tt.wantErr)
 
 			repo := repository.NewMysqlConversion(db)
 			if err := repo.UpdateConversion(tt.args.ctx, tt.args.id, tt.args.category); (err != nil) != tt.wantErr {
@@ -307,6 +313,52 @@ func Test_mysqlConversion_UpdateConversion(t *testing.T) {
 	}
 }
 
+func Test_mysqlConversion_UpdateConversionVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenantID string
+		stored   *sqlmock.Rows
+		wantErr  string
+	}{
+		{
+			name:     "stale version",
+			tenantID: "acme",
+			stored:   sqlmock.NewRows([]string{"version"}).AddRow(3),
+			wantErr:  "Precondition Failed: conversion has been modified",
+		},
+		{
+			// the rate 1 is global, a tenant cannot change it whatever its version
+			name:     "global rate",
+			tenantID: "acme",
+			stored:   sqlmock.NewRows([]string{"version"}),
+			wantErr:  "Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`^UPDATE conversions (.+) WHERE ID = 1 AND tenant_id = "acme" AND version = 2$`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT version FROM conversions WHERE id = ? AND tenant_id = ?")).WithArgs(1, tt.tenantID).WillReturnRows(tt.stored)
+			mock.ExpectRollback()
+
+			repo := repository.NewMysqlConversion(db)
+			err = repo.UpdateConversion(request.WithTenantID(context.TODO(), tt.tenantID), 1, &entity.Conversion{Rate: 2, Version: 2})
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("mysqlConversion.UpdateConversion() error = %v, want %s", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
 func Test_mysqlConversion_DeleteConversion(t *testing.T) {
 
 	type args struct {
@@ -348,6 +400,7 @@ func Test_mysqlConversion_DeleteConversion(t *testing.T) {
 			}
 			defer db.Close()
 
+			mock.ExpectBegin()
 			prep := mock.ExpectExec("^DELETE FROM conversions(.+)")
 
 			if tt.returnErr != nil {
@@ -355,6 +408,7 @@ func Test_mysqlConversion_DeleteConversion(t *testing.T) {
 			} else {
 				prep.WillReturnResult(sqlmock.NewResult(2, tt.rowAffected))
 			}
+			expectEvent(mock, entity.EventRateDeleted, !tt.wantErr)
 
 			repo := repository.NewMysqlConversion(db)
 			if err := repo.DeleteConversion(tt.args.ctx, tt.args.id); (err != nil) != tt.wantErr {
@@ -363,3 +417,60 @@ func Test_mysqlConversion_DeleteConversion(t *testing.T) {
 		})
 	}
 }
+
+func Test_mysqlConversion_TenantScope(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := request.WithTenantID(context.TODO(), "acme")
+	columns := []string{"id", "tenant_id", "currency_id_from", "currency_id_to", "rate", "version", "updated_at", "created_at"}
+	// tenant rates plus the global rates of pairs the tenant has not overridden
+	effective := regexp.QuoteMeta(`(tenant_id = "acme" OR (tenant_id = '' AND NOT EXISTS (`) + `(.+)` + regexp.QuoteMeta(`o.tenant_id = "acme"`)
+
+	mock.ExpectQuery("^SELECT id(.+) WHERE id = 7 AND " + regexp.QuoteMeta(`tenant_id IN ('', "acme")`) + "$").WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery("^SELECT COUNT(.+) WHERE " + effective + "(.+) AND \\(\\(currency_id_from = 1 AND currency_id_to = 2\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1))
+	mock.ExpectQuery("^SELECT(.+) WHERE " + effective + "(.+) AND \\(\\(currency_id_from = 1 AND currency_id_to = 2\\)").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "acme", 1, 2, 1.5, 1, time.Time{}, time.Time{}))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO conversions (tenant_id, currency_id_from, currency_id_to, rate, updated_at, created_at) VALUES ("acme", 1, 2`)).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	expectEvent(mock, entity.EventRateCreated, true)
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE conversions (.+) WHERE ID = 7 AND tenant_id = "acme"$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectEvent(mock, entity.EventRateUpdated, false)
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM conversions WHERE id = 7 AND tenant_id = "acme"$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectEvent(mock, entity.EventRateDeleted, false)
+
+	repo := repository.NewMysqlConversion(db)
+
+	if _, err := repo.GetConversion(ctx, 7); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlConversion.GetConversion() error = %v, want Not Found", err)
+	}
+
+	list, _, err := repo.GetConversions(ctx, &request.ConversionParameter{Limit: 10, CurrencyIDFrom: 1, CurrencyIDTo: 2})
+	if err != nil || len(list) != 1 || list[0].TenantID != "acme" {
+		t.Errorf("mysqlConversion.GetConversions() = %v, %v", list, err)
+	}
+
+	conversion := entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5}
+	if err := repo.CreateConversion(ctx, &conversion); err != nil || conversion.TenantID != "acme" {
+		t.Errorf("mysqlConversion.CreateConversion() tenant = %q, error = %v", conversion.TenantID, err)
+	}
+
+	if err := repo.UpdateConversion(ctx, 7, &entity.Conversion{Rate: 2}); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlConversion.UpdateConversion() error = %v, want Not Found", err)
+	}
+
+	if err := repo.DeleteConversion(ctx, 7); err == nil {
+		t.Errorf("mysqlConversion.DeleteConversion() error = nil, want error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+// mysqlConvertCurrencies scopes every query by the tenant of the caller, a tenant only sees
+// the conversions made by its clients.
+type mysqlConvertCurrencies struct {
+	db *tracedDB
+}
+
+type ConvertCurrenciesRepo interface {
+	CreateConvertCurrencies(ctx context.Context, ec *entity.ConvertCurrencies) error
+	GetConvertCurrency(ctx context.Context, id int64) (*entity.ConvertCurrencies, error)
+	GetConvertCurrencies(ctx context.Context, p *request.ConvertCurrenciesParameter) ([]entity.ConvertCurrencies, int64, error)
+}
+
+//NewMysqlConvertCurrencies is a function to create implementation of mysql ConvertCurrencies repository
+func NewMysqlConvertCurrencies(db *sql.DB, replicas ...*sql.DB) ConvertCurrenciesRepo {
+	return &mysqlConvertCurrencies{traced(db, replicas...)}
+}
+
+func (t *mysqlConvertCurrencies) fetch(ctx context.Context, query string, args ...interface{}) ([]entity.ConvertCurrencies, error) {
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]entity.ConvertCurrencies, 0)
+	for rows.Next() {
+		cc := entity.ConvertCurrencies{}
+		err = rows.Scan(
+			&cc.ID,
+			&cc.TenantID,
+			&cc.ConversionID,
+			&cc.QuoteID,
+			&cc.CurrencyIDFrom,
+			&cc.CurrencyIDTo,
+			&cc.Amount,
+			&cc.Rate,
+			&cc.Result,
+			&cc.ClientID,
+			&cc.IdempotencyKey,
+			&cc.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, cc)
+	}
+
+	return result, nil
+}
+
+func (t *mysqlConvertCurrencies) GetConvertCurrency(ctx context.Context, id int64) (*entity.ConvertCurrencies, error) {
+	query := `SELECT id, tenant_id, conversion_id, quote_id, currency_id_from, currency_id_to, amount, rate, result, client_id, idempotency_key, created_at
+						  FROM convert_currencies WHERE id = %d AND tenant_id = ?`
+
+	list, err := t.fetch(ctx, buildQuery(query, id), request.TenantID(ctx))
+	if err == sql.ErrNoRows || len(list) == 0 {
+		return nil, fmt.Errorf("Not Found")
+	}
+
+	return &list[0], nil
+}
+
+func (t *mysqlConvertCurrencies) GetConvertCurrencies(ctx context.Context, p *request.ConvertCurrenciesParameter) ([]entity.ConvertCurrencies, int64, error) {
+	var total int64
+
+	conditions := []string{"tenant_id = ?"}
+	args := []interface{}{request.TenantID(ctx)}
+	if p.CurrencyIDFrom != 0 {
+		conditions = append(conditions, buildQuery("currency_id_from = %d", p.CurrencyIDFrom))
+	}
+	if p.CurrencyIDTo != 0 {
+		conditions = append(conditions, buildQuery("currency_id_to = %d", p.CurrencyIDTo))
+	}
+	if p.ClientID != "" {
+		conditions = append(conditions, "client_id = ?")
+		args = append(args, p.ClientID)
+	}
+	if p.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, sqlTime(p.CreatedFrom.UTC()))
+	}
+	if p.CreatedTo != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, sqlTime(p.CreatedTo.UTC()))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	err := t.db.QueryRowContext(ctx, buildQuery("SELECT COUNT(id) FROM convert_currencies WHERE %s", where), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT id, tenant_id, conversion_id, quote_id, currency_id_from, currency_id_to, amount, rate, result, client_id, idempotency_key, created_at
+						FROM convert_currencies WHERE %s ORDER BY id DESC LIMIT %d, %d `
+
+	result, err := t.fetch(ctx, buildQuery(query, where, p.Offset, p.Limit), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, total, err
+}
+
+// CreateConvertCurrencies records an executed conversion with its ConversionExecuted event
+func (t *mysqlConvertCurrencies) CreateConvertCurrencies(ctx context.Context, cc *entity.ConvertCurrencies) error {
+	query := `INSERT INTO convert_currencies (tenant_id, conversion_id, quote_id, currency_id_from, currency_id_to, amount, rate, result, client_id, idempotency_key, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	cc.TenantID = request.TenantID(ctx)
+	return t.db.inTx(ctx, func(ex executor) error {
+		res, err := ex.ExecContext(ctx, query,
+			cc.TenantID,
+			cc.ConversionID,
+			cc.QuoteID,
+			cc.CurrencyIDFrom,
+			cc.CurrencyIDTo,
+			cc.Amount,
+			cc.Rate,
+			cc.Result,
+			cc.ClientID,
+			cc.IdempotencyKey,
+			sqlTime(cc.CreatedAt),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		lastID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		cc.ID = lastID
+
+		return appendEvent(ctx, ex, entity.EventConversionExecuted, "convert_currencies", lastID, cc.TenantID, cc)
+	})
+}
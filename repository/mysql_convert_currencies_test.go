@@ -0,0 +1,234 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bxcodec/faker"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+var convertCurrenciesColumns = []string{"id", "tenant_id", "conversion_id", "quote_id", "currency_id_from", "currency_id_to", "amount", "rate", "result", "client_id", "idempotency_key", "created_at"}
+
+func Test_mysqlConvertCurrencies_GetConvertCurrencies(t *testing.T) {
+	createdFrom := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name           string
+		params         request.ConvertCurrenciesParameter
+		wantWhere      string
+		wantArgs       []driver.Value
+		countErrQuery  error
+		selectErrQuery error
+		want           []entity.ConvertCurrencies
+		wantErr        bool
+	}{
+		{
+			name:      "no filter",
+			params:    request.ConvertCurrenciesParameter{Limit: 10},
+			wantWhere: "WHERE tenant_id = ? ORDER",
+			wantArgs:  []driver.Value{""},
+			want:      make([]entity.ConvertCurrencies, 3),
+		},
+		{
+			name:      "filter by client and date",
+			params:    request.ConvertCurrenciesParameter{Limit: 10, ClientID: "client-1", CreatedFrom: &createdFrom},
+			wantWhere: `tenant_id = ? AND client_id = ? AND created_at >= ?`,
+			wantArgs:  []driver.Value{"", "client-1", createdFrom.UTC().Format(repository.MysqlTimeFormat)},
+			want:      make([]entity.ConvertCurrencies, 1),
+		},
+		{
+			name:          "error count",
+			params:        request.ConvertCurrenciesParameter{Limit: 10},
+			countErrQuery: errors.New("error count"),
+			wantErr:       true,
+		},
+		{
+			name:           "error select",
+			params:         request.ConvertCurrenciesParameter{Limit: 10},
+			selectErrQuery: errors.New("error select"),
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.want {
+				err := faker.FakeData(&tt.want[i])
+				if err != nil {
+					fmt.Println(err)
+				}
+			}
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			rows := sqlmock.NewRows(convertCurrenciesColumns)
+			for _, v := range tt.want {
+				rows = rows.AddRow(v.ID, v.TenantID, v.ConversionID, v.QuoteID, v.CurrencyIDFrom, v.CurrencyIDTo, v.Amount, v.Rate, v.Result, v.ClientID, v.IdempotencyKey, v.CreatedAt)
+			}
+
+			if tt.countErrQuery != nil {
+				mock.ExpectQuery("^SELECT COUNT(.+)").WillReturnError(tt.countErrQuery)
+			} else {
+				mock.ExpectQuery("^SELECT COUNT(.+)").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(len(tt.want)))
+			}
+
+			if tt.selectErrQuery != nil {
+				mock.ExpectQuery("^SELECT id(.+)").WillReturnError(tt.selectErrQuery)
+			} else if tt.countErrQuery == nil {
+				mock.ExpectQuery("^SELECT id(.+)" + regexp.QuoteMeta(tt.wantWhere)).WithArgs(tt.wantArgs...).WillReturnRows(rows)
+			}
+
+			repo := repository.NewMysqlConvertCurrencies(db)
+			result, total, err := repo.GetConvertCurrencies(context.TODO(), &tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mysqlConvertCurrencies.GetConvertCurrencies() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, result); diff != "" {
+				t.Errorf("mysqlConvertCurrencies.GetConvertCurrencies() mismatch (-want +got):\n%s", diff)
+			}
+			if total != int64(len(tt.want)) {
+				t.Errorf("mysqlConvertCurrencies.GetConvertCurrencies() got1 = %v, want %v", total, len(tt.want))
+			}
+		})
+	}
+}
+
+func Test_mysqlConvertCurrencies_GetConvertCurrency(t *testing.T) {
+	sample := entity.ConvertCurrencies{}
+	err := faker.FakeData(&sample)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows(convertCurrenciesColumns).
+		AddRow(sample.ID, sample.TenantID, sample.ConversionID, sample.QuoteID, sample.CurrencyIDFrom, sample.CurrencyIDTo, sample.Amount, sample.Rate, sample.Result, sample.ClientID, sample.IdempotencyKey, sample.CreatedAt)
+	mock.ExpectQuery("^SELECT id(.+) AND tenant_id = \\?").WithArgs(sample.TenantID).WillReturnRows(rows)
+	mock.ExpectQuery("^SELECT id(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	repo := repository.NewMysqlConvertCurrencies(db)
+
+	got, err := repo.GetConvertCurrency(request.WithTenantID(context.TODO(), sample.TenantID), sample.ID)
+	if err != nil {
+		t.Fatalf("mysqlConvertCurrencies.GetConvertCurrency() error = %v", err)
+	}
+	if diff := cmp.Diff(&sample, got); diff != "" {
+		t.Errorf("mysqlConvertCurrencies.GetConvertCurrency() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := repo.GetConvertCurrency(context.TODO(), sample.ID+1); err == nil {
+		t.Errorf("mysqlConvertCurrencies.GetConvertCurrency() expected not found error")
+	}
+}
+
+func Test_mysqlConvertCurrencies_TenantScope(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// the conversion 7 was made by a client of acme, it is not found by globex
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT INTO convert_currencies").WithArgs("acme", 1, 0, 1, 2, 10.0, 2.0, 20.0, "pricing", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec("^INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id = 7 AND tenant_id = ?")).WithArgs("globex").WillReturnRows(sqlmock.NewRows(convertCurrenciesColumns))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(id) FROM convert_currencies WHERE tenant_id = ? AND client_id = ?")).
+		WithArgs("globex", "pricing").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE tenant_id = ? AND client_id = ?")).WithArgs("globex", "pricing").WillReturnRows(sqlmock.NewRows(convertCurrenciesColumns))
+
+	repo := repository.NewMysqlConvertCurrencies(db)
+	acme := request.WithTenantID(context.TODO(), "acme")
+	globex := request.WithTenantID(context.TODO(), "globex")
+
+	cc := entity.ConvertCurrencies{ConversionID: 1, CurrencyIDFrom: 1, CurrencyIDTo: 2, Amount: 10, Rate: 2, Result: 20, ClientID: "pricing"}
+	if err := repo.CreateConvertCurrencies(acme, &cc); err != nil || cc.TenantID != "acme" {
+		t.Errorf("mysqlConvertCurrencies.CreateConvertCurrencies() error = %v, tenant = %q", err, cc.TenantID)
+	}
+	if _, err := repo.GetConvertCurrency(globex, 7); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlConvertCurrencies.GetConvertCurrency() error = %v, want Not Found", err)
+	}
+	if list, _, err := repo.GetConvertCurrencies(globex, &request.ConvertCurrenciesParameter{Limit: 10, ClientID: "pricing"}); err != nil || len(list) != 0 {
+		t.Errorf("mysqlConvertCurrencies.GetConvertCurrencies() = %v, %v, want none", list, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_mysqlConvertCurrencies_CreateConvertCurrencies(t *testing.T) {
+	sample := entity.ConvertCurrencies{}
+	err := faker.FakeData(&sample)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	tests := []struct {
+		name      string
+		returnErr error
+		wantErr   bool
+	}{
+		{
+			name:    "insert ok",
+			wantErr: false,
+		},
+		{
+			name:      "insert failed",
+			returnErr: errors.New("fail insert"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+			mock.ExpectBegin()
+			prep := mock.ExpectExec("^INSERT INTO convert_currencies(.+)")
+			if tt.returnErr != nil {
+				prep.WillReturnError(tt.returnErr)
+			} else {
+				prep.WillReturnResult(sqlmock.NewResult(7, 1))
+			}
+			expectEvent(mock, entity.EventConversionExecuted, !tt.wantErr)
+
+			repo := repository.NewMysqlConvertCurrencies(db)
+			err = repo.CreateConvertCurrencies(context.TODO(), &sample)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mysqlConvertCurrencies.CreateConvertCurrencies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && sample.ID != 7 {
+				t.Errorf("mysqlConvertCurrencies.CreateConvertCurrencies() id = %v, want %v", sample.ID, 7)
+			}
+		})
+	}
+}
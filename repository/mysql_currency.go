@@ -4,13 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/entity"
 )
 
+// mysqlCurrency scopes every query by the tenant of the caller: a tenant sees the global
+// currencies and its own, and can only change its own.
 type mysqlCurrency struct {
-	db *sql.DB
+	db *tracedDB
 }
 
 type CurrencyRepo interface {
@@ -22,12 +25,13 @@ type CurrencyRepo interface {
 }
 
 //NewMysqlCurrency is a function to create implementation of mysql Currency repository
-func NewMysqlCurrency(db *sql.DB) CurrencyRepo {
-	return &mysqlCurrency{db}
+func NewMysqlCurrency(db *sql.DB, replicas ...*sql.DB) CurrencyRepo {
+	return &mysqlCurrency{traced(db, replicas...)}
 }
 
-func (t *mysqlCurrency) fetch(ctx context.Context, query string) ([]entity.Currency, error) {
-	rows, err := t.db.QueryContext(ctx, query)
+func (t *mysqlCurrency) fetch(ctx context.Context, query string, args ...interface{}) ([]entity.Currency, error) {
+	logQuery(ctx, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +43,9 @@ func (t *mysqlCurrency) fetch(ctx context.Context, query string) ([]entity.Curre
 		cat := entity.Currency{}
 		err = rows.Scan(
 			&cat.ID,
+			&cat.TenantID,
 			&cat.Name,
+			&cat.Version,
 			&cat.UpdatedAt,
 			&cat.CreatedAt,
 		)
@@ -53,12 +59,21 @@ func (t *mysqlCurrency) fetch(ctx context.Context, query string) ([]entity.Curre
 	return result, nil
 }
 
+// GetCurrency locks the currency until the end of the transaction of ctx, if any, so it is not
+// changed or deleted before the transaction commits
 func (t *mysqlCurrency) GetCurrency(ctx context.Context, id int64) (*entity.Currency, error) {
-	query := `SELECT id, name, updated_at, created_at
-						  FROM currencies WHERE id = %d`
+	query := `SELECT id, tenant_id, name, version, updated_at, created_at
+						  FROM currencies WHERE id = %d AND %s`
+	query = buildQuery(query, id, visibleToTenant(request.TenantID(ctx)))
+	if inTransaction(ctx) {
+		query += " FOR UPDATE"
+	}
 
-	list, err := t.fetch(ctx, buildQuery(query, id))
-	if err == sql.ErrNoRows || len(list) == 0 {
+	list, err := t.fetch(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("get currency %d: %w", id, err)
+	}
+	if len(list) == 0 {
 		return nil, fmt.Errorf("Not Found")
 	}
 
@@ -70,21 +85,26 @@ func (t *mysqlCurrency) GetCurrencies(ctx context.Context, p *request.CurrencyPa
 	var total int64
 	var queryString string
 
-	err := t.db.QueryRowContext(ctx, "SELECT COUNT(id) FROM currencies").Scan(&total)
+	scope := visibleToTenant(request.TenantID(ctx))
+	if len(p.IDs) > 0 {
+		scope += buildQuery(" AND id IN (%s)", int64sToString(p.IDs, ", "))
+	}
+	err := t.db.QueryRowContext(ctx, buildQuery("SELECT COUNT(id) FROM currencies WHERE %s", scope)).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	var args []interface{}
 	if p.Query != "" {
-		query := `SELECT id, name, updated_at, created_at FROM currencies WHERE name LIKE '%%%s%%' LIMIT %d, %d `
-		queryString = buildQuery(query, p.Query, p.Offset, p.Limit)
-		fmt.Println(queryString)
+		query := `SELECT id, tenant_id, name, version, updated_at, created_at FROM currencies WHERE %s AND name LIKE ? ORDER BY id LIMIT %d, %d `
+		queryString = buildQuery(query, scope, p.Offset, p.Limit)
+		args = append(args, containing(p.Query))
 	} else {
-		query := `SELECT id, name, updated_at, created_at FROM currencies LIMIT %d, %d `
-		queryString = buildQuery(query, p.Offset, p.Limit)
+		query := `SELECT id, tenant_id, name, version, updated_at, created_at FROM currencies WHERE %s ORDER BY id LIMIT %d, %d `
+		queryString = buildQuery(query, scope, p.Offset, p.Limit)
 	}
 
-	result, err = t.fetch(ctx, queryString)
+	result, err = t.fetch(ctx, queryString, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -93,69 +113,98 @@ func (t *mysqlCurrency) GetCurrencies(ctx context.Context, p *request.CurrencyPa
 }
 
 func (t *mysqlCurrency) CreateCurrency(ctx context.Context, Currency *entity.Currency) error {
-	query := `INSERT INTO currencies (name, updated_at, created_at) VALUES (%q, %q, %q)`
-
-	res, err := t.db.ExecContext(ctx,
-		buildQuery(query,
-			Currency.Name,
-			sqlTime(Currency.UpdatedAt),
-			sqlTime(Currency.CreatedAt)),
-	)
-	if err != nil {
+	query := `INSERT INTO currencies (tenant_id, name, updated_at, created_at) VALUES (%q, %q, %q, %q)`
+
+	Currency.TenantID = request.TenantID(ctx)
+	return t.db.inTx(ctx, func(ex executor) error {
+		res, err := ex.ExecContext(ctx,
+			buildQuery(query,
+				Currency.TenantID,
+				Currency.Name,
+				sqlTime(Currency.UpdatedAt),
+				sqlTime(Currency.CreatedAt)),
+		)
+		if err != nil {
 
-		return err
-	}
-	lastID, err := res.LastInsertId()
-	if err != nil {
-		return err
-	}
-	Currency.ID = lastID
-	return nil
+			return err
+		}
+		lastID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		Currency.ID = lastID
+		Currency.Version = 1
+
+		change := currencyChange{ID: lastID, TenantID: Currency.TenantID, Name: Currency.Name, UpdatedAt: Currency.UpdatedAt}
+		return appendEvent(ctx, ex, entity.EventCurrencyCreated, "currency", lastID, Currency.TenantID, change)
+	})
 }
 
+// UpdateCurrency updates the name and bumps the version. When Currency.Version is set,
+// the update only succeeds if it still matches the stored version.
 func (t *mysqlCurrency) UpdateCurrency(ctx context.Context, id int64, Currency *entity.Currency) error {
-	query := `UPDATE currencies set name=%q, updated_at=%q WHERE ID = %d`
-
-	res, err := t.db.ExecContext(ctx, buildQuery(query, Currency.Name, sqlTime(Currency.UpdatedAt), id))
-	if err != nil {
-		return err
-	}
-	affect, err := res.RowsAffected()
-	if err != nil {
-		return err
+	query := `UPDATE currencies set name=%q, version=version+1, updated_at=%q WHERE ID = %d AND tenant_id = %q`
+	queryString := buildQuery(query, Currency.Name, sqlTime(Currency.UpdatedAt), id, request.TenantID(ctx))
+	if Currency.Version > 0 {
+		queryString += buildQuery(" AND version = %d", Currency.Version)
 	}
 
-	if affect == 0 {
-		err = fmt.Errorf("Not Found")
+	err := t.db.inTx(ctx, func(ex executor) error {
+		res, err := ex.ExecContext(ctx, queryString)
+		if err != nil {
+			return err
+		}
+		affect, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affect == 0 && Currency.Version > 0 {
+			return versionMismatch(ctx, ex, "currencies", id, "currency")
+		}
 
-		return err
-	}
+		if affect == 0 {
+			err = fmt.Errorf("Not Found")
 
-	if affect != 1 {
-		err = fmt.Errorf("weird  behaviour. total affected: %d", affect)
+			return err
+		}
 
+		if affect != 1 {
+			err = fmt.Errorf("weird  behaviour. total affected: %d", affect)
+
+			return err
+		}
+
+		change := currencyChange{ID: id, TenantID: request.TenantID(ctx), Name: Currency.Name, UpdatedAt: Currency.UpdatedAt}
+		return appendEvent(ctx, ex, entity.EventCurrencyUpdated, "currency", id, change.TenantID, change)
+	})
+	if err != nil {
 		return err
 	}
 
+	Currency.Version++
 	return nil
 }
 
 func (t *mysqlCurrency) DeleteCurrency(ctx context.Context, id int64) error {
-	query := "DELETE FROM currencies WHERE id = %d"
+	query := "DELETE FROM currencies WHERE id = %d AND tenant_id = %q"
 
-	res, err := t.db.ExecContext(ctx, buildQuery(query, id))
-	if err != nil {
+	return t.db.inTx(ctx, func(ex executor) error {
+		res, err := ex.ExecContext(ctx, buildQuery(query, id, request.TenantID(ctx)))
+		if err != nil {
 
-		return err
-	}
-	rowsAfected, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rowsAfected != 1 {
-		err = fmt.Errorf("weird behaviour. total affected: %d", rowsAfected)
-		return err
-	}
+			return err
+		}
+		rowsAfected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAfected != 1 {
+			err = fmt.Errorf("weird behaviour. total affected: %d", rowsAfected)
+			return err
+		}
 
-	return nil
+		change := currencyChange{ID: id, TenantID: request.TenantID(ctx), UpdatedAt: time.Now().UTC()}
+		return appendEvent(ctx, ex, entity.EventCurrencyDeleted, "currency", id, change.TenantID, change)
+	})
 }
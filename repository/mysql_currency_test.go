@@ -5,7 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/bxcodec/faker"
@@ -82,9 +85,9 @@ func Test_mysqlCurrency_GetCurrencies(t *testing.T) {
 			}
 			defer db.Close()
 
-			rows := sqlmock.NewRows([]string{"id", "name", "updated_at", "created_at"})
+			rows := sqlmock.NewRows([]string{"id", "tenant_id", "name", "version", "updated_at", "created_at"})
 			for _, v := range tt.want {
-				rows = rows.AddRow(v.ID, v.Name, v.UpdatedAt, v.CreatedAt)
+				rows = rows.AddRow(v.ID, v.TenantID, v.Name, v.Version, v.UpdatedAt, v.CreatedAt)
 			}
 
 			rowCount := sqlmock.NewRows([]string{"total"}).AddRow(len(tt.want))
@@ -169,8 +172,8 @@ func Test_mysqlCurrency_GetCurrency(t *testing.T) {
 			}
 			defer db.Close()
 			if tt.want != nil {
-				rows = sqlmock.NewRows([]string{"id", "name", "updated_at", "created_at"}).
-					AddRow(tt.want.ID, tt.want.Name, tt.want.UpdatedAt, tt.want.CreatedAt)
+				rows = sqlmock.NewRows([]string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}).
+					AddRow(tt.want.ID, tt.want.TenantID, tt.want.Name, tt.want.Version, tt.want.UpdatedAt, tt.want.CreatedAt)
 			}
 
 			if tt.returnQuery != nil {
@@ -229,12 +232,14 @@ func Test_mysqlCurrency_CreateCurrency(t *testing.T) {
 				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 			}
 			defer db.Close()
+			mock.ExpectBegin()
 			prep := mock.ExpectExec("^INSERT INTO currencies(.+)")
 			if tt.returnErr != nil {
 				prep.WillReturnError(tt.returnErr)
 			} else {
 				prep.WillReturnResult(sqlmock.NewResult(2, 1))
 			}
+			expectEvent(mock, entity.EventCurrencyCreated, !tt.wantErr)
 
 			repo := repository.NewMysqlCurrency(db)
 			if err := repo.CreateCurrency(tt.args.ctx, tt.args.currency); (err != nil) != tt.wantErr {
@@ -290,6 +295,7 @@ func Test_mysqlCurrency_UpdateCurrency(t *testing.T) {
 			}
 			defer db.Close()
 
+			mock.ExpectBegin()
 			prep := mock.ExpectExec("^UPDATE currencies(.+)")
 
 			if tt.returnErr != nil {
@@ -297,6 +303,7 @@ func Test_mysqlCurrency_UpdateCurrency(t *testing.T) {
 			} else {
 				prep.WillReturnResult(sqlmock.NewResult(2, tt.rowsAffected))
 			}
+			expectEvent(mock, entity.EventCurrencyUpdated, !tt.wantErr)
 
 			repo := repository.NewMysqlCurrency(db)
 			if err := repo.UpdateCurrency(tt.args.ctx, tt.args.id, tt.args.currency); (err != nil) != tt.wantErr {
@@ -306,6 +313,83 @@ func Test_mysqlCurrency_UpdateCurrency(t *testing.T) {
 	}
 }
 
+func Test_mysqlCurrency_UpdateCurrencyVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		tenantID     string
+		version      int64
+		wantQuery    string
+		rowsAffected int64
+		stored       *sqlmock.Rows
+		wantErr      string
+	}{
+		{
+			name:         "unconditional update",
+			wantQuery:    "^UPDATE currencies set name=(.+), version=version\\+1, (.+) WHERE ID = 1 AND tenant_id = \"\"$",
+			rowsAffected: 1,
+		},
+		{
+			name:         "matching version",
+			version:      3,
+			wantQuery:    "^UPDATE currencies (.+) WHERE ID = 1 AND tenant_id = \"\" AND version = 3$",
+			rowsAffected: 1,
+		},
+		{
+			name:         "stale version",
+			version:      2,
+			wantQuery:    "^UPDATE currencies (.+) WHERE ID = 1 AND tenant_id = \"\" AND version = 2$",
+			rowsAffected: 0,
+			stored:       sqlmock.NewRows([]string{"version"}).AddRow(3),
+			wantErr:      "Precondition Failed",
+		},
+		{
+			// the currency 1 is global, a tenant cannot change it whatever its version
+			name:         "global currency",
+			tenantID:     "acme",
+			version:      2,
+			wantQuery:    "^UPDATE currencies (.+) WHERE ID = 1 AND tenant_id = \"acme\" AND version = 2$",
+			rowsAffected: 0,
+			stored:       sqlmock.NewRows([]string{"version"}),
+			wantErr:      "Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(tt.wantQuery).WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+			if tt.stored != nil {
+				mock.ExpectQuery("^SELECT version FROM currencies WHERE id = \\? AND tenant_id = \\?$").WithArgs(1, tt.tenantID).WillReturnRows(tt.stored)
+			}
+			expectEvent(mock, entity.EventCurrencyUpdated, tt.wantErr == "")
+
+			currency := entity.Currency{Name: "IDR", Version: tt.version}
+			repo := repository.NewMysqlCurrency(db)
+			err = repo.UpdateCurrency(request.WithTenantID(context.TODO(), tt.tenantID), 1, &currency)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("mysqlCurrency.UpdateCurrency() error = %v, want %v", err, tt.wantErr)
+				}
+				if err := mock.ExpectationsWereMet(); err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("mysqlCurrency.UpdateCurrency() error = %v", err)
+			}
+			if tt.version > 0 && currency.Version != tt.version+1 {
+				t.Errorf("mysqlCurrency.UpdateCurrency() version = %v, want %v", currency.Version, tt.version+1)
+			}
+		})
+	}
+}
+
 func Test_mysqlCurrency_DeleteCurrency(t *testing.T) {
 
 	type args struct {
@@ -347,6 +431,7 @@ func Test_mysqlCurrency_DeleteCurrency(t *testing.T) {
 			}
 			defer db.Close()
 
+			mock.ExpectBegin()
 			prep := mock.ExpectExec("^DELETE FROM currencies(.+)")
 
 			if tt.returnErr != nil {
@@ -354,6 +439,7 @@ func Test_mysqlCurrency_DeleteCurrency(t *testing.T) {
 			} else {
 				prep.WillReturnResult(sqlmock.NewResult(2, tt.rowAffected))
 			}
+			expectEvent(mock, entity.EventCurrencyDeleted, !tt.wantErr)
 
 			repo := repository.NewMysqlCurrency(db)
 			if err := repo.DeleteCurrency(tt.args.ctx, tt.args.id); (err != nil) != tt.wantErr {
@@ -362,3 +448,113 @@ func Test_mysqlCurrency_DeleteCurrency(t *testing.T) {
 		})
 	}
 }
+
+func Test_mysqlCurrency_TenantScope(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := request.WithTenantID(context.TODO(), "acme")
+	visible := regexp.QuoteMeta(`tenant_id IN ('', "acme")`)
+	columns := []string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}
+
+	mock.ExpectQuery("^SELECT id(.+) WHERE id = 7 AND " + visible + "$").WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery("^SELECT COUNT(.+) WHERE " + visible + "$").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1))
+	mock.ExpectQuery("^SELECT id(.+) WHERE " + visible + " ORDER BY id LIMIT").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "acme", "IDR", 1, time.Time{}, time.Time{}))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO currencies (tenant_id, name, updated_at, created_at) VALUES ("acme", "IDR"`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, entity.EventCurrencyCreated, true)
+	// another tenant's or a global currency is not updated or deleted
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE currencies (.+) WHERE ID = 7 AND tenant_id = "acme"$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectEvent(mock, entity.EventCurrencyUpdated, false)
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM currencies WHERE id = 7 AND tenant_id = "acme"$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectEvent(mock, entity.EventCurrencyDeleted, false)
+
+	repo := repository.NewMysqlCurrency(db)
+
+	if _, err := repo.GetCurrency(ctx, 7); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlCurrency.GetCurrency() error = %v, want Not Found", err)
+	}
+
+	list, _, err := repo.GetCurrencies(ctx, &request.CurrencyParameter{Limit: 10})
+	if err != nil || len(list) != 1 || list[0].TenantID != "acme" {
+		t.Errorf("mysqlCurrency.GetCurrencies() = %v, %v", list, err)
+	}
+
+	currency := entity.Currency{TenantID: "other", Name: "IDR"}
+	if err := repo.CreateCurrency(ctx, &currency); err != nil || currency.TenantID != "acme" {
+		t.Errorf("mysqlCurrency.CreateCurrency() tenant = %q, error = %v", currency.TenantID, err)
+	}
+
+	if err := repo.UpdateCurrency(ctx, 7, &entity.Currency{Name: "IDR"}); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlCurrency.UpdateCurrency() error = %v, want Not Found", err)
+	}
+
+	if err := repo.DeleteCurrency(ctx, 7); err == nil {
+		t.Errorf("mysqlCurrency.DeleteCurrency() error = nil, want error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_mysqlCurrency_GetCurrenciesByIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	scope := regexp.QuoteMeta(`tenant_id = '' AND id IN (3, 5, 8)`)
+	columns := []string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}
+
+	mock.ExpectQuery("^SELECT COUNT(.+) WHERE " + scope + "$").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(2))
+	mock.ExpectQuery("^SELECT id(.+) WHERE " + scope + " ORDER BY id LIMIT 0, 3$").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, "", "IDR", 1, time.Time{}, time.Time{}).
+			AddRow(8, "", "USD", 1, time.Time{}, time.Time{}))
+
+	repo := repository.NewMysqlCurrency(db)
+
+	list, total, err := repo.GetCurrencies(context.TODO(), &request.CurrencyParameter{Limit: 3, IDs: []int64{3, 5, 8}})
+	if err != nil || len(list) != 2 || total != 2 {
+		t.Errorf("mysqlCurrency.GetCurrencies() = %v, %d, %v", list, total, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_mysqlCurrency_GetCurrenciesByName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}
+
+	// the query is bound, not spliced into the SQL, and its wildcards only match themselves
+	mock.ExpectQuery("^SELECT COUNT(.+)").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+	mock.ExpectQuery("^SELECT id(.+) WHERE tenant_id = '' AND name LIKE \\? ORDER BY id LIMIT 0, 10$").
+		WithArgs(`%I\_R\%' OR '1'='1\\%`).WillReturnRows(sqlmock.NewRows(columns))
+
+	repo := repository.NewMysqlCurrency(db)
+
+	list, _, err := repo.GetCurrencies(context.TODO(), &request.CurrencyParameter{Limit: 10, Query: `I_R%' OR '1'='1\`})
+	if err != nil || len(list) != 0 {
+		t.Errorf("mysqlCurrency.GetCurrencies() = %v, %v", list, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
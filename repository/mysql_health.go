@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Schema lists the tables of db/whim_development.sql the application needs, with their columns,
+// so a database missing a migration of db/migrations is reported
+var Schema = []struct {
+	Table   string
+	Columns []string
+}{
+	{"conversions", []string{"id", "tenant_id", "currency_id_from", "currency_id_to", "rate", "version", "created_at", "updated_at"}},
+	{"currencies", []string{"id", "tenant_id", "name", "version", "created_at", "updated_at"}},
+	{"quotes", []string{"id", "tenant_id", "conversion_id", "currency_id_from", "currency_id_to", "rate", "amount", "result", "client_id", "expires_at", "executed_at", "created_at", "updated_at"}},
+	{"convert_currencies", []string{"id", "tenant_id", "conversion_id", "quote_id", "currency_id_from", "currency_id_to", "amount", "rate", "result", "client_id", "idempotency_key", "created_at"}},
+	{"idempotency_keys", []string{"idempotency_key", "tenant_id", "client_id", "request_hash", "response_status", "response_headers", "response_body", "created_at", "updated_at"}},
+	{"api_keys", []string{"id", "name", "client_id", "tenant_id", "role", "prefix", "key_hash", "revoked_at", "created_at", "updated_at"}},
+	{"conversion_quotas", []string{"tenant_id", "client_id", "monthly_conversions", "created_at", "updated_at"}},
+	{"conversion_quota_usages", []string{"tenant_id", "client_id", "period", "used", "created_at", "updated_at"}},
+	{"webhook_subscriptions", []string{"id", "tenant_id", "url", "secret", "pairs", "created_at", "updated_at"}},
+	{"webhook_deliveries", []string{"id", "subscription_id", "tenant_id", "event_id", "event_type", "payload", "status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error", "locked_by", "locked_until", "created_at", "updated_at"}},
+	{"outbox_events", []string{"id", "event_type", "aggregate_type", "aggregate_id", "tenant_id", "payload", "occurred_at", "published_at", "locked_by", "locked_until"}},
+}
+
+type mysqlHealth struct {
+	db *tracedDB
+}
+
+type HealthRepo interface {
+	Ping(ctx context.Context) error
+	MissingSchema(ctx context.Context) ([]string, error)
+	LatestRateUpdate(ctx context.Context) (time.Time, error)
+}
+
+//NewMysqlHealth is a function to create implementation of mysql Health repository
+func NewMysqlHealth(db *sql.DB) HealthRepo {
+	return &mysqlHealth{traced(db)}
+}
+
+func (t *mysqlHealth) Ping(ctx context.Context) error {
+	return t.db.PingContext(ctx)
+}
+
+// MissingSchema returns the tables of Schema not found in the current database, and the
+// columns, as table.column, not found in the tables that exist
+func (t *mysqlHealth) MissingSchema(ctx context.Context) ([]string, error) {
+	rows, err := t.db.QueryContext(ctx, "SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = DATABASE()")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := map[string]map[string]bool{}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		if found[table] == nil {
+			found[table] = map[string]bool{}
+		}
+		found[table][column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, table := range Schema {
+		columns, ok := found[table.Table]
+		if !ok {
+			missing = append(missing, table.Table)
+			continue
+		}
+		for _, column := range table.Columns {
+			if !columns[column] {
+				missing = append(missing, table.Table+"."+column)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// LatestRateUpdate returns when a conversion rate was last created or updated, of any tenant,
+// it returns the zero time when there is no rate
+func (t *mysqlHealth) LatestRateUpdate(ctx context.Context) (time.Time, error) {
+	var updatedAt sql.NullTime
+	if err := t.db.QueryRowContext(ctx, "SELECT MAX(updated_at) FROM conversions").Scan(&updatedAt); err != nil {
+		return time.Time{}, err
+	}
+	return updatedAt.Time, nil
+}
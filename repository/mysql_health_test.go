@@ -0,0 +1,68 @@
+package repository_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+func Test_mysqlHealth_MissingSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// quotes and api_keys were not created, and currencies predates the version column
+	rows := sqlmock.NewRows([]string{"table_name", "column_name"})
+	for _, table := range repository.Schema {
+		if table.Table == "quotes" || table.Table == "api_keys" {
+			continue
+		}
+		for _, column := range table.Columns {
+			if table.Table != "currencies" || column != "version" {
+				rows.AddRow(table.Table, column)
+			}
+		}
+	}
+	mock.ExpectQuery("^SELECT table_name, column_name FROM information_schema.columns").WillReturnRows(rows)
+
+	missing, err := repository.NewMysqlHealth(db).MissingSchema(context.TODO())
+	if err != nil {
+		t.Fatalf("mysqlHealth.MissingSchema() error = %v", err)
+	}
+	if want := []string{"currencies.version", "quotes", "api_keys"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("mysqlHealth.MissingSchema() = %v, want %v", missing, want)
+	}
+}
+
+func Test_mysqlHealth_LatestRateUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	updatedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery(`^SELECT MAX\(updated_at\) FROM conversions$`).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updatedAt))
+	mock.ExpectQuery(`^SELECT MAX\(updated_at\) FROM conversions$`).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(nil))
+
+	repo := repository.NewMysqlHealth(db)
+
+	got, err := repo.LatestRateUpdate(context.TODO())
+	if err != nil || !got.Equal(updatedAt) {
+		t.Errorf("mysqlHealth.LatestRateUpdate() = %v, %v, want %v", got, err, updatedAt)
+	}
+
+	got, err = repo.LatestRateUpdate(context.TODO())
+	if err != nil || !got.IsZero() {
+		t.Errorf("mysqlHealth.LatestRateUpdate() = %v, %v, want zero time", got, err)
+	}
+}
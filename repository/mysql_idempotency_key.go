@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+type mysqlIdempotencyKey struct {
+	db *tracedDB
+}
+
+type IdempotencyKeyRepo interface {
+	CreateIdempotencyKey(ctx context.Context, ek *entity.IdempotencyKey) error
+	UpdateIdempotencyKey(ctx context.Context, ek *entity.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, clientID, key string) error
+	GetIdempotencyKey(ctx context.Context, clientID, key string) (*entity.IdempotencyKey, error)
+}
+
+//NewMysqlIdempotencyKey is a function to create implementation of mysql IdempotencyKey repository.
+func NewMysqlIdempotencyKey(db *sql.DB) IdempotencyKeyRepo {
+	return &mysqlIdempotencyKey{traced(db)}
+}
+
+func (t *mysqlIdempotencyKey) GetIdempotencyKey(ctx context.Context, clientID, key string) (*entity.IdempotencyKey, error) {
+	query := `SELECT idempotency_key, tenant_id, client_id, request_hash, response_status, response_headers, response_body, updated_at, created_at
+						  FROM idempotency_keys WHERE tenant_id = ? AND client_id = ? AND idempotency_key = ?`
+
+	ek := entity.IdempotencyKey{}
+	var headers []byte
+	err := t.db.QueryRowContext(ctx, query, request.TenantID(ctx), clientID, key).Scan(
+		&ek.Key,
+		&ek.TenantID,
+		&ek.ClientID,
+		&ek.RequestHash,
+		&ek.ResponseStatus,
+		&headers,
+		&ek.ResponseBody,
+		&ek.UpdatedAt,
+		&ek.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("Not Found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &ek.ResponseHeaders); err != nil {
+			return nil, err
+		}
+	}
+	return &ek, nil
+}
+
+func (t *mysqlIdempotencyKey) CreateIdempotencyKey(ctx context.Context, ek *entity.IdempotencyKey) error {
+	query := `INSERT INTO idempotency_keys (idempotency_key, tenant_id, client_id, request_hash, response_status, response_body, updated_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	ek.TenantID = request.TenantID(ctx)
+	_, err := t.db.ExecContext(ctx, query,
+		ek.Key,
+		ek.TenantID,
+		ek.ClientID,
+		ek.RequestHash,
+		ek.ResponseStatus,
+		ek.ResponseBody,
+		sqlTime(ek.UpdatedAt),
+		sqlTime(ek.CreatedAt),
+	)
+
+	return err
+}
+
+func (t *mysqlIdempotencyKey) UpdateIdempotencyKey(ctx context.Context, ek *entity.IdempotencyKey) error {
+	query := `UPDATE idempotency_keys set response_status=?, response_headers=?, response_body=?, updated_at=? WHERE tenant_id = ? AND client_id = ? AND idempotency_key = ?`
+
+	var headers []byte
+	if ek.ResponseHeaders != nil {
+		b, err := json.Marshal(ek.ResponseHeaders)
+		if err != nil {
+			return err
+		}
+		headers = b
+	}
+
+	res, err := t.db.ExecContext(ctx, query, ek.ResponseStatus, headers, ek.ResponseBody, sqlTime(ek.UpdatedAt), request.TenantID(ctx), ek.ClientID, ek.Key)
+	if err != nil {
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affect == 0 {
+		err = fmt.Errorf("Not Found")
+
+		return err
+	}
+
+	return nil
+}
+
+func (t *mysqlIdempotencyKey) DeleteIdempotencyKey(ctx context.Context, clientID, key string) error {
+	query := "DELETE FROM idempotency_keys WHERE tenant_id = ? AND client_id = ? AND idempotency_key = ?"
+
+	_, err := t.db.ExecContext(ctx, query, request.TenantID(ctx), clientID, key)
+
+	return err
+}
@@ -0,0 +1,147 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+func Test_mysqlIdempotencyKey_GetIdempotencyKey(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	sample := entity.IdempotencyKey{
+		Key:             "key-1",
+		TenantID:        "acme",
+		ClientID:        "client-1",
+		RequestHash:     "hash",
+		ResponseStatus:  201,
+		ResponseHeaders: map[string][]string{"Content-Type": {"application/json"}, "Location": {"/v1/conversions/4"}},
+		ResponseBody:    []byte(`{"data":{}}`),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	tests := []struct {
+		name        string
+		want        *entity.IdempotencyKey
+		returnQuery error
+		wantErr     bool
+	}{
+		{
+			name: "found",
+			want: &sample,
+		},
+		{
+			name:        "not found",
+			returnQuery: sql.ErrNoRows,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			prep := mock.ExpectQuery("^SELECT idempotency_key(.+)").WithArgs("acme", "client-1", "key-1")
+			if tt.returnQuery != nil {
+				prep.WillReturnError(tt.returnQuery)
+			} else {
+				prep.WillReturnRows(sqlmock.NewRows([]string{"idempotency_key", "tenant_id", "client_id", "request_hash", "response_status", "response_headers", "response_body", "updated_at", "created_at"}).
+					AddRow(tt.want.Key, tt.want.TenantID, tt.want.ClientID, tt.want.RequestHash, tt.want.ResponseStatus,
+						[]byte(`{"Content-Type":["application/json"],"Location":["/v1/conversions/4"]}`), tt.want.ResponseBody, tt.want.UpdatedAt, tt.want.CreatedAt))
+			}
+
+			repo := repository.NewMysqlIdempotencyKey(db)
+			got, err := repo.GetIdempotencyKey(request.WithTenantID(context.TODO(), "acme"), "client-1", "key-1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mysqlIdempotencyKey.GetIdempotencyKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mysqlIdempotencyKey.GetIdempotencyKey() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_mysqlIdempotencyKey_CreateAndUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ek := &entity.IdempotencyKey{Key: "key-1", ClientID: "client-1", RequestHash: "hash"}
+
+	mock.ExpectExec("^INSERT INTO idempotency_keys(.+)").
+		WithArgs("key-1", "acme", "client-1", "hash", 0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE idempotency_keys(.+)").
+		WithArgs(201, []byte(`{"Location":["/v1/conversions/4"]}`), []byte(`{}`), sqlmock.AnyArg(), "acme", "client-1", "key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE idempotency_keys(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewMysqlIdempotencyKey(db)
+	ctx := request.WithTenantID(context.TODO(), "acme")
+	if err := repo.CreateIdempotencyKey(ctx, ek); err != nil || ek.TenantID != "acme" {
+		t.Errorf("mysqlIdempotencyKey.CreateIdempotencyKey() error = %v, tenant = %q", err, ek.TenantID)
+	}
+
+	ek.ResponseStatus = 201
+	ek.ResponseHeaders = map[string][]string{"Location": {"/v1/conversions/4"}}
+	ek.ResponseBody = []byte(`{}`)
+	if err := repo.UpdateIdempotencyKey(ctx, ek); err != nil {
+		t.Errorf("mysqlIdempotencyKey.UpdateIdempotencyKey() error = %v", err)
+	}
+	if err := repo.UpdateIdempotencyKey(ctx, ek); err == nil {
+		t.Errorf("mysqlIdempotencyKey.UpdateIdempotencyKey() expected not found error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_mysqlIdempotencyKey_KeyOfAnotherTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// the key key-1 of the client pricing of acme is not the key key-1 of the client pricing of globex
+	mock.ExpectExec("^INSERT INTO idempotency_keys").WithArgs("key-1", "acme", "pricing", "hash", 0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("^SELECT idempotency_key(.+) WHERE tenant_id = \\? AND client_id = \\? AND idempotency_key = \\?").
+		WithArgs("globex", "pricing", "key-1").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("^DELETE FROM idempotency_keys WHERE tenant_id = \\? AND client_id = \\? AND idempotency_key = \\?").
+		WithArgs("globex", "pricing", "key-1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewMysqlIdempotencyKey(db)
+	acme := request.WithTenantID(context.TODO(), "acme")
+	globex := request.WithTenantID(context.TODO(), "globex")
+
+	if err := repo.CreateIdempotencyKey(acme, &entity.IdempotencyKey{Key: "key-1", ClientID: "pricing", RequestHash: "hash"}); err != nil {
+		t.Errorf("mysqlIdempotencyKey.CreateIdempotencyKey() error = %v", err)
+	}
+	if _, err := repo.GetIdempotencyKey(globex, "pricing", "key-1"); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlIdempotencyKey.GetIdempotencyKey() error = %v, want Not Found", err)
+	}
+	if err := repo.DeleteIdempotencyKey(globex, "pricing", "key-1"); err != nil {
+		t.Errorf("mysqlIdempotencyKey.DeleteIdempotencyKey() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+type mysqlOutbox struct {
+	db *tracedDB
+}
+
+type OutboxRepo interface {
+	ClaimEvents(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]entity.DomainEvent, error)
+	MarkPublished(ctx context.Context, ids []int64, now time.Time) error
+	PurgePublished(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+//NewMysqlOutbox is a function to create implementation of mysql Outbox repository.
+//The events are written by the currency, conversion and convert currencies repositories.
+func NewMysqlOutbox(db *sql.DB) OutboxRepo {
+	return &mysqlOutbox{traced(db)}
+}
+
+// currencyChange is the payload of the currency events
+type currencyChange struct {
+	ID        int64     `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	Name      string    `json:"name,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// rateChange is the payload of the rate events
+type rateChange struct {
+	ID             int64     `json:"id"`
+	TenantID       string    `json:"tenant_id"`
+	CurrencyIDFrom int64     `json:"currency_id_from,omitempty"`
+	CurrencyIDTo   int64     `json:"currency_id_to,omitempty"`
+	Rate           float64   `json:"rate,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// appendEvent records an event of the aggregate id in the outbox, ex must be the transaction
+// making the change so the event is only recorded when the change is committed
+func appendEvent(ctx context.Context, ex executor, typ, aggregateType string, id int64, tenantID string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO outbox_events (event_type, aggregate_type, aggregate_id, tenant_id, payload, occurred_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = ex.ExecContext(ctx, query, typ, aggregateType, id, tenantID, b, sqlTime(time.Now().UTC()))
+	return err
+}
+
+// ClaimEvents locks up to limit unpublished events, oldest first, for owner until the lease
+// expires, so several relays can publish the outbox without handing them the same events. The
+// claim is committed before the events are returned, and the events of an owner that stopped
+// before marking them published are claimed again once their lease expired.
+func (t *mysqlOutbox) ClaimEvents(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]entity.DomainEvent, error) {
+	query := `UPDATE outbox_events SET locked_by = ?, locked_until = ?
+						WHERE published_at IS NULL AND (locked_until IS NULL OR locked_until < ?) ORDER BY id LIMIT ?`
+
+	res, err := t.db.ExecContext(ctx, query, owner, sqlTime(now.Add(lease)), sqlTime(now), limit)
+	if err != nil {
+		return nil, err
+	}
+	if affect, err := res.RowsAffected(); err != nil || affect == 0 {
+		return []entity.DomainEvent{}, err
+	}
+
+	query = `SELECT id, event_type, aggregate_type, aggregate_id, tenant_id, payload, occurred_at, published_at
+						FROM outbox_events WHERE locked_by = ? AND published_at IS NULL ORDER BY id`
+
+	rows, err := t.db.QueryContext(ctx, query, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]entity.DomainEvent, 0)
+	for rows.Next() {
+		e := entity.DomainEvent{}
+		var payload []byte
+		err = rows.Scan(
+			&e.ID,
+			&e.Type,
+			&e.AggregateType,
+			&e.AggregateID,
+			&e.TenantID,
+			&payload,
+			&e.OccurredAt,
+			&e.PublishedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// MarkPublished records the events of ids as published and releases the claim on them
+func (t *mysqlOutbox) MarkPublished(ctx context.Context, ids []int64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := buildQuery("UPDATE outbox_events SET published_at = ?, locked_by = '', locked_until = NULL WHERE id IN (%s)", int64sToString(ids, ", "))
+	_, err := t.db.ExecContext(ctx, query, sqlTime(now))
+	return err
+}
+
+// PurgePublished deletes up to limit events published before before, oldest first, and returns
+// the number of events deleted
+func (t *mysqlOutbox) PurgePublished(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := "DELETE FROM outbox_events WHERE published_at < ? ORDER BY id LIMIT ?"
+
+	res, err := t.db.ExecContext(ctx, query, sqlTime(before), limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
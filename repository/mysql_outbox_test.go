@@ -0,0 +1,132 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+// expectEvent expects the end of the transaction of a repository write: the event recorded
+// in the outbox and the commit when the write succeeds, the rollback otherwise
+func expectEvent(mock sqlmock.Sqlmock, eventType string, ok bool) {
+	if !ok {
+		mock.ExpectRollback()
+		return
+	}
+	mock.ExpectExec("^INSERT INTO outbox_events").
+		WithArgs(eventType, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
+var outboxColumns = []string{"id", "event_type", "aggregate_type", "aggregate_id", "tenant_id", "payload", "occurred_at", "published_at"}
+
+func Test_mysqlOutbox_EventInWriteTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT INTO conversions").WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("^INSERT INTO outbox_events").
+		WithArgs(entity.EventRateCreated, "conversion", 4, "acme", []byte(`{"id":4,"tenant_id":"acme","currency_id_from":1,"currency_id_to":2,"rate":1.5,"updated_at":"0001-01-01T00:00:00Z"}`), sqlmock.AnyArg()).
+		WillReturnError(errors.New("outbox is full"))
+	mock.ExpectRollback()
+
+	conversion := entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5}
+	err = repository.NewMysqlConversion(db).CreateConversion(request.WithTenantID(context.TODO(), "acme"), &conversion)
+	if err == nil {
+		t.Errorf("mysqlConversion.CreateConversion() error = nil, want the error recording the event")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("the conversion is not rolled back with its event: %s", err)
+	}
+}
+
+func Test_mysqlOutbox_ClaimEvents(t *testing.T) {
+	now := time.Now().UTC()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// the claim is its own statement, committed before the events are handed to the publisher
+	mock.ExpectExec(`^UPDATE outbox_events SET locked_by = \?, locked_until = \? WHERE published_at IS NULL AND \(locked_until IS NULL OR locked_until < \?\) ORDER BY id LIMIT \?$`).
+		WithArgs("relay-1", sqlmock.AnyArg(), sqlmock.AnyArg(), 10).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`FROM outbox_events WHERE locked_by = \? AND published_at IS NULL ORDER BY id$`).WithArgs("relay-1").
+		WillReturnRows(sqlmock.NewRows(outboxColumns).
+			AddRow(1, entity.EventCurrencyCreated, "currency", 3, "", []byte(`{"id":3}`), now, nil).
+			AddRow(2, entity.EventRateUpdated, "conversion", 4, "acme", []byte(`{"id":4}`), now, nil))
+	mock.ExpectExec(`^UPDATE outbox_events SET published_at = \?, locked_by = '', locked_until = NULL WHERE id IN \(1, 2\)$`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	repo := repository.NewMysqlOutbox(db)
+	events, err := repo.ClaimEvents(context.TODO(), "relay-1", now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("mysqlOutbox.ClaimEvents() error = %v", err)
+	}
+	if len(events) != 2 || events[0].ID != 1 || string(events[1].Payload) != `{"id":4}` || events[1].TenantID != "acme" {
+		t.Errorf("mysqlOutbox.ClaimEvents() = %+v", events)
+	}
+
+	if err := repo.MarkPublished(context.TODO(), []int64{1, 2}, now); err != nil {
+		t.Errorf("mysqlOutbox.MarkPublished() error = %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_mysqlOutbox_ClaimEventsEmpty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("^UPDATE outbox_events").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	events, err := repository.NewMysqlOutbox(db).ClaimEvents(context.TODO(), "relay-1", time.Now().UTC(), time.Minute, 10)
+	if err != nil || len(events) != 0 {
+		t.Errorf("mysqlOutbox.ClaimEvents() = %v, %v, want none", events, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("the events are read without any claimed: %s", err)
+	}
+}
+
+func Test_mysqlOutbox_PurgePublished(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	before := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	// the unpublished events, published_at being NULL, are kept
+	mock.ExpectExec(`^DELETE FROM outbox_events WHERE published_at < \? ORDER BY id LIMIT \?$`).
+		WithArgs("2026-10-12 00:00:00", 1000).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := repository.NewMysqlOutbox(db).PurgePublished(context.TODO(), before, 1000)
+	if err != nil || n != 3 {
+		t.Errorf("mysqlOutbox.PurgePublished() = %d, %v, want 3", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
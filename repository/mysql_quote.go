@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+type mysqlQuote struct {
+	db *tracedDB
+}
+
+type QuoteRepo interface {
+	CreateQuote(ctx context.Context, eq *entity.Quote) error
+	GetQuote(ctx context.Context, id int64) (*entity.Quote, error)
+	ExecuteQuote(ctx context.Context, id int64, executedAt time.Time) error
+}
+
+//NewMysqlQuote is a function to create implementation of mysql Quote repository
+func NewMysqlQuote(db *sql.DB) QuoteRepo {
+	return &mysqlQuote{traced(db)}
+}
+
+func (t *mysqlQuote) fetch(ctx context.Context, query string) ([]entity.Quote, error) {
+	rows, err := t.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]entity.Quote, 0)
+	for rows.Next() {
+		q := entity.Quote{}
+		err = rows.Scan(
+			&q.ID,
+			&q.TenantID,
+			&q.ConversionID,
+			&q.CurrencyIDFrom,
+			&q.CurrencyIDTo,
+			&q.Rate,
+			&q.Amount,
+			&q.Result,
+			&q.ClientID,
+			&q.ExpiresAt,
+			&q.ExecutedAt,
+			&q.UpdatedAt,
+			&q.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, q)
+	}
+
+	return result, nil
+}
+
+func (t *mysqlQuote) GetQuote(ctx context.Context, id int64) (*entity.Quote, error) {
+	query := `SELECT id, tenant_id, conversion_id, currency_id_from, currency_id_to, rate, amount, result, client_id, expires_at, executed_at, updated_at, created_at
+						  FROM quotes WHERE id = %d`
+
+	list, err := t.fetch(ctx, buildQuery(query, id))
+	if err == sql.ErrNoRows || len(list) == 0 {
+		return nil, fmt.Errorf("Not Found")
+	}
+
+	return &list[0], nil
+}
+
+func (t *mysqlQuote) CreateQuote(ctx context.Context, quote *entity.Quote) error {
+	query := `INSERT INTO quotes (tenant_id, conversion_id, currency_id_from, currency_id_to, rate, amount, result, client_id, expires_at, updated_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := t.db.ExecContext(ctx, query,
+		quote.TenantID,
+		quote.ConversionID,
+		quote.CurrencyIDFrom,
+		quote.CurrencyIDTo,
+		quote.Rate,
+		quote.Amount,
+		quote.Result,
+		quote.ClientID,
+		sqlTime(quote.ExpiresAt),
+		sqlTime(quote.UpdatedAt),
+		sqlTime(quote.CreatedAt),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	lastID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	quote.ID = lastID
+	return nil
+}
+
+// ExecuteQuote marks the quote as executed. The update only matches a quote that
+// has not been executed yet and is still valid at executedAt, so a quote can be
+// executed at most once even under concurrent requests.
+func (t *mysqlQuote) ExecuteQuote(ctx context.Context, id int64, executedAt time.Time) error {
+	query := `UPDATE quotes set executed_at=%q, updated_at=%q WHERE id = %d AND executed_at IS NULL AND expires_at > %q`
+
+	res, err := t.db.ExecContext(ctx, buildQuery(query, sqlTime(executedAt), sqlTime(executedAt), id, sqlTime(executedAt)))
+	if err != nil {
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affect == 0 {
+		err = fmt.Errorf("Conflict: quote is expired or has already been executed")
+
+		return err
+	}
+
+	if affect != 1 {
+		err = fmt.Errorf("weird  behaviour. total affected: %d", affect)
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bxcodec/faker"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+func Test_mysqlQuote_GetQuote(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  int64
+	}
+	sampleQuote := entity.Quote{}
+	err := faker.FakeData(&sampleQuote)
+	if err != nil {
+		fmt.Println(err)
+	}
+	sampleQuote.ExecutedAt = nil
+
+	tests := []struct {
+		name        string
+		args        args
+		want        *entity.Quote
+		returnQuery error
+		wantErr     bool
+	}{
+		{
+			name:    "ID 1",
+			args:    args{context.TODO(), sampleQuote.ID},
+			want:    &sampleQuote,
+			wantErr: false,
+		},
+		{
+			name:        "not found error",
+			args:        args{context.TODO(), 1},
+			returnQuery: sql.ErrNoRows,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows *sqlmock.Rows
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+			if tt.want != nil {
+				rows = sqlmock.NewRows([]string{"id", "tenant_id", "conversion_id", "currency_id_from", "currency_id_to", "rate", "amount", "result", "client_id", "expires_at", "executed_at", "updated_at", "created_at"}).
+					AddRow(tt.want.ID, tt.want.TenantID, tt.want.ConversionID, tt.want.CurrencyIDFrom, tt.want.CurrencyIDTo, tt.want.Rate, tt.want.Amount, tt.want.Result, tt.want.ClientID, tt.want.ExpiresAt, nil, tt.want.UpdatedAt, tt.want.CreatedAt)
+			}
+
+			if tt.returnQuery != nil {
+				mock.ExpectQuery("^SELECT id(.+)").WillReturnError(tt.returnQuery)
+			} else {
+				mock.ExpectQuery("^SELECT id(.+)").WillReturnRows(rows)
+			}
+
+			repo := repository.NewMysqlQuote(db)
+
+			got, err := repo.GetQuote(tt.args.ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mysqlQuote.GetQuote() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mysqlQuote.GetQuote() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_mysqlQuote_CreateQuote(t *testing.T) {
+	sampleQuote := entity.Quote{}
+	err := faker.FakeData(&sampleQuote)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	tests := []struct {
+		name      string
+		returnErr error
+		wantErr   bool
+	}{
+		{
+			name:    "insert ok",
+			wantErr: false,
+		},
+		{
+			name:      "insert failed",
+			returnErr: errors.New("fail insert"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+			prep := mock.ExpectExec("^INSERT INTO quotes(.+)")
+			if tt.returnErr != nil {
+				prep.WillReturnError(tt.returnErr)
+			} else {
+				prep.WillReturnResult(sqlmock.NewResult(2, 1))
+			}
+
+			repo := repository.NewMysqlQuote(db)
+			if err := repo.CreateQuote(context.TODO(), &sampleQuote); (err != nil) != tt.wantErr {
+				t.Errorf("mysqlQuote.CreateQuote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_mysqlQuote_ExecuteQuote(t *testing.T) {
+	tests := []struct {
+		name         string
+		returnErr    error
+		rowsAffected int64
+		wantErr      bool
+	}{
+		{
+			name:         "execute ok",
+			rowsAffected: 1,
+			wantErr:      false,
+		},
+		{
+			name:         "already executed or expired",
+			rowsAffected: 0,
+			wantErr:      true,
+		},
+		{
+			name:      "execute fail",
+			returnErr: errors.New("update fail"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			prep := mock.ExpectExec("^UPDATE quotes (.+) executed_at IS NULL AND expires_at > (.+)")
+
+			if tt.returnErr != nil {
+				prep.WillReturnError(tt.returnErr)
+			} else {
+				prep.WillReturnResult(sqlmock.NewResult(2, tt.rowsAffected))
+			}
+
+			repo := repository.NewMysqlQuote(db)
+			if err := repo.ExecuteQuote(context.TODO(), 1, time.Now()); (err != nil) != tt.wantErr {
+				t.Errorf("mysqlQuote.ExecuteQuote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
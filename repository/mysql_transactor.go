@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// txAttempts is the number of times a transaction is run when it is aborted by a deadlock or
+// a lock wait timeout
+const txAttempts = 3
+
+// Transactor runs several repository operations atomically
+type Transactor interface {
+	// WithinTx runs fn in a transaction, committed when fn returns nil and rolled back when it
+	// returns an error or panics. The repositories of the same database run the statements of
+	// the ctx given to fn in the transaction. Called within a transaction, fn joins it.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type mysqlTransactor struct {
+	db *sql.DB
+}
+
+//NewMysqlTransactor is a function to create implementation of mysql Transactor.
+//The transactions are repeatable read, the repositories lock the rows a transaction depends on.
+//A transaction aborted by a deadlock or a lock wait timeout is run again, and is a conflict
+//once it failed txAttempts times.
+func NewMysqlTransactor(db *sql.DB) Transactor {
+	return &mysqlTransactor{db}
+}
+
+func (t *mysqlTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if txOf(ctx, t.db) != nil {
+		return fn(ctx)
+	}
+
+	var err error
+	for i := 0; i < txAttempts; i++ {
+		if err = t.run(ctx, fn); !lockFailed(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("Conflict: %w", err)
+}
+
+// run runs fn in one transaction
+func (t *mysqlTransactor) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	txCtx, b := withTx(ctx, t.db, tx)
+	if err := fn(txCtx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	b.committed()
+	return nil
+}
+
+// lockFailed reports whether err is a deadlock or a lock wait timeout, after which the
+// transaction is to be run again
+func lockFailed(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && (me.Number == 1213 || me.Number == 1205)
+}
@@ -0,0 +1,193 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+var currencyColumns = []string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}
+
+func Test_mysqlTransactor_WithinTx(t *testing.T) {
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		fn      func(ctx context.Context, currencies repository.CurrencyRepo, conversions repository.ConversionRepo) error
+		wantErr bool
+	}{
+		{
+			name: "committed",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				// the currency is locked until the conversion is committed
+				mock.ExpectQuery("FROM currencies WHERE id = 1 (.+) FOR UPDATE$").
+					WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow(1, "", "IDR", 1, time.Time{}, time.Time{}))
+				// the insert and its event join the transaction instead of beginning their own
+				mock.ExpectExec("^INSERT INTO conversions").WillReturnResult(sqlmock.NewResult(4, 1))
+				mock.ExpectExec("^INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			fn: func(ctx context.Context, currencies repository.CurrencyRepo, conversions repository.ConversionRepo) error {
+				if _, err := currencies.GetCurrency(ctx, 1); err != nil {
+					return err
+				}
+				return conversions.CreateConversion(ctx, &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5})
+			},
+		},
+		{
+			name: "rolled back on error",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("^INSERT INTO conversions").WillReturnResult(sqlmock.NewResult(4, 1))
+				mock.ExpectExec("^INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("FROM currencies WHERE id = 2").WillReturnRows(sqlmock.NewRows(currencyColumns))
+				mock.ExpectRollback()
+			},
+			fn: func(ctx context.Context, currencies repository.CurrencyRepo, conversions repository.ConversionRepo) error {
+				if err := conversions.CreateConversion(ctx, &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5}); err != nil {
+					return err
+				}
+				_, err := currencies.GetCurrency(ctx, 2)
+				return err
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			tt.expect(mock)
+
+			tx := repository.NewMysqlTransactor(db)
+			currencies := repository.NewMysqlCurrency(db)
+			conversions := repository.NewMysqlConversion(db)
+			err = tx.WithinTx(context.TODO(), func(ctx context.Context) error {
+				return tt.fn(ctx, currencies, conversions)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mysqlTransactor.WithinTx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_mysqlTransactor_WithinTxNested(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM conversions").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	tx := repository.NewMysqlTransactor(db)
+	conversions := repository.NewMysqlConversion(db)
+	err = tx.WithinTx(context.TODO(), func(ctx context.Context) error {
+		err := tx.WithinTx(ctx, func(ctx context.Context) error {
+			return conversions.DeleteConversion(ctx, 4)
+		})
+		if err != nil {
+			return err
+		}
+		return errors.New("failed after the nested transaction")
+	})
+	if err == nil {
+		t.Errorf("mysqlTransactor.WithinTx() error = nil")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("the nested transaction is not rolled back with the outer one: %s", err)
+	}
+}
+
+func Test_mysqlTransactor_WithinTxPanic(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("mysqlTransactor.WithinTx() recovered the panic of fn")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("the transaction is not rolled back: %s", err)
+		}
+	}()
+
+	repository.NewMysqlTransactor(db).WithinTx(context.TODO(), func(ctx context.Context) error {
+		panic("boom")
+	})
+}
+
+func Test_mysqlTransactor_WithinTxDeadlock(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	tests := []struct {
+		name     string
+		failures int
+		wantErr  string
+	}{
+		{name: "run again after a deadlock", failures: 2},
+		{name: "conflict after every attempt failed", failures: 3, wantErr: "Conflict: Error 1213: Deadlock found when trying to get lock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			for i := 0; i < tt.failures; i++ {
+				mock.ExpectBegin()
+				mock.ExpectExec("^INSERT INTO conversions").WillReturnError(deadlock)
+				mock.ExpectRollback()
+			}
+			if tt.wantErr == "" {
+				mock.ExpectBegin()
+				mock.ExpectExec("^INSERT INTO conversions").WillReturnResult(sqlmock.NewResult(4, 1))
+				mock.ExpectExec("^INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			}
+
+			conversions := repository.NewMysqlConversion(db)
+			err = repository.NewMysqlTransactor(db).WithinTx(context.TODO(), func(ctx context.Context) error {
+				return conversions.CreateConversion(ctx, &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5})
+			})
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("mysqlTransactor.WithinTx() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("mysqlTransactor.WithinTx() error = %v, want %s", err, tt.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+type mysqlWebhook struct {
+	db *tracedDB
+}
+
+type WebhookRepo interface {
+	CreateSubscription(ctx context.Context, ws *entity.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id int64) (*entity.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context, p *request.WebhookParameter) ([]entity.WebhookSubscription, int64, error)
+	DeleteSubscription(ctx context.Context, id int64, now time.Time) error
+	MatchSubscriptions(ctx context.Context, tenantID string, currencyIDFrom, currencyIDTo int64) ([]entity.WebhookSubscription, error)
+	CreateDeliveries(ctx context.Context, deliveries []entity.WebhookDelivery) error
+	ClaimDeliveries(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]entity.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, wd *entity.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, p *request.WebhookDeliveryParameter) ([]entity.WebhookDelivery, int64, error)
+	PurgeDeliveries(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+//NewMysqlWebhook is a function to create implementation of mysql Webhook repository.
+//Subscriptions belong to the tenant of the caller only, the global rates they receive are not shared rows.
+func NewMysqlWebhook(db *sql.DB) WebhookRepo {
+	return &mysqlWebhook{traced(db)}
+}
+
+const webhookSubscriptionColumns = "id, tenant_id, url, secret, pairs, updated_at, created_at"
+
+const webhookDeliveryColumns = `id, subscription_id, tenant_id, event_id, event_type, payload, status, attempts,
+						next_attempt_at, last_attempt_at, response_status, last_error, updated_at, created_at`
+
+func (t *mysqlWebhook) fetchSubscriptions(ctx context.Context, query string, args ...interface{}) ([]entity.WebhookSubscription, error) {
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]entity.WebhookSubscription, 0)
+	for rows.Next() {
+		s := entity.WebhookSubscription{}
+		err = rows.Scan(
+			&s.ID,
+			&s.TenantID,
+			&s.URL,
+			&s.Secret,
+			&s.Pairs,
+			&s.UpdatedAt,
+			&s.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+
+	return result, nil
+}
+
+func (t *mysqlWebhook) fetchDeliveries(ctx context.Context, query string, args ...interface{}) ([]entity.WebhookDelivery, error) {
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]entity.WebhookDelivery, 0)
+	for rows.Next() {
+		d := entity.WebhookDelivery{}
+		var payload []byte
+		err = rows.Scan(
+			&d.ID,
+			&d.SubscriptionID,
+			&d.TenantID,
+			&d.EventID,
+			&d.EventType,
+			&payload,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.LastAttemptAt,
+			&d.ResponseStatus,
+			&d.LastError,
+			&d.UpdatedAt,
+			&d.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		result = append(result, d)
+	}
+
+	return result, nil
+}
+
+func (t *mysqlWebhook) CreateSubscription(ctx context.Context, ws *entity.WebhookSubscription) error {
+	query := `INSERT INTO webhook_subscriptions (tenant_id, url, secret, pairs, updated_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	res, err := t.db.ExecContext(ctx, query,
+		request.TenantID(ctx),
+		ws.URL,
+		ws.Secret,
+		ws.Pairs,
+		sqlTime(ws.UpdatedAt),
+		sqlTime(ws.CreatedAt),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	lastID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	ws.ID = lastID
+	ws.TenantID = request.TenantID(ctx)
+	return nil
+}
+
+func (t *mysqlWebhook) GetSubscription(ctx context.Context, id int64) (*entity.WebhookSubscription, error) {
+	query := "SELECT " + webhookSubscriptionColumns + " FROM webhook_subscriptions WHERE id = ? AND tenant_id = ?"
+
+	list, err := t.fetchSubscriptions(ctx, query, id, request.TenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("Not Found")
+	}
+
+	return &list[0], nil
+}
+
+func (t *mysqlWebhook) GetSubscriptions(ctx context.Context, p *request.WebhookParameter) ([]entity.WebhookSubscription, int64, error) {
+	var total int64
+
+	tenantID := request.TenantID(ctx)
+	err := t.db.QueryRowContext(ctx, "SELECT COUNT(id) FROM webhook_subscriptions WHERE tenant_id = ?", tenantID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT " + webhookSubscriptionColumns + " FROM webhook_subscriptions WHERE tenant_id = ? ORDER BY id LIMIT ?, ?"
+
+	result, err := t.fetchSubscriptions(ctx, query, tenantID, p.Offset, p.Limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, total, nil
+}
+
+// DeleteSubscription removes a subscription, its pending deliveries are failed but kept in the delivery log
+func (t *mysqlWebhook) DeleteSubscription(ctx context.Context, id int64, now time.Time) error {
+	res, err := t.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = ? AND tenant_id = ?", id, request.TenantID(ctx))
+	if err != nil {
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affect == 0 {
+		return fmt.Errorf("Not Found")
+	}
+
+	query := `UPDATE webhook_deliveries SET status = ?, last_error = ?, locked_by = '', locked_until = NULL, updated_at = ?
+						WHERE subscription_id = ? AND status = ?`
+	_, err = t.db.ExecContext(ctx, query, entity.DeliveryFailed, "subscription deleted", sqlTime(now), id, entity.DeliveryPending)
+	return err
+}
+
+// MatchSubscriptions returns the subscriptions receiving the events of tenantID on the rate of a
+// pair. The events of the global tenant are received by the subscriptions of every tenant that
+// has not overridden the rate of the pair, in either direction, like in effectiveRates.
+func (t *mysqlWebhook) MatchSubscriptions(ctx context.Context, tenantID string, currencyIDFrom, currencyIDTo int64) ([]entity.WebhookSubscription, error) {
+	query := "SELECT " + webhookSubscriptionColumns + " FROM webhook_subscriptions"
+	if tenantID == "" {
+		query += ` WHERE tenant_id = '' OR NOT EXISTS (
+								SELECT 1 FROM conversions o WHERE o.tenant_id = webhook_subscriptions.tenant_id AND (
+									(o.currency_id_from = ? AND o.currency_id_to = ?) OR
+									(o.currency_id_from = ? AND o.currency_id_to = ?)))`
+		return t.fetchSubscriptions(ctx, query, currencyIDFrom, currencyIDTo, currencyIDTo, currencyIDFrom)
+	}
+
+	return t.fetchSubscriptions(ctx, query+" WHERE tenant_id = ?", tenantID)
+}
+
+// CreateDeliveries queues the deliveries, a delivery of an event already queued for the same
+// subscription is skipped, so an event relayed again is not delivered twice
+func (t *mysqlWebhook) CreateDeliveries(ctx context.Context, deliveries []entity.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(deliveries))
+	args := make([]interface{}, 0, len(deliveries)*9)
+	for _, d := range deliveries {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args,
+			d.SubscriptionID,
+			d.TenantID,
+			d.EventID,
+			d.EventType,
+			[]byte(d.Payload),
+			d.Status,
+			sqlTime(d.NextAttemptAt),
+			sqlTime(d.UpdatedAt),
+			sqlTime(d.CreatedAt),
+		)
+	}
+
+	query := `INSERT IGNORE INTO webhook_deliveries (subscription_id, tenant_id, event_id, event_type, payload, status, next_attempt_at, updated_at, created_at)
+						VALUES ` + strings.Join(values, ", ")
+
+	_, err := t.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// ClaimDeliveries locks up to limit pending deliveries due at now for owner until the lease expires,
+// so several instances can dispatch the outbox without sending a delivery twice. The deliveries
+// of an owner that stopped before updating them are claimed again once their lease expired.
+func (t *mysqlWebhook) ClaimDeliveries(ctx context.Context, owner string, now time.Time, lease time.Duration, limit int) ([]entity.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries SET locked_by = ?, locked_until = ?
+						WHERE status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)
+						ORDER BY next_attempt_at LIMIT ?`
+
+	res, err := t.db.ExecContext(ctx, query, owner, sqlTime(now.Add(lease)), entity.DeliveryPending, sqlTime(now), sqlTime(now), limit)
+	if err != nil {
+		return nil, err
+	}
+	if affect, err := res.RowsAffected(); err != nil || affect == 0 {
+		return []entity.WebhookDelivery{}, err
+	}
+
+	query = "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE locked_by = ? AND status = ? ORDER BY next_attempt_at"
+	return t.fetchDeliveries(ctx, query, owner, entity.DeliveryPending)
+}
+
+// UpdateDelivery records the outcome of an attempt and releases the claim on the delivery
+func (t *mysqlWebhook) UpdateDelivery(ctx context.Context, wd *entity.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_attempt_at = ?, response_status = ?,
+						last_error = ?, locked_by = '', locked_until = NULL, updated_at = ? WHERE id = ?`
+
+	var lastAttemptAt interface{}
+	if wd.LastAttemptAt != nil {
+		lastAttemptAt = sqlTime(*wd.LastAttemptAt)
+	}
+
+	res, err := t.db.ExecContext(ctx, query,
+		wd.Status,
+		wd.Attempts,
+		sqlTime(wd.NextAttemptAt),
+		lastAttemptAt,
+		wd.ResponseStatus,
+		wd.LastError,
+		sqlTime(wd.UpdatedAt),
+		wd.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affect == 0 {
+		return fmt.Errorf("Not Found")
+	}
+
+	return nil
+}
+
+func (t *mysqlWebhook) GetDeliveries(ctx context.Context, p *request.WebhookDeliveryParameter) ([]entity.WebhookDelivery, int64, error) {
+	var total int64
+
+	where := "subscription_id = ? AND tenant_id = ?"
+	args := []interface{}{p.SubscriptionID, request.TenantID(ctx)}
+	if p.Status != "" {
+		where += " AND status = ?"
+		args = append(args, p.Status)
+	}
+
+	err := t.db.QueryRowContext(ctx, "SELECT COUNT(id) FROM webhook_deliveries WHERE "+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE " + where + " ORDER BY id DESC LIMIT ?, ?"
+
+	result, err := t.fetchDeliveries(ctx, query, append(args, p.Offset, p.Limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, total, nil
+}
+
+// PurgeDeliveries deletes up to limit delivered or failed deliveries last updated before before,
+// oldest first, and returns the number of deliveries deleted. The pending deliveries are kept.
+func (t *mysqlWebhook) PurgeDeliveries(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := "DELETE FROM webhook_deliveries WHERE status IN (?, ?) AND updated_at < ? ORDER BY id LIMIT ?"
+
+	res, err := t.db.ExecContext(ctx, query, entity.DeliveryDelivered, entity.DeliveryFailed, sqlTime(before), limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
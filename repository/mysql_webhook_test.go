@@ -0,0 +1,200 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+var webhookDeliveryColumns = []string{"id", "subscription_id", "tenant_id", "event_id", "event_type", "payload", "status", "attempts",
+	"next_attempt_at", "last_attempt_at", "response_status", "last_error", "updated_at", "created_at"}
+
+func Test_mysqlWebhook_CreateSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC()
+	mock.ExpectExec("^INSERT INTO webhook_subscriptions").
+		WithArgs("acme", "https://example.com/hooks", "whsec_secret", "1-2", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	ws := entity.WebhookSubscription{URL: "https://example.com/hooks", Secret: "whsec_secret", Pairs: "1-2", CreatedAt: now, UpdatedAt: now}
+	err = repository.NewMysqlWebhook(db).CreateSubscription(request.WithTenantID(context.TODO(), "acme"), &ws)
+	if err != nil {
+		t.Fatalf("mysqlWebhook.CreateSubscription() error = %v", err)
+	}
+	if ws.ID != 7 || ws.TenantID != "acme" {
+		t.Errorf("mysqlWebhook.CreateSubscription() = %+v, want id 7 of tenant acme", ws)
+	}
+}
+
+func Test_mysqlWebhook_MatchSubscriptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC()
+	columns := []string{"id", "tenant_id", "url", "secret", "pairs", "updated_at", "created_at"}
+	// the subscriptions of the tenants overriding the rate of the pair do not get its global changes
+	mock.ExpectQuery(`FROM webhook_subscriptions WHERE tenant_id = '' OR NOT EXISTS \((.+)o.tenant_id = webhook_subscriptions.tenant_id(.+)\)\)\)$`).
+		WithArgs(1, 2, 2, 1).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "", "https://a.example.com", "s1", "", now, now).
+			AddRow(2, "acme", "https://b.example.com", "s2", "1-2", now, now))
+	mock.ExpectQuery(`FROM webhook_subscriptions WHERE tenant_id = \?$`).WithArgs("acme").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "acme", "https://b.example.com", "s2", "1-2", now, now))
+
+	repo := repository.NewMysqlWebhook(db)
+
+	subs, err := repo.MatchSubscriptions(context.TODO(), "", 1, 2)
+	if err != nil || len(subs) != 2 {
+		t.Errorf("mysqlWebhook.MatchSubscriptions() of a global event = %v, %v, want the subscriptions of the tenants without an override", subs, err)
+	}
+
+	subs, err = repo.MatchSubscriptions(context.TODO(), "acme", 1, 2)
+	if err != nil || len(subs) != 1 || subs[0].Secret != "s2" {
+		t.Errorf("mysqlWebhook.MatchSubscriptions() of a tenant event = %v, %v, want the tenant subscription", subs, err)
+	}
+}
+
+func Test_mysqlWebhook_CreateDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// an event relayed again is not queued twice for the same subscription
+	now := time.Now().UTC()
+	mock.ExpectExec(`^INSERT IGNORE INTO webhook_deliveries (.+) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?, \?\), \(\?, \?, \?, \?, \?, \?, \?, \?, \?\)$`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deliveries := []entity.WebhookDelivery{
+		{SubscriptionID: 1, TenantID: "acme", EventID: "8", EventType: entity.EventRateUpdated, Payload: []byte(`{}`), Status: entity.DeliveryPending, NextAttemptAt: now},
+		{SubscriptionID: 2, TenantID: "acme", EventID: "8", EventType: entity.EventRateUpdated, Payload: []byte(`{}`), Status: entity.DeliveryPending, NextAttemptAt: now},
+	}
+	if err := repository.NewMysqlWebhook(db).CreateDeliveries(context.TODO(), deliveries); err != nil {
+		t.Errorf("mysqlWebhook.CreateDeliveries() error = %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_mysqlWebhook_ClaimDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC()
+	mock.ExpectExec(`^UPDATE webhook_deliveries SET locked_by = \?, locked_until = \?(.+)LIMIT \?$`).
+		WithArgs("owner", sqlmock.AnyArg(), entity.DeliveryPending, sqlmock.AnyArg(), sqlmock.AnyArg(), 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`FROM webhook_deliveries WHERE locked_by = \?`).WithArgs("owner", entity.DeliveryPending).
+		WillReturnRows(sqlmock.NewRows(webhookDeliveryColumns).
+			AddRow(3, 1, "acme", "e1", "rate.updated", []byte(`{"id":"e1"}`), "pending", 0, now, nil, 0, "", now, now))
+	mock.ExpectExec("^UPDATE webhook_deliveries SET locked_by").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewMysqlWebhook(db)
+
+	deliveries, err := repo.ClaimDeliveries(context.TODO(), "owner", now, time.Minute, 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("mysqlWebhook.ClaimDeliveries() = %v, %v, want one delivery", deliveries, err)
+	}
+	if string(deliveries[0].Payload) != `{"id":"e1"}` || deliveries[0].LastAttemptAt != nil {
+		t.Errorf("mysqlWebhook.ClaimDeliveries() = %+v", deliveries[0])
+	}
+
+	deliveries, err = repo.ClaimDeliveries(context.TODO(), "owner", now, time.Minute, 10)
+	if err != nil || len(deliveries) != 0 {
+		t.Errorf("mysqlWebhook.ClaimDeliveries() = %v, %v, want none when no delivery is due", deliveries, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_mysqlWebhook_DeleteSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("^DELETE FROM webhook_subscriptions").WithArgs(1, "acme").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE webhook_deliveries SET status").
+		WithArgs(entity.DeliveryFailed, "subscription deleted", sqlmock.AnyArg(), 1, entity.DeliveryPending).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("^DELETE FROM webhook_subscriptions").WithArgs(2, "acme").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewMysqlWebhook(db)
+	ctx := request.WithTenantID(context.TODO(), "acme")
+
+	if err := repo.DeleteSubscription(ctx, 1, time.Now()); err != nil {
+		t.Errorf("mysqlWebhook.DeleteSubscription() error = %v", err)
+	}
+	if err := repo.DeleteSubscription(ctx, 2, time.Now()); err == nil || err.Error() != "Not Found" {
+		t.Errorf("mysqlWebhook.DeleteSubscription() error = %v, want Not Found", err)
+	}
+}
+
+func Test_mysqlWebhook_GetDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC()
+	mock.ExpectQuery(`^SELECT COUNT\(id\) FROM webhook_deliveries WHERE subscription_id = \? AND tenant_id = \? AND status = \?$`).
+		WithArgs(1, "acme", "failed").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`ORDER BY id DESC LIMIT \?, \?$`).WithArgs(1, "acme", "failed", 0, 10).
+		WillReturnRows(sqlmock.NewRows(webhookDeliveryColumns).
+			AddRow(3, 1, "acme", "e1", "rate.updated", []byte(`{}`), "failed", 8, now, now, 500, "unexpected response status 500", now, now))
+
+	p := request.WebhookDeliveryParameter{Limit: 10, SubscriptionID: 1, Status: "failed"}
+	deliveries, total, err := repository.NewMysqlWebhook(db).GetDeliveries(request.WithTenantID(context.TODO(), "acme"), &p)
+	if err != nil || total != 1 || len(deliveries) != 1 {
+		t.Fatalf("mysqlWebhook.GetDeliveries() = %v, %d, %v", deliveries, total, err)
+	}
+	if deliveries[0].ResponseStatus != 500 || deliveries[0].LastAttemptAt == nil {
+		t.Errorf("mysqlWebhook.GetDeliveries() = %+v", deliveries[0])
+	}
+}
+
+func Test_mysqlWebhook_PurgeDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	before := time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC)
+	// the pending deliveries are kept whatever their age
+	mock.ExpectExec(`^DELETE FROM webhook_deliveries WHERE status IN \(\?, \?\) AND updated_at < \? ORDER BY id LIMIT \?$`).
+		WithArgs(entity.DeliveryDelivered, entity.DeliveryFailed, "2026-09-19 00:00:00", 1000).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := repository.NewMysqlWebhook(db).PurgeDeliveries(context.TODO(), before, 1000)
+	if err != nil || n != 2 {
+		t.Errorf("mysqlWebhook.PurgeDeliveries() = %d, %v, want 2", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// executor runs the statements of a repository, on the connection pool or in a transaction
+type executor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// tracedDB starts a span for each query, recording the statement with its literals removed.
+// The statements of a context carrying a transaction of the database, begun by a Transactor,
+// run in that transaction. The other queries are sent to the replicas in turn, if any, unless
+// the context reads from the primary, and to the primary when the replica fails. The writes
+// always go to the primary.
+type tracedDB struct {
+	*sql.DB
+	replicas []*replica
+	next     atomic.Uint32
+}
+
+// replica is a read only copy of the database, skipped until retryAt after it failed
+type replica struct {
+	db      *sql.DB
+	retryAt atomic.Int64
+}
+
+// replicaRetry is how long a failed replica is skipped
+const replicaRetry = 5 * time.Second
+
+func traced(db *sql.DB, replicas ...*sql.DB) *tracedDB {
+	t := &tracedDB{DB: db}
+	for _, r := range replicas {
+		t.replicas = append(t.replicas, &replica{db: r})
+	}
+	return t
+}
+
+// replica returns the replica to send the next query of ctx to, nil when it goes to the primary
+func (db *tracedDB) replica(ctx context.Context) *replica {
+	if len(db.replicas) == 0 || request.ReadPrimary(ctx) {
+		return nil
+	}
+
+	now := time.Now().UnixNano()
+	start := db.next.Add(1)
+	for i := range db.replicas {
+		r := db.replicas[(int(start)+i)%len(db.replicas)]
+		if r.retryAt.Load() <= now {
+			return r
+		}
+	}
+	return nil
+}
+
+// failed skips r for a while after a query failed, unless ctx was canceled. It reports whether
+// the query is to be sent to the primary instead.
+func (r *replica) failed(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	r.retryAt.Store(time.Now().Add(replicaRetry).UnixNano())
+	metrics.ReplicaFallbacks.Inc()
+	slog.WarnContext(ctx, "replica query failed, reading from the primary", slog.String("error", err.Error()))
+	return true
+}
+
+var sqlTable = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE)\s+([a-z_]+)`)
+
+// startQuery starts a client span named after the operation and table of query
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := strings.ToUpper(strings.SplitN(strings.TrimSpace(query), " ", 2)[0])
+	name := operation
+	if m := sqlTable.FindStringSubmatch(query); m != nil {
+		name += " " + m[1]
+	}
+
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mysql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", tracing.SanitizeSQL(query)),
+		),
+	)
+}
+
+func (db *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if tx := txOf(ctx, db.DB); tx != nil {
+		return tx.QueryContext(ctx, query, args...)
+	}
+
+	if r := db.replica(ctx); r != nil {
+		spanCtx, span := startQuery(ctx, query)
+		span.SetAttributes(attribute.Bool("db.replica", true))
+		rows, err := r.db.QueryContext(spanCtx, query, args...)
+		tracing.End(span, err)
+		if err == nil || !r.failed(ctx, err) {
+			return rows, err
+		}
+	}
+
+	ctx, span := startQuery(ctx, query)
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+func (db *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if tx := txOf(ctx, db.DB); tx != nil {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+
+	if r := db.replica(ctx); r != nil {
+		spanCtx, span := startQuery(ctx, query)
+		span.SetAttributes(attribute.Bool("db.replica", true))
+		row := r.db.QueryRowContext(spanCtx, query, args...)
+		tracing.End(span, row.Err())
+		if row.Err() == nil || !r.failed(ctx, row.Err()) {
+			return row
+		}
+	}
+
+	ctx, span := startQuery(ctx, query)
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+	return row
+}
+
+func (db *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if tx := txOf(ctx, db.DB); tx != nil {
+		return tx.ExecContext(ctx, query, args...)
+	}
+
+	ctx, span := startQuery(ctx, query)
+	res, err := db.DB.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return res, err
+}
+
+// inTx runs fn in a transaction, committed when fn succeeds and rolled back otherwise. When
+// ctx carries a transaction of db fn joins it, and it is committed or rolled back as a whole.
+func (db *tracedDB) inTx(ctx context.Context, fn func(ex executor) error) error {
+	if tx := txOf(ctx, db.DB); tx != nil {
+		return fn(tx)
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&tracedTx{tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// tracedTx traces the statements of a transaction the same way as tracedDB
+type tracedTx struct {
+	*sql.Tx
+}
+
+func (tx *tracedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+func (tx *tracedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuery(ctx, query)
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+	return row
+}
+
+func (tx *tracedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	res, err := tx.Tx.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return res, err
+}
+
+type txKey struct{}
+
+// boundTx is a transaction carried by a context, with the connection pool it belongs to and
+// the functions to run once it is committed
+type boundTx struct {
+	db       *sql.DB
+	tx       *tracedTx
+	mu       sync.Mutex
+	onCommit []func()
+}
+
+// withTx returns a copy of ctx carrying tx, begun on db
+func withTx(ctx context.Context, db *sql.DB, tx *sql.Tx) (context.Context, *boundTx) {
+	b := &boundTx{db: db, tx: &tracedTx{tx}}
+	return context.WithValue(ctx, txKey{}, b), b
+}
+
+// txOf returns the transaction of db carried by ctx, nil when there is none
+func txOf(ctx context.Context, db *sql.DB) *tracedTx {
+	if b, ok := ctx.Value(txKey{}).(*boundTx); ok && b.db == db {
+		return b.tx
+	}
+	return nil
+}
+
+// inTransaction reports whether ctx carries a transaction, of any database
+func inTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*boundTx)
+	return ok
+}
+
+// afterCommit runs fn once the transaction carried by ctx is committed, or right away when
+// ctx carries none. fn is not run when the transaction is rolled back.
+func afterCommit(ctx context.Context, fn func()) {
+	b, ok := ctx.Value(txKey{}).(*boundTx)
+	if !ok {
+		fn()
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onCommit = append(b.onCommit, fn)
+}
+
+// committed runs the functions registered with afterCommit
+func (b *boundTx) committed() {
+	b.mu.Lock()
+	fns := b.onCommit
+	b.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
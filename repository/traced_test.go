@@ -0,0 +1,127 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+func Test_tracedDB_Replicas(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer replica.Close()
+
+	currency := sqlmock.NewRows(currencyColumns).AddRow(1, "", "IDR", 1, time.Time{}, time.Time{})
+	replicaMock.ExpectQuery("FROM currencies WHERE id = 1").WillReturnRows(currency)
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectExec("^INSERT INTO currencies").WillReturnResult(sqlmock.NewResult(2, 1))
+	expectEvent(primaryMock, entity.EventCurrencyCreated, true)
+	primaryMock.ExpectQuery("FROM currencies WHERE id = 2").
+		WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow(2, "", "USD", 1, time.Time{}, time.Time{}))
+
+	repo := repository.NewMysqlCurrency(primary, replica)
+
+	if _, err := repo.GetCurrency(context.TODO(), 1); err != nil {
+		t.Errorf("mysqlCurrency.GetCurrency() from the replica error = %v", err)
+	}
+	if err := repo.CreateCurrency(context.TODO(), &entity.Currency{Name: "USD"}); err != nil {
+		t.Errorf("mysqlCurrency.CreateCurrency() on the primary error = %v", err)
+	}
+	if _, err := repo.GetCurrency(request.WithReadPrimary(context.TODO()), 2); err != nil {
+		t.Errorf("mysqlCurrency.GetCurrency() reading its write error = %v", err)
+	}
+
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("the writes are not sent to the primary: %s", err)
+	}
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("the reads are not sent to the replica: %s", err)
+	}
+}
+
+func Test_tracedDB_ReplicaFallback(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer replica.Close()
+
+	replicaMock.ExpectQuery("^SELECT COUNT").WillReturnError(errors.New("connection refused"))
+	// the replica is skipped for a while after it failed
+	primaryMock.ExpectQuery("^SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	primaryMock.ExpectQuery("FROM currencies WHERE").
+		WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow(1, "", "IDR", 1, time.Time{}, time.Time{}))
+
+	fallbacks := testutil.ToFloat64(metrics.ReplicaFallbacks)
+
+	currencies, total, err := repository.NewMysqlCurrency(primary, replica).
+		GetCurrencies(context.TODO(), &request.CurrencyParameter{Limit: 10})
+	if err != nil || total != 1 || len(currencies) != 1 {
+		t.Errorf("mysqlCurrency.GetCurrencies() = %v, %d, %v, want the currencies of the primary", currencies, total, err)
+	}
+	if got := testutil.ToFloat64(metrics.ReplicaFallbacks); got != fallbacks+1 {
+		t.Errorf("ReplicaFallbacks = %v, want %v", got, fallbacks+1)
+	}
+
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func Test_tracedDB_ReplicaTransaction(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer replica.Close()
+
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectQuery("FROM currencies WHERE id = 1").
+		WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow(1, "", "IDR", 1, time.Time{}, time.Time{}))
+	primaryMock.ExpectCommit()
+
+	repo := repository.NewMysqlCurrency(primary, replica)
+	err = repository.NewMysqlTransactor(primary).WithinTx(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.GetCurrency(ctx, 1)
+		return err
+	})
+	if err != nil {
+		t.Errorf("mysqlCurrency.GetCurrency() in a transaction error = %v", err)
+	}
+
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("the reads of a transaction are not sent to the primary: %s", err)
+	}
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -1,9 +1,14 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
 )
 
 const (
@@ -14,10 +19,45 @@ func buildQuery(query string, args ...interface{}) string {
 	return fmt.Sprintf(query, args...)
 }
 
+// logQuery logs query at debug level, with the request ID of ctx
+func logQuery(ctx context.Context, query string) {
+	slog.DebugContext(ctx, "sql query", slog.String("query", query))
+}
+
 func sqlTime(t time.Time) string {
 	return t.Format(MysqlTimeFormat)
 }
 
+// visibleToTenant matches the rows of the global tenant and of tenantID
+func visibleToTenant(tenantID string) string {
+	if tenantID == "" {
+		return "tenant_id = ''"
+	}
+	return buildQuery("tenant_id IN ('', %q)", tenantID)
+}
+
+// containing returns the LIKE pattern matching the values that contain s, its wildcards matched as is
+func containing(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 func int64sToString(a []int64, delim string) string {
 	return strings.Trim(strings.Replace(fmt.Sprint(a), " ", delim, -1), "[]")
 }
+
+// versionMismatch tells why an update of the row id of table with an expected version matched
+// no row: the row is not one of the tenant of ctx, a global row or the row of another tenant
+// included, or it has been modified since the version was read
+func versionMismatch(ctx context.Context, ex executor, table string, id int64, entityName string) error {
+	var version int64
+	err := ex.QueryRowContext(ctx, buildQuery("SELECT version FROM %s WHERE id = ? AND tenant_id = ?", table), id, request.TenantID(ctx)).Scan(&version)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("Not Found")
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("Precondition Failed: %s has been modified", entityName)
+}
@@ -0,0 +1,151 @@
+package api_key
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/auth"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+// KeyPrefix starts every issued key so leaked keys are easy to recognise
+const KeyPrefix = "whim_"
+
+// usecase
+type APIKeyUsecase interface {
+	IssueAPIKey(ctx context.Context, ek *entity.APIKey) error
+	RevokeAPIKey(ctx context.Context, id int64) error
+	GetAPIKeys(ctx context.Context, p *request.APIKeyParameter) ([]entity.APIKey, int64, error)
+	Authenticate(ctx context.Context, key string) (*entity.APIKey, error)
+	BootstrapAPIKey(ctx context.Context, key string) error
+}
+
+type Provider struct {
+	Repo repository.APIKeyRepo
+}
+
+//Service api key usecase
+type Service struct {
+	*Provider
+}
+
+//NewService create new service
+func NewService(prvd *Provider) APIKeyUsecase {
+	return &Service{prvd}
+}
+
+// HashKey returns the stored representation of a plain API key
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return KeyPrefix + hex.EncodeToString(b), nil
+}
+
+func (s *Service) IssueAPIKey(ctx context.Context, ek *entity.APIKey) (err error) {
+	ctx, span := tracing.Start(ctx, "api_key.IssueAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	if ek.Name == "" {
+		return fmt.Errorf("name cannot be null")
+	}
+	if !auth.Role(ek.Role).Valid() {
+		return fmt.Errorf("Bad Request: role must be one of reader, rate-admin or admin")
+	}
+	if ek.ClientID == "" {
+		ek.ClientID = ek.Name
+	}
+	// a tenant admin only issues keys of its tenant, the client ids are those of the tenant
+	if tenantID := request.TenantID(ctx); tenantID != "" {
+		if ek.TenantID != "" && ek.TenantID != tenantID {
+			return fmt.Errorf("Bad Request: tenant_id must be the tenant of the caller")
+		}
+		ek.TenantID = tenantID
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return err
+	}
+
+	ek.Key = key
+	ek.KeyHash = HashKey(key)
+	ek.Prefix = key[:len(KeyPrefix)+8]
+	ek.RevokedAt = nil
+	ek.CreatedAt = time.Now().UTC()
+	ek.UpdatedAt = ek.CreatedAt
+
+	return s.Repo.CreateAPIKey(ctx, ek)
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "api_key.RevokeAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	return s.Repo.RevokeAPIKey(ctx, id, time.Now().UTC())
+}
+
+func (s *Service) GetAPIKeys(ctx context.Context, p *request.APIKeyParameter) (_ []entity.APIKey, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "api_key.GetAPIKeys")
+	defer func() { tracing.End(span, err) }()
+
+	return s.Repo.GetAPIKeys(ctx, p)
+}
+
+// Authenticate returns the active API key matching key
+func (s *Service) Authenticate(ctx context.Context, key string) (_ *entity.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "api_key.Authenticate")
+	defer func() { tracing.End(span, err) }()
+
+	ek, err := s.Repo.GetAPIKeyByHash(ctx, HashKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if ek.RevokedAt != nil {
+		return nil, fmt.Errorf("Not Found")
+	}
+
+	return ek, nil
+}
+
+// BootstrapAPIKey stores key as an admin key unless it is already known, so a fresh
+// installation has a key that can issue the others
+func (s *Service) BootstrapAPIKey(ctx context.Context, key string) (err error) {
+	ctx, span := tracing.Start(ctx, "api_key.BootstrapAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	_, err = s.Repo.GetAPIKeyByHash(ctx, HashKey(key))
+	if err == nil {
+		return nil
+	}
+	if err.Error() != "Not Found" {
+		return err
+	}
+
+	now := time.Now().UTC()
+	ek := entity.APIKey{
+		Name:      "bootstrap",
+		ClientID:  "bootstrap",
+		Role:      string(auth.RoleAdmin),
+		Prefix:    key[:min(len(key), len(KeyPrefix)+8)],
+		KeyHash:   HashKey(key),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	return s.Repo.CreateAPIKey(ctx, &ek)
+}
@@ -0,0 +1,116 @@
+package api_key_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/usecase/api_key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func createService(repo *mocks.APIKeyRepo) api_key.APIKeyUsecase {
+	return api_key.NewService(&api_key.Provider{Repo: repo})
+}
+
+func TestIssueAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    entity.APIKey
+		IsError bool
+	}{
+		{
+			name: "success",
+			data: entity.APIKey{Name: "pricing", Role: "rate-admin"},
+		},
+		{
+			name:    "unknown role",
+			data:    entity.APIKey{Name: "pricing", Role: "root"},
+			IsError: true,
+		},
+		{
+			name:    "missing name",
+			data:    entity.APIKey{Role: "reader"},
+			IsError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.APIKeyRepo)
+			repo.On("CreateAPIKey", mock.Anything, mock.Anything).Return(nil)
+
+			err := createService(repo).IssueAPIKey(context.TODO(), &tt.data)
+			if !assert.Equal(t, tt.IsError, err != nil) || err != nil {
+				repo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.True(t, strings.HasPrefix(tt.data.Key, api_key.KeyPrefix))
+			assert.Equal(t, api_key.HashKey(tt.data.Key), tt.data.KeyHash)
+			assert.NotContains(t, tt.data.KeyHash, tt.data.Key)
+			assert.True(t, strings.HasPrefix(tt.data.Key, tt.data.Prefix))
+			assert.Equal(t, "pricing", tt.data.ClientID)
+		})
+	}
+}
+
+func TestIssueAPIKeyOfTenant(t *testing.T) {
+	repo := new(mocks.APIKeyRepo)
+	repo.On("CreateAPIKey", mock.Anything, mock.Anything).Return(nil)
+	ctx := request.WithTenantID(context.TODO(), "acme")
+
+	key := entity.APIKey{Name: "pricing", Role: "admin"}
+	assert.NoError(t, createService(repo).IssueAPIKey(ctx, &key))
+	assert.Equal(t, "acme", key.TenantID, "the key belongs to the tenant of the caller")
+
+	key = entity.APIKey{Name: "pricing", Role: "admin", TenantID: "globex"}
+	err := createService(repo).IssueAPIKey(ctx, &key)
+	assert.EqualError(t, err, "Bad Request: tenant_id must be the tenant of the caller")
+	repo.AssertNumberOfCalls(t, "CreateAPIKey", 1)
+
+	// an admin of the global tenant issues the keys of the tenants
+	key = entity.APIKey{Name: "pricing", Role: "admin", TenantID: "globex"}
+	assert.NoError(t, createService(repo).IssueAPIKey(context.TODO(), &key))
+	assert.Equal(t, "globex", key.TenantID)
+}
+
+func TestAuthenticate(t *testing.T) {
+	revokedAt := time.Now()
+	repo := new(mocks.APIKeyRepo)
+	repo.On("GetAPIKeyByHash", mock.Anything, api_key.HashKey("whim_active")).Return(&entity.APIKey{ID: 1, Role: "reader"}, nil)
+	repo.On("GetAPIKeyByHash", mock.Anything, api_key.HashKey("whim_revoked")).Return(&entity.APIKey{ID: 2, Role: "admin", RevokedAt: &revokedAt}, nil)
+	repo.On("GetAPIKeyByHash", mock.Anything, mock.Anything).Return(nil, errors.New("Not Found"))
+
+	u := createService(repo)
+
+	key, err := u.Authenticate(context.TODO(), "whim_active")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), key.ID)
+
+	_, err = u.Authenticate(context.TODO(), "whim_revoked")
+	assert.EqualError(t, err, "Not Found")
+
+	_, err = u.Authenticate(context.TODO(), "whim_unknown")
+	assert.EqualError(t, err, "Not Found")
+}
+
+func TestBootstrapAPIKey(t *testing.T) {
+	repo := new(mocks.APIKeyRepo)
+	repo.On("GetAPIKeyByHash", mock.Anything, api_key.HashKey("whim_known")).Return(&entity.APIKey{ID: 1}, nil)
+	repo.On("GetAPIKeyByHash", mock.Anything, api_key.HashKey("whim_new")).Return(nil, errors.New("Not Found"))
+	repo.On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(k *entity.APIKey) bool {
+		return k.Role == "admin" && k.KeyHash == api_key.HashKey("whim_new")
+	})).Return(nil).Once()
+
+	u := createService(repo)
+	assert.NoError(t, u.BootstrapAPIKey(context.TODO(), "whim_known"))
+	assert.NoError(t, u.BootstrapAPIKey(context.TODO(), "whim_new"))
+	repo.AssertExpectations(t)
+}
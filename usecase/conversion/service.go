@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/stream"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/repository"
 )
@@ -18,9 +20,14 @@ type ConversionUsecase interface {
 	GetConversion(ctx context.Context, id int64) (*entity.Conversion, error)
 }
 
+// Provider holds the dependencies of the service, rate changes are not published when Events
+// is nil. The checks and the insert of a new conversion run in one transaction of Tx, or one
+// statement after the other when Tx is nil.
 type Provider struct {
 	Repo         repository.ConversionRepo
 	CurrencyRepo repository.CurrencyRepo
+	Tx           repository.Transactor
+	Events       stream.Publisher
 }
 
 //Service book usecase
@@ -33,49 +40,109 @@ func NewService(prvd *Provider) ConversionUsecase {
 	return &Service{prvd}
 }
 
-func (s *Service) CreateConversion(ctx context.Context, ec *entity.Conversion) error {
-	_, err := s.CurrencyRepo.GetCurrency(ctx, ec.CurrencyIDFrom)
-	if err != nil {
-		return fmt.Errorf("Bad Request")
+func (s *Service) CreateConversion(ctx context.Context, ec *entity.Conversion) (err error) {
+	ctx, span := tracing.Start(ctx, "conversion.CreateConversion")
+	defer func() { tracing.End(span, err) }()
+
+	// the currencies cannot be deleted, nor the same rate created, until the conversion is saved
+	err = s.withinTx(ctx, func(ctx context.Context) error {
+		// the currencies are locked in ascending id order, so the conversions of a pair and of
+		// its reverse do not deadlock
+		ids := []int64{ec.CurrencyIDFrom, ec.CurrencyIDTo}
+		if ids[0] > ids[1] {
+			ids[0], ids[1] = ids[1], ids[0]
+		}
+		for _, id := range ids {
+			if _, err := s.CurrencyRepo.GetCurrency(ctx, id); err != nil {
+				if err.Error() == "Not Found" {
+					return fmt.Errorf("Bad Request")
+				}
+				return err
+			}
+		}
+
+		params := request.ConversionParameter{
+			Limit:          10,
+			Offset:         0,
+			CurrencyIDFrom: ec.CurrencyIDFrom,
+			CurrencyIDTo:   ec.CurrencyIDTo,
+		}
+		conversions, _, err := s.Repo.GetConversions(ctx, &params)
+		if err != nil {
+			return err
+		}
+
+		// a tenant may override a global rate, but not its own
+		for _, c := range conversions {
+			if c.TenantID == request.TenantID(ctx) {
+				return fmt.Errorf("Duplicate entry")
+			}
+		}
+
+		ec.CreatedAt = time.Now()
+		ec.UpdatedAt = time.Now()
+
+		return s.Repo.CreateConversion(ctx, ec)
+	})
+	if err == nil {
+		s.publish(rateEvent(stream.RateCreated, ec.ID, ec))
 	}
+	return err
+}
 
-	_, err = s.CurrencyRepo.GetCurrency(ctx, ec.CurrencyIDTo)
-	if err != nil {
-		return fmt.Errorf("Bad Request")
+// withinTx runs fn in a transaction of Tx, if any
+func (s *Service) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.Tx == nil {
+		return fn(ctx)
 	}
+	return s.Tx.WithinTx(ctx, fn)
+}
 
-	params := request.ConversionParameter{
-		Limit:          10,
-		Offset:         0,
-		CurrencyIDFrom: ec.CurrencyIDFrom,
-		CurrencyIDTo:   ec.CurrencyIDTo,
-	}
-	_, total, err := s.Repo.GetConversions(ctx, &params)
-	if err == nil && total > 0 {
-		return fmt.Errorf("Duplicate entry")
-	}
+func (s *Service) UpdateConversion(ctx context.Context, id int64, ec *entity.Conversion) (err error) {
+	ctx, span := tracing.Start(ctx, "conversion.UpdateConversion")
+	defer func() { tracing.End(span, err) }()
 
-	ec.CreatedAt = time.Now()
 	ec.UpdatedAt = time.Now()
 
-	err = s.Repo.CreateConversion(ctx, ec)
+	err = s.Repo.UpdateConversion(ctx, id, ec)
+	if err == nil {
+		s.publish(rateEvent(stream.RateUpdated, id, ec))
+	}
+
 	return err
 }
 
-func (s *Service) UpdateConversion(ctx context.Context, id int64, ec *entity.Conversion) error {
-	ec.UpdatedAt = time.Now()
-
-	err := s.Repo.UpdateConversion(ctx, id, ec)
+func rateEvent(typ string, id int64, ec *entity.Conversion) stream.RateEvent {
+	return stream.RateEvent{
+		Type:           typ,
+		ConversionID:   id,
+		TenantID:       ec.TenantID,
+		CurrencyIDFrom: ec.CurrencyIDFrom,
+		CurrencyIDTo:   ec.CurrencyIDTo,
+		Rate:           ec.Rate,
+		UpdatedAt:      ec.UpdatedAt,
+	}
+}
 
-	return err
+// publish streams e to the subscribed clients once the change is committed
+func (s *Service) publish(e stream.RateEvent) {
+	if s.Events != nil {
+		s.Events.Publish(e)
+	}
 }
 
-func (s *Service) GetConversions(ctx context.Context, p *request.ConversionParameter) ([]entity.Conversion, int64, error) {
+func (s *Service) GetConversions(ctx context.Context, p *request.ConversionParameter) (_ []entity.Conversion, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "conversion.GetConversions")
+	defer func() { tracing.End(span, err) }()
+
 	conversions, length, err := s.Repo.GetConversions(ctx, p)
 
 	return conversions, length, err
 }
 
-func (s *Service) GetConversion(ctx context.Context, id int64) (*entity.Conversion, error) {
+func (s *Service) GetConversion(ctx context.Context, id int64) (_ *entity.Conversion, err error) {
+	ctx, span := tracing.Start(ctx, "conversion.GetConversion")
+	defer func() { tracing.End(span, err) }()
+
 	return s.Repo.GetConversion(ctx, id)
 }
@@ -2,13 +2,19 @@ package conversion_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+
 	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/app/stream"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/repository"
 	"github.com/rbpermadi/whim_assignment/usecase/conversion"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -149,3 +155,182 @@ func TestUpdateConversion(t *testing.T) {
 	}
 	ap.Repo.AssertExpectations(t)
 }
+
+func TestCreateConversionTenantOverride(t *testing.T) {
+	global := sampleConversion()
+	override := sampleConversion()
+	override.TenantID = "acme"
+
+	tests := []struct {
+		name     string
+		existing []entity.Conversion
+		IsError  bool
+	}{
+		{"new pair", []entity.Conversion{}, false},
+		{"override global rate", []entity.Conversion{global}, false},
+		{"duplicate tenant rate", []entity.Conversion{override}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := provider()
+			ap.CurrencyRepo.On("GetCurrency", mock.Anything, mock.Anything).Return(&entity.Currency{}, nil)
+			ap.Repo.On("GetConversions", mock.Anything, mock.Anything).Return(tt.existing, int64(len(tt.existing)), nil)
+			ap.Repo.On("CreateConversion", mock.Anything, mock.Anything).Return(nil)
+
+			u := createService(&conversion.Provider{Repo: ap.Repo, CurrencyRepo: ap.CurrencyRepo})
+			ec := sampleConversion()
+			err := u.CreateConversion(request.WithTenantID(context.TODO(), "acme"), &ec)
+			assert.Equal(t, tt.IsError, err != nil)
+		})
+	}
+}
+
+func TestPublishRateEvents(t *testing.T) {
+	ap := provider()
+	ap.Repo.On("UpdateConversion", mock.Anything, int64(1), mock.Anything).Return(nil).Once()
+	ap.Repo.On("UpdateConversion", mock.Anything, int64(2), mock.Anything).Return(fmt.Errorf("Not Found")).Once()
+
+	hub := stream.NewHub(10)
+	sub := hub.Subscribe("", stream.Pair{From: 1, To: 2})
+	defer sub.Close()
+
+	u := createService(&conversion.Provider{Repo: ap.Repo, Events: hub})
+
+	err := u.UpdateConversion(context.TODO(), 1, &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 14500})
+	assert.NoError(t, err)
+	err = u.UpdateConversion(context.TODO(), 2, &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 14600})
+	assert.Error(t, err)
+
+	select {
+	case e := <-sub.Events():
+		assert.Equal(t, stream.RateUpdated, e.Type)
+		assert.Equal(t, int64(1), e.ConversionID)
+		assert.Equal(t, 14500.0, e.Rate)
+	default:
+		t.Fatal("no event published")
+	}
+	assert.Len(t, sub.Events(), 0, "failed updates are not published")
+}
+
+func TestCreateConversionWithinTx(t *testing.T) {
+	currencyColumns := []string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}
+	conversionColumns := []string{"id", "tenant_id", "currency_id_from", "currency_id_to", "rate", "version", "updated_at", "created_at"}
+
+	tests := []struct {
+		name    string
+		rates   *sqlmock.Rows
+		wantErr string
+	}{
+		{name: "committed", rates: sqlmock.NewRows(conversionColumns)},
+		{name: "duplicate rolled back", rates: sqlmock.NewRows(conversionColumns).AddRow(3, "acme", 1, 2, 1.5, 1, time.Time{}, time.Time{}), wantErr: "Duplicate entry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, sqlMock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			// the lookups, the duplicate check and the insert run in one transaction
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectQuery("FROM currencies WHERE id = 1").
+				WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow(1, "", "IDR", 1, time.Time{}, time.Time{}))
+			sqlMock.ExpectQuery("FROM currencies WHERE id = 2").
+				WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow(2, "", "USD", 1, time.Time{}, time.Time{}))
+			sqlMock.ExpectQuery("^SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			sqlMock.ExpectQuery("FROM conversions").WillReturnRows(tt.rates)
+			if tt.wantErr == "" {
+				sqlMock.ExpectExec("^INSERT INTO conversions").WillReturnResult(sqlmock.NewResult(4, 1))
+				sqlMock.ExpectExec("^INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(1, 1))
+				sqlMock.ExpectCommit()
+			} else {
+				sqlMock.ExpectRollback()
+			}
+
+			u := createService(&conversion.Provider{
+				Repo:         repository.NewMysqlConversion(db),
+				CurrencyRepo: repository.NewMysqlCurrency(db),
+				Tx:           repository.NewMysqlTransactor(db),
+			})
+
+			ec := entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5}
+			err = u.CreateConversion(request.WithTenantID(context.TODO(), "acme"), &ec)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(4), ec.ID)
+			}
+
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateConversionRetriedAfterDeadlock(t *testing.T) {
+	currencyColumns := []string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// the first attempt is aborted by a deadlock while locking the currencies, which are
+	// locked in ascending id order whatever the direction of the rate
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("FROM currencies WHERE id = 1 (.+) FOR UPDATE$").WillReturnError(deadlock)
+	sqlMock.ExpectRollback()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("FROM currencies WHERE id = 1 (.+) FOR UPDATE$").
+		WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow(1, "", "IDR", 1, time.Time{}, time.Time{}))
+	sqlMock.ExpectQuery("FROM currencies WHERE id = 2 (.+) FOR UPDATE$").
+		WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow(2, "", "USD", 1, time.Time{}, time.Time{}))
+	sqlMock.ExpectQuery("^SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	sqlMock.ExpectQuery("FROM conversions").WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "currency_id_from", "currency_id_to", "rate", "version", "updated_at", "created_at"}))
+	sqlMock.ExpectExec("^INSERT INTO conversions").WillReturnResult(sqlmock.NewResult(4, 1))
+	sqlMock.ExpectExec("^INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+
+	u := createService(&conversion.Provider{
+		Repo:         repository.NewMysqlConversion(db),
+		CurrencyRepo: repository.NewMysqlCurrency(db),
+		Tx:           repository.NewMysqlTransactor(db),
+	})
+
+	ec := entity.Conversion{CurrencyIDFrom: 2, CurrencyIDTo: 1, Rate: 0.00007}
+	assert.NoError(t, u.CreateConversion(context.TODO(), &ec))
+	assert.Equal(t, int64(4), ec.ID)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreateConversionUnknownCurrency(t *testing.T) {
+	ap := provider()
+	ap.CurrencyRepo.On("GetCurrency", mock.Anything, int64(1)).Return(&entity.Currency{}, nil)
+	ap.CurrencyRepo.On("GetCurrency", mock.Anything, int64(2)).Return(nil, fmt.Errorf("Not Found")).Once()
+	ap.CurrencyRepo.On("GetCurrency", mock.Anything, int64(2)).Return(nil, fmt.Errorf("get currency 2: connection refused")).Once()
+
+	u := createService(&conversion.Provider{Repo: ap.Repo, CurrencyRepo: ap.CurrencyRepo})
+
+	err := u.CreateConversion(context.TODO(), &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5})
+	assert.EqualError(t, err, "Bad Request")
+	// a failed lookup is not mistaken for an unknown currency
+	err = u.CreateConversion(context.TODO(), &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5})
+	assert.EqualError(t, err, "get currency 2: connection refused")
+	ap.Repo.AssertNotCalled(t, "CreateConversion", mock.Anything, mock.Anything)
+}
+
+func TestCreateConversionTxFailed(t *testing.T) {
+	ap := provider()
+	tx := new(mocks.Transactor)
+	tx.On("WithinTx", mock.Anything, mock.Anything).Return(fmt.Errorf("deadlock found"))
+
+	u := createService(&conversion.Provider{Repo: ap.Repo, CurrencyRepo: ap.CurrencyRepo, Tx: tx})
+
+	err := u.CreateConversion(context.TODO(), &entity.Conversion{CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 1.5})
+	assert.EqualError(t, err, "deadlock found")
+	ap.Repo.AssertNotCalled(t, "CreateConversion", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,115 @@
+package conversion_quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+const periodFormat = "2006-01"
+
+// usecase, the services consuming it leave conversions unlimited when they are given none
+type ConversionQuotaUsecase interface {
+	ConsumeConversion(ctx context.Context) error
+	ReleaseConversion(ctx context.Context) error
+	GetConversionQuota(ctx context.Context, clientID string) (*entity.ConversionQuota, error)
+	SetConversionQuota(ctx context.Context, clientID string, limit int64) (*entity.ConversionQuota, error)
+}
+
+// Provider holds the quota storage. DefaultLimit applies to clients without a limit of their own,
+// zero is unlimited.
+type Provider struct {
+	Repo         repository.ConversionQuotaRepo
+	DefaultLimit int64
+}
+
+//Service conversion quota usecase
+type Service struct {
+	*Provider
+}
+
+//NewService create new service
+func NewService(prvd *Provider) ConversionQuotaUsecase {
+	return &Service{prvd}
+}
+
+func period(t time.Time) string {
+	return t.UTC().Format(periodFormat)
+}
+
+func (s *Service) limit(ctx context.Context, clientID string) (int64, error) {
+	limit, err := s.Repo.GetQuotaLimit(ctx, clientID)
+	if err != nil && err.Error() == "Not Found" {
+		return s.DefaultLimit, nil
+	}
+	return limit, err
+}
+
+// ConsumeConversion counts a conversion of the calling client, it fails with Too Many Requests
+// once the client has used its quota for the month
+func (s *Service) ConsumeConversion(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "conversion_quota.ConsumeConversion")
+	defer func() { tracing.End(span, err) }()
+
+	clientID := request.ClientID(ctx)
+	limit, err := s.limit(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return s.Repo.IncrementQuotaUsage(ctx, clientID, period(now), limit, now)
+}
+
+// ReleaseConversion gives back the conversion consumed by a request that failed
+func (s *Service) ReleaseConversion(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "conversion_quota.ReleaseConversion")
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now().UTC()
+	return s.Repo.DecrementQuotaUsage(ctx, request.ClientID(ctx), period(now), now)
+}
+
+func (s *Service) GetConversionQuota(ctx context.Context, clientID string) (_ *entity.ConversionQuota, err error) {
+	ctx, span := tracing.Start(ctx, "conversion_quota.GetConversionQuota")
+	defer func() { tracing.End(span, err) }()
+
+	limit, err := s.limit(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	used, err := s.Repo.GetQuotaUsage(ctx, clientID, period(now))
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ConversionQuota{
+		ClientID: clientID,
+		Period:   period(now),
+		Limit:    limit,
+		Used:     used,
+		ResetsAt: time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func (s *Service) SetConversionQuota(ctx context.Context, clientID string, limit int64) (_ *entity.ConversionQuota, err error) {
+	ctx, span := tracing.Start(ctx, "conversion_quota.SetConversionQuota")
+	defer func() { tracing.End(span, err) }()
+
+	if limit < 0 {
+		return nil, fmt.Errorf("Bad Request: limit cannot be negative")
+	}
+
+	if err := s.Repo.SetQuotaLimit(ctx, clientID, limit, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	return s.GetConversionQuota(ctx, clientID)
+}
@@ -0,0 +1,68 @@
+package conversion_quota_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/usecase/conversion_quota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestConsumeConversion(t *testing.T) {
+	period := time.Now().UTC().Format("2006-01")
+
+	tests := []struct {
+		name      string
+		limit     int64
+		limitErr  error
+		wantLimit int64
+	}{
+		{"client limit", 500, nil, 500},
+		{"default limit", 0, errors.New("Not Found"), 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.ConversionQuotaRepo)
+			repo.On("GetQuotaLimit", mock.Anything, "pricing").Return(tt.limit, tt.limitErr)
+			repo.On("IncrementQuotaUsage", mock.Anything, "pricing", period, tt.wantLimit, mock.Anything).Return(nil)
+
+			u := conversion_quota.NewService(&conversion_quota.Provider{Repo: repo, DefaultLimit: 100})
+			err := u.ConsumeConversion(request.WithClientID(context.TODO(), "pricing"))
+			assert.NoError(t, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetConversionQuota(t *testing.T) {
+	now := time.Now().UTC()
+	period := now.Format("2006-01")
+
+	repo := new(mocks.ConversionQuotaRepo)
+	repo.On("GetQuotaLimit", mock.Anything, "pricing").Return(int64(0), errors.New("Not Found"))
+	repo.On("GetQuotaUsage", mock.Anything, "pricing", period).Return(int64(42), nil)
+
+	u := conversion_quota.NewService(&conversion_quota.Provider{Repo: repo, DefaultLimit: 100})
+	quota, err := u.GetConversionQuota(context.TODO(), "pricing")
+	assert.NoError(t, err)
+	assert.Equal(t, period, quota.Period)
+	assert.Equal(t, int64(100), quota.Limit)
+	assert.Equal(t, int64(42), quota.Used)
+	assert.Equal(t, 1, quota.ResetsAt.Day())
+	assert.True(t, quota.ResetsAt.After(now))
+}
+
+func TestSetConversionQuota(t *testing.T) {
+	repo := new(mocks.ConversionQuotaRepo)
+	u := conversion_quota.NewService(&conversion_quota.Provider{Repo: repo})
+
+	_, err := u.SetConversionQuota(context.TODO(), "pricing", -1)
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "SetQuotaLimit", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -3,19 +3,29 @@ package convert_currencies
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/rbpermadi/whim_assignment/app/metrics"
 	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/conversion_quota"
 )
 
 // usecase
 type ConvertCurrenciesUsecase interface {
 	CreateConvertCurrencies(ctx context.Context, cry *entity.ConvertCurrencies) error
+	GetConvertCurrencies(ctx context.Context, p *request.ConvertCurrenciesParameter) ([]entity.ConvertCurrencies, int64, error)
+	GetConvertCurrency(ctx context.Context, id int64) (*entity.ConvertCurrencies, error)
 }
 
 type Provider struct {
-	Repo repository.ConversionRepo
+	Repo                  repository.ConversionRepo
+	ConvertCurrenciesRepo repository.ConvertCurrenciesRepo
+	Quota                 conversion_quota.ConversionQuotaUsecase
 }
 
 //Service book usecase
@@ -28,7 +38,14 @@ func NewService(prvd *Provider) ConvertCurrenciesUsecase {
 	return &Service{prvd}
 }
 
-func (s *Service) CreateConvertCurrencies(ctx context.Context, ec *entity.ConvertCurrencies) error {
+func (s *Service) CreateConvertCurrencies(ctx context.Context, ec *entity.ConvertCurrencies) (err error) {
+	ctx, span := tracing.Start(ctx, "convert_currencies.CreateConvertCurrencies")
+	defer func() { tracing.End(span, err) }()
+
+	if ec.Amount <= 0 {
+		return fmt.Errorf("Bad Request: amount must be greater than zero")
+	}
+
 	params := request.ConversionParameter{
 		Limit:          10,
 		Offset:         0,
@@ -44,10 +61,48 @@ func (s *Service) CreateConvertCurrencies(ctx context.Context, ec *entity.Conver
 		return fmt.Errorf("Not Found")
 	}
 
+	ec.ConversionID = conversions[0].ID
 	if conversions[0].CurrencyIDFrom == ec.CurrencyIDFrom && conversions[0].CurrencyIDTo == ec.CurrencyIDTo {
+		ec.Rate = conversions[0].Rate
 		ec.Result = ec.Amount * conversions[0].Rate
 	} else {
+		ec.Rate = 1 / conversions[0].Rate
 		ec.Result = ec.Amount / conversions[0].Rate
 	}
+
+	ec.ClientID = request.ClientID(ctx)
+	ec.IdempotencyKey = request.IdempotencyKey(ctx)
+	ec.CreatedAt = time.Now().UTC()
+
+	if s.Quota != nil {
+		if err := s.Quota.ConsumeConversion(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := s.ConvertCurrenciesRepo.CreateConvertCurrencies(ctx, ec); err != nil {
+		if s.Quota != nil {
+			if err := s.Quota.ReleaseConversion(ctx); err != nil {
+				slog.WarnContext(ctx, "releasing conversion quota", slog.String("error", err.Error()))
+			}
+		}
+		return err
+	}
+
+	metrics.Conversions.WithLabelValues(strconv.FormatInt(ec.CurrencyIDFrom, 10), strconv.FormatInt(ec.CurrencyIDTo, 10)).Inc()
 	return nil
 }
+
+func (s *Service) GetConvertCurrencies(ctx context.Context, p *request.ConvertCurrenciesParameter) (_ []entity.ConvertCurrencies, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "convert_currencies.GetConvertCurrencies")
+	defer func() { tracing.End(span, err) }()
+
+	return s.ConvertCurrenciesRepo.GetConvertCurrencies(ctx, p)
+}
+
+func (s *Service) GetConvertCurrency(ctx context.Context, id int64) (_ *entity.ConvertCurrencies, err error) {
+	ctx, span := tracing.Start(ctx, "convert_currencies.GetConvertCurrency")
+	defer func() { tracing.End(span, err) }()
+
+	return s.ConvertCurrenciesRepo.GetConvertCurrency(ctx, id)
+}
@@ -2,9 +2,11 @@ package convert_currencies_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/mocks"
 	"github.com/rbpermadi/whim_assignment/usecase/convert_currencies"
@@ -19,7 +21,8 @@ type writeConvertCurrenciesData struct {
 }
 
 type mockProvider struct {
-	Repo *mocks.ConversionRepo
+	Repo                  *mocks.ConversionRepo
+	ConvertCurrenciesRepo *mocks.ConvertCurrenciesRepo
 }
 
 func createService(p *convert_currencies.Provider) convert_currencies.ConvertCurrenciesUsecase {
@@ -28,20 +31,36 @@ func createService(p *convert_currencies.Provider) convert_currencies.ConvertCur
 
 func provider() mockProvider {
 	return mockProvider{
-		Repo: new(mocks.ConversionRepo),
+		Repo:                  new(mocks.ConversionRepo),
+		ConvertCurrenciesRepo: new(mocks.ConvertCurrenciesRepo),
 	}
 }
 
 func getWriteConvertCurrenciesData(data entity.ConvertCurrencies) []writeConvertCurrenciesData {
+	zero := data
+	zero.Amount = 0
+	negative := data
+	negative.Amount = -5
+
 	return []writeConvertCurrenciesData{
-		// TODO: Add test cases.
-		// TODO: Add test cases.
 		{
 			name:    "success",
+			data:    data,
 			IsError: false,
 		},
 		{
 			name:    "not found",
+			data:    data,
+			IsError: true,
+		},
+		{
+			name:    "zero amount",
+			data:    zero,
+			IsError: true,
+		},
+		{
+			name:    "negative amount",
+			data:    negative,
 			IsError: true,
 		},
 	}
@@ -78,10 +97,11 @@ func TestCreateConvertCurrencies(t *testing.T) {
 	ap.Repo.On("GetConversions", mock.Anything, mock.Anything).Return([]entity.Conversion{singleConversion}, int64(1), nil).Times(1)
 	//emulate not found occurred
 	ap.Repo.On("GetConversions", mock.Anything, mock.Anything).Return(nil, int64(0), nil).Times(1)
+	ap.ConvertCurrenciesRepo.On("CreateConvertCurrencies", mock.Anything, mock.Anything).Return(nil).Times(1)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			u := createService(&convert_currencies.Provider{Repo: ap.Repo})
+			u := createService(&convert_currencies.Provider{Repo: ap.Repo, ConvertCurrenciesRepo: ap.ConvertCurrenciesRepo})
 			ctx := context.TODO()
 
 			err := u.CreateConvertCurrencies(ctx, &tt.data)
@@ -92,4 +112,67 @@ func TestCreateConvertCurrencies(t *testing.T) {
 		})
 	}
 	ap.Repo.AssertExpectations(t)
+	ap.ConvertCurrenciesRepo.AssertExpectations(t)
+}
+
+func TestCreateConvertCurrenciesPersistsLog(t *testing.T) {
+	ap := provider()
+	singleConversion := sampleConversion()
+
+	ap.Repo.On("GetConversions", mock.Anything, mock.Anything).Return([]entity.Conversion{singleConversion}, int64(1), nil)
+	ap.ConvertCurrenciesRepo.On("CreateConvertCurrencies", mock.Anything, mock.Anything).Return(nil)
+
+	u := createService(&convert_currencies.Provider{Repo: ap.Repo, ConvertCurrenciesRepo: ap.ConvertCurrenciesRepo})
+	ctx := request.WithIdempotencyKey(request.WithClientID(context.TODO(), "client-1"), "key-1")
+
+	data := entity.ConvertCurrencies{CurrencyIDFrom: 2, CurrencyIDTo: 1, Amount: 58}
+	err := u.CreateConvertCurrencies(ctx, &data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, singleConversion.ID, data.ConversionID)
+	assert.Equal(t, float64(2), data.Result)
+	assert.Equal(t, 1/singleConversion.Rate, data.Rate)
+	assert.Equal(t, "client-1", data.ClientID)
+	assert.Equal(t, "key-1", data.IdempotencyKey)
+	assert.False(t, data.CreatedAt.IsZero())
+	ap.ConvertCurrenciesRepo.AssertCalled(t, "CreateConvertCurrencies", mock.Anything, &data)
+}
+
+func TestCreateConvertCurrenciesQuota(t *testing.T) {
+	tests := []struct {
+		name        string
+		consumeErr  error
+		createErr   error
+		wantRelease bool
+		IsError     bool
+	}{
+		{"within quota", nil, nil, false, false},
+		{"quota exceeded", errors.New("Too Many Requests: monthly conversion quota exceeded"), nil, false, true},
+		{"failed conversion gives the quota back", nil, errors.New("connection refused"), true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := provider()
+			quota := new(mocks.ConversionQuotaUsecase)
+			ap.Repo.On("GetConversions", mock.Anything, mock.Anything).Return([]entity.Conversion{sampleConversion()}, int64(1), nil)
+			ap.ConvertCurrenciesRepo.On("CreateConvertCurrencies", mock.Anything, mock.Anything).Return(tt.createErr)
+			quota.On("ConsumeConversion", mock.Anything).Return(tt.consumeErr)
+			quota.On("ReleaseConversion", mock.Anything).Return(nil)
+
+			u := createService(&convert_currencies.Provider{Repo: ap.Repo, ConvertCurrenciesRepo: ap.ConvertCurrenciesRepo, Quota: quota})
+			data := sampleConvertCurrencies()
+			err := u.CreateConvertCurrencies(context.TODO(), &data)
+			assert.Equal(t, tt.IsError, err != nil)
+
+			if tt.consumeErr != nil {
+				ap.ConvertCurrenciesRepo.AssertNotCalled(t, "CreateConvertCurrencies", mock.Anything, mock.Anything)
+			}
+			if tt.wantRelease {
+				quota.AssertCalled(t, "ReleaseConversion", mock.Anything)
+			} else {
+				quota.AssertNotCalled(t, "ReleaseConversion", mock.Anything)
+			}
+		})
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/repository"
 )
@@ -31,27 +32,39 @@ func NewService(prvd *Provider) CurrencyUsecase {
 	return &Service{prvd}
 }
 
-func (s *Service) CreateCurrency(ctx context.Context, ec *entity.Currency) error {
+func (s *Service) CreateCurrency(ctx context.Context, ec *entity.Currency) (err error) {
+	ctx, span := tracing.Start(ctx, "currency.CreateCurrency")
+	defer func() { tracing.End(span, err) }()
+
 	ec.CreatedAt = time.Now()
 	ec.UpdatedAt = time.Now()
 
-	err := s.Repo.CreateCurrency(ctx, ec)
+	err = s.Repo.CreateCurrency(ctx, ec)
 	return err
 }
 
-func (s *Service) UpdateCurrency(ctx context.Context, id int64, ec *entity.Currency) error {
+func (s *Service) UpdateCurrency(ctx context.Context, id int64, ec *entity.Currency) (err error) {
+	ctx, span := tracing.Start(ctx, "currency.UpdateCurrency")
+	defer func() { tracing.End(span, err) }()
+
 	ec.UpdatedAt = time.Now()
 
-	err := s.Repo.UpdateCurrency(ctx, id, ec)
+	err = s.Repo.UpdateCurrency(ctx, id, ec)
 	return err
 }
 
-func (s *Service) GetCurrencies(ctx context.Context, p *request.CurrencyParameter) ([]entity.Currency, int64, error) {
+func (s *Service) GetCurrencies(ctx context.Context, p *request.CurrencyParameter) (_ []entity.Currency, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "currency.GetCurrencies")
+	defer func() { tracing.End(span, err) }()
+
 	currencies, length, err := s.Repo.GetCurrencies(ctx, p)
 
 	return currencies, length, err
 }
 
-func (s *Service) GetCurrency(ctx context.Context, id int64) (*entity.Currency, error) {
+func (s *Service) GetCurrency(ctx context.Context, id int64) (_ *entity.Currency, err error) {
+	ctx, span := tracing.Start(ctx, "currency.GetCurrency")
+	defer func() { tracing.End(span, err) }()
+
 	return s.Repo.GetCurrency(ctx, id)
 }
@@ -2,16 +2,22 @@ package currency_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/rbpermadi/whim_assignment/app/request"
 	"github.com/rbpermadi/whim_assignment/app/response"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
 	"github.com/rbpermadi/whim_assignment/entity"
 	"github.com/rbpermadi/whim_assignment/mocks"
 	"github.com/rbpermadi/whim_assignment/usecase/currency"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type writeCurrencyData struct {
@@ -145,3 +151,22 @@ func TestUpdateCurrency(t *testing.T) {
 	}
 	ap.Repo.AssertExpectations(t)
 }
+
+func TestUpdateCurrencySpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	global := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(global) })
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSyncer(exporter)))
+
+	repo := new(mocks.CurrencyRepo)
+	repo.On("UpdateCurrency", mock.Anything, int64(1), mock.Anything).Return(errors.New("Conflict: the currency was changed")).Once()
+	u := createService(&currency.Provider{Repo: repo})
+
+	assert.Error(t, u.UpdateCurrency(context.TODO(), 1, &entity.Currency{}))
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "currency.UpdateCurrency", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "Conflict: the currency was changed", spans[0].Status.Description)
+}
@@ -0,0 +1,119 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+// Publisher sends the domain events relayed from the outbox to the other systems. An event
+// can be published more than once, so consumers must deduplicate them by id.
+type Publisher interface {
+	Publish(ctx context.Context, events []entity.DomainEvent) error
+}
+
+// Publishers publishes the events to each publisher in turn, until one of them fails
+type Publishers []Publisher
+
+func (p Publishers) Publish(ctx context.Context, events []entity.DomainEvent) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LogPublisher writes each event to the log
+type LogPublisher struct {
+	Logger *slog.Logger
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, events []entity.DomainEvent) error {
+	logger := p.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	for _, e := range events {
+		logger.InfoContext(ctx, "domain event",
+			slog.Int64("id", e.ID),
+			slog.String("type", e.Type),
+			slog.String("aggregate_type", e.AggregateType),
+			slog.Int64("aggregate_id", e.AggregateID),
+			slog.String("tenant_id", e.TenantID),
+			slog.String("payload", string(e.Payload)),
+		)
+	}
+	return nil
+}
+
+// FilePublisher appends the events to a file, one JSON document per line
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFilePublisher opens path for appending, creating it when it does not exist
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilePublisher{file: f}, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, events []entity.DomainEvent) error {
+	var b []byte
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b = append(append(b, line...), '\n')
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(b); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
+
+// Close closes the file
+func (p *FilePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.file.Close()
+}
+
+// MemoryPublisher keeps the events in memory, for tests and for a broker client to be
+// plugged in later
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []entity.DomainEvent
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, events []entity.DomainEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, events...)
+	return nil
+}
+
+// Events returns a copy of the events published so far
+func (p *MemoryPublisher) Events() []entity.DomainEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]entity.DomainEvent(nil), p.events...)
+}
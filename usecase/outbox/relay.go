@@ -0,0 +1,132 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+// purgeInterval is the time between two purges of the published events, and purgeBatch the
+// number of events deleted at a time
+const (
+	purgeInterval = time.Minute
+	purgeBatch    = 1000
+)
+
+// Relay publishes the domain events recorded in the outbox. The events of a batch are
+// published in the order they were recorded, but several relays publish their batches
+// concurrently, so consumers order the events by id rather than by arrival. An event stays in
+// the outbox until the publisher accepted it, so it is published at least once. The published
+// events are deleted once they are older than Retention, a zero Retention keeps them.
+type Relay struct {
+	Repo      repository.OutboxRepo
+	Publisher Publisher
+	Owner     string
+	BatchSize int
+	Lease     time.Duration
+	Retention time.Duration
+}
+
+// NewRelay returns a relay claiming up to batchSize events at a time under a random owner
+func NewRelay(repo repository.OutboxRepo, publisher Publisher, batchSize int) *Relay {
+	owner := strconv.FormatInt(time.Now().UnixNano(), 16)
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err == nil {
+		owner = hex.EncodeToString(b)
+	}
+
+	return &Relay{
+		Repo:      repo,
+		Publisher: publisher,
+		Owner:     owner,
+		BatchSize: batchSize,
+		Lease:     time.Minute,
+	}
+}
+
+// Run relays the events every interval until ctx is done
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var purged time.Time
+	for {
+		if r.Retention > 0 && time.Since(purged) >= purgeInterval {
+			purged = time.Now()
+			if _, err := r.Purge(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "purging published domain events", slog.String("error", err.Error()))
+			}
+		}
+
+		// a full batch suggests more events are waiting, so they are relayed without waiting
+		n, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "relaying domain events", slog.String("error", err.Error()))
+		}
+		if err == nil && n == r.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce claims a batch of unpublished events and publishes them, it returns the number
+// of events published. When the publisher fails the events are left claimed, and are relayed
+// again once their lease expired.
+func (r *Relay) RelayOnce(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "outbox.RelayOnce")
+	defer func() { tracing.End(span, err) }()
+
+	events, err := r.Repo.ClaimEvents(ctx, r.Owner, time.Now().UTC(), r.Lease, r.BatchSize)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	if err := r.Publisher.Publish(ctx, events); err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+		metrics.OutboxEvents.WithLabelValues(e.Type).Inc()
+	}
+	if err := r.Repo.MarkPublished(ctx, ids, time.Now().UTC()); err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
+
+// Purge deletes the events published more than Retention ago, purgeBatch at a time, and
+// returns the number of events deleted
+func (r *Relay) Purge(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "outbox.Purge")
+	defer func() { tracing.End(span, err) }()
+
+	if r.Retention <= 0 {
+		return 0, nil
+	}
+
+	before := time.Now().UTC().Add(-r.Retention)
+	var total int64
+	for {
+		n, err := r.Repo.PurgePublished(ctx, before, purgeBatch)
+		total += n
+		if err != nil || n < purgeBatch {
+			return total, err
+		}
+	}
+}
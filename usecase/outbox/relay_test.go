@@ -0,0 +1,121 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/usecase/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var recorded = []entity.DomainEvent{
+	{ID: 1, Type: entity.EventCurrencyCreated, AggregateType: "currency", AggregateID: 3, Payload: []byte(`{"id":3}`)},
+	{ID: 2, Type: entity.EventRateUpdated, AggregateType: "conversion", AggregateID: 4, TenantID: "acme", Payload: []byte(`{"id":4}`)},
+}
+
+// relayRepo returns an outbox handing the recorded events to the relay
+func relayRepo() *mocks.OutboxRepo {
+	repo := new(mocks.OutboxRepo)
+	repo.On("ClaimEvents", mock.Anything, mock.Anything, mock.Anything, time.Minute, 10).Return(recorded, nil)
+	repo.On("MarkPublished", mock.Anything, []int64{1, 2}, mock.Anything).Return(nil)
+
+	return repo
+}
+
+func TestRelayMemory(t *testing.T) {
+	repo := relayRepo()
+	publisher := &outbox.MemoryPublisher{}
+
+	n, err := outbox.NewRelay(repo, publisher, 10).RelayOnce(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, recorded, publisher.Events())
+	repo.AssertCalled(t, "MarkPublished", mock.Anything, []int64{1, 2}, mock.Anything)
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(ctx context.Context, events []entity.DomainEvent) error {
+	return errors.New("broker down")
+}
+
+func TestRelayPublishFailed(t *testing.T) {
+	repo := relayRepo()
+	publisher := &outbox.MemoryPublisher{}
+
+	n, err := outbox.NewRelay(repo, outbox.Publishers{publisher, failingPublisher{}}, 10).RelayOnce(context.TODO())
+	assert.EqualError(t, err, "broker down")
+	assert.Zero(t, n)
+	assert.Equal(t, recorded, publisher.Events(), "the publishers before the failing one are sent the events")
+	repo.AssertNotCalled(t, "MarkPublished", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRelayEmpty(t *testing.T) {
+	repo := new(mocks.OutboxRepo)
+	repo.On("ClaimEvents", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]entity.DomainEvent{}, nil)
+
+	n, err := outbox.NewRelay(repo, failingPublisher{}, 10).RelayOnce(context.TODO())
+	assert.NoError(t, err, "the publisher is not called without events")
+	assert.Zero(t, n)
+}
+
+func TestRelayFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	publisher, err := outbox.NewFilePublisher(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	repo := relayRepo()
+	relay := outbox.NewRelay(repo, publisher, 10)
+	for i := 0; i < 2; i++ {
+		_, err = relay.RelayOnce(context.TODO())
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, publisher.Close())
+
+	f, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	var lines []entity.DomainEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e entity.DomainEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		lines = append(lines, e)
+	}
+	assert.Equal(t, append(recorded, recorded...), lines, "the events are appended one per line")
+}
+
+func TestRelayPurge(t *testing.T) {
+	repo := new(mocks.OutboxRepo)
+	// a full batch is followed by another one, until fewer events are left
+	repo.On("PurgePublished", mock.Anything, mock.Anything, 1000).Return(int64(1000), nil).Once()
+	repo.On("PurgePublished", mock.Anything, mock.Anything, 1000).Return(int64(20), nil).Once()
+
+	relay := outbox.NewRelay(repo, &outbox.MemoryPublisher{}, 10)
+	n, err := relay.Purge(context.TODO())
+	assert.NoError(t, err)
+	assert.Zero(t, n, "the events are kept without a retention")
+	repo.AssertNotCalled(t, "PurgePublished", mock.Anything, mock.Anything, mock.Anything)
+
+	relay.Retention = time.Hour
+	n, err = relay.Purge(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1020), n)
+	repo.AssertNumberOfCalls(t, "PurgePublished", 2)
+	before := repo.Calls[0].Arguments.Get(1).(time.Time)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Second)
+}
@@ -0,0 +1,181 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/conversion_quota"
+)
+
+// DefaultTTL is how long a quote is honored when Provider.TTL is not set
+const DefaultTTL = 30 * time.Second
+
+// usecase
+type QuoteUsecase interface {
+	CreateQuote(ctx context.Context, eq *entity.Quote) error
+	GetQuote(ctx context.Context, id int64) (*entity.Quote, error)
+	ExecuteQuote(ctx context.Context, id int64) (*entity.Quote, error)
+}
+
+// Provider holds the dependencies of the service. Executing a quote marks it executed and
+// records its conversion in one transaction of Tx, or one statement after the other when Tx is nil.
+type Provider struct {
+	Repo                  repository.QuoteRepo
+	ConversionRepo        repository.ConversionRepo
+	ConvertCurrenciesRepo repository.ConvertCurrenciesRepo
+	Tx                    repository.Transactor
+	Quota                 conversion_quota.ConversionQuotaUsecase
+	TTL                   time.Duration
+}
+
+//Service quote usecase
+type Service struct {
+	*Provider
+}
+
+//NewService create new service
+func NewService(prvd *Provider) QuoteUsecase {
+	if prvd.TTL <= 0 {
+		prvd.TTL = DefaultTTL
+	}
+	return &Service{prvd}
+}
+
+func (s *Service) CreateQuote(ctx context.Context, eq *entity.Quote) (err error) {
+	ctx, span := tracing.Start(ctx, "quote.CreateQuote")
+	defer func() { tracing.End(span, err) }()
+
+	if eq.Amount <= 0 {
+		return fmt.Errorf("Bad Request: amount must be greater than zero")
+	}
+
+	params := request.ConversionParameter{
+		Limit:          10,
+		Offset:         0,
+		CurrencyIDFrom: eq.CurrencyIDFrom,
+		CurrencyIDTo:   eq.CurrencyIDTo,
+	}
+	conversions, total, err := s.ConversionRepo.GetConversions(ctx, &params)
+	if err != nil {
+		return err
+	}
+
+	if total == 0 {
+		return fmt.Errorf("Not Found")
+	}
+
+	eq.ConversionID = conversions[0].ID
+	if conversions[0].CurrencyIDFrom == eq.CurrencyIDFrom && conversions[0].CurrencyIDTo == eq.CurrencyIDTo {
+		eq.Rate = conversions[0].Rate
+	} else {
+		eq.Rate = 1 / conversions[0].Rate
+	}
+	eq.Result = eq.Amount * eq.Rate
+	eq.TenantID = request.TenantID(ctx)
+	eq.ClientID = request.ClientID(ctx)
+
+	now := time.Now().UTC()
+	eq.ExpiresAt = now.Add(s.TTL)
+	eq.ExecutedAt = nil
+	eq.CreatedAt = now
+	eq.UpdatedAt = now
+
+	return s.Repo.CreateQuote(ctx, eq)
+}
+
+func (s *Service) GetQuote(ctx context.Context, id int64) (_ *entity.Quote, err error) {
+	ctx, span := tracing.Start(ctx, "quote.GetQuote")
+	defer func() { tracing.End(span, err) }()
+
+	return s.ownQuote(ctx, id)
+}
+
+// ownQuote returns the quote id given to the client of ctx, the quotes of other clients are not
+// found. Client ids are only unique within a tenant, so the tenant has to match too.
+func (s *Service) ownQuote(ctx context.Context, id int64) (*entity.Quote, error) {
+	eq, err := s.Repo.GetQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if eq.TenantID != request.TenantID(ctx) || eq.ClientID != request.ClientID(ctx) {
+		return nil, fmt.Errorf("Not Found")
+	}
+	return eq, nil
+}
+
+func (s *Service) ExecuteQuote(ctx context.Context, id int64) (_ *entity.Quote, err error) {
+	ctx, span := tracing.Start(ctx, "quote.ExecuteQuote")
+	defer func() { tracing.End(span, err) }()
+
+	eq, err := s.ownQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if eq.ExecutedAt != nil {
+		return nil, fmt.Errorf("Conflict: quote has already been executed")
+	}
+
+	now := time.Now().UTC()
+	if !now.Before(eq.ExpiresAt) {
+		return nil, fmt.Errorf("Gone: quote has expired")
+	}
+
+	if s.Quota != nil {
+		if err := s.Quota.ConsumeConversion(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	convert := entity.ConvertCurrencies{
+		ConversionID:   eq.ConversionID,
+		QuoteID:        eq.ID,
+		CurrencyIDFrom: eq.CurrencyIDFrom,
+		CurrencyIDTo:   eq.CurrencyIDTo,
+		Amount:         eq.Amount,
+		Rate:           eq.Rate,
+		Result:         eq.Result,
+		ClientID:       request.ClientID(ctx),
+		IdempotencyKey: request.IdempotencyKey(ctx),
+		CreatedAt:      now,
+	}
+
+	// the quote is only spent when its conversion is recorded
+	err = s.withinTx(ctx, func(ctx context.Context) error {
+		if err := s.Repo.ExecuteQuote(ctx, id, now); err != nil {
+			return err
+		}
+		return s.ConvertCurrenciesRepo.CreateConvertCurrencies(ctx, &convert)
+	})
+	if err != nil {
+		if s.Quota != nil {
+			if err := s.Quota.ReleaseConversion(ctx); err != nil {
+				slog.WarnContext(ctx, "releasing conversion quota", slog.String("error", err.Error()))
+			}
+		}
+		return nil, err
+	}
+
+	eq.ExecutedAt = &now
+	eq.UpdatedAt = now
+
+	metrics.Conversions.WithLabelValues(strconv.FormatInt(eq.CurrencyIDFrom, 10), strconv.FormatInt(eq.CurrencyIDTo, 10)).Inc()
+	return eq, nil
+}
+
+// withinTx runs fn in a transaction of Tx, if any
+func (s *Service) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.Tx == nil {
+		return fn(ctx)
+	}
+	return s.Tx.WithinTx(ctx, fn)
+}
@@ -0,0 +1,246 @@
+package quote_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/quote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockProvider struct {
+	Repo                  *mocks.QuoteRepo
+	ConversionRepo        *mocks.ConversionRepo
+	ConvertCurrenciesRepo *mocks.ConvertCurrenciesRepo
+}
+
+func createService(p *quote.Provider) quote.QuoteUsecase {
+	return quote.NewService(p)
+}
+
+func provider() mockProvider {
+	return mockProvider{
+		Repo:                  new(mocks.QuoteRepo),
+		ConversionRepo:        new(mocks.ConversionRepo),
+		ConvertCurrenciesRepo: new(mocks.ConvertCurrenciesRepo),
+	}
+}
+
+func sampleConversion() entity.Conversion {
+	now := time.Now()
+	return entity.Conversion{
+		ID:             1,
+		CurrencyIDFrom: 1,
+		CurrencyIDTo:   2,
+		Rate:           4,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+func TestCreateQuote(t *testing.T) {
+	ap := provider()
+	singleConversion := sampleConversion()
+
+	ap.ConversionRepo.On("GetConversions", mock.Anything, mock.Anything).Return([]entity.Conversion{singleConversion}, int64(1), nil)
+	ap.Repo.On("CreateQuote", mock.Anything, mock.Anything).Return(nil)
+
+	tests := []struct {
+		name       string
+		data       entity.Quote
+		wantRate   float64
+		wantResult float64
+		IsError    bool
+	}{
+		{
+			name:       "direct pair",
+			data:       entity.Quote{CurrencyIDFrom: 1, CurrencyIDTo: 2, Amount: 10},
+			wantRate:   4,
+			wantResult: 40,
+		},
+		{
+			name:       "inverse pair",
+			data:       entity.Quote{CurrencyIDFrom: 2, CurrencyIDTo: 1, Amount: 10},
+			wantRate:   0.25,
+			wantResult: 2.5,
+		},
+		{
+			name:    "invalid amount",
+			data:    entity.Quote{CurrencyIDFrom: 1, CurrencyIDTo: 2},
+			IsError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := createService(&quote.Provider{Repo: ap.Repo, ConversionRepo: ap.ConversionRepo, TTL: time.Minute})
+
+			err := u.CreateQuote(request.WithClientID(request.WithTenantID(context.TODO(), "acme"), "pricing"), &tt.data)
+			if !assert.Equal(t, tt.IsError, err != nil) || err != nil {
+				return
+			}
+
+			assert.Equal(t, "acme", tt.data.TenantID)
+			assert.Equal(t, "pricing", tt.data.ClientID)
+			assert.Equal(t, singleConversion.ID, tt.data.ConversionID)
+			assert.Equal(t, tt.wantRate, tt.data.Rate)
+			assert.Equal(t, tt.wantResult, tt.data.Result)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), tt.data.ExpiresAt, time.Second)
+		})
+	}
+}
+
+func TestExecuteQuote(t *testing.T) {
+	executedAt := time.Now().UTC().Add(-time.Second)
+
+	tests := []struct {
+		name    string
+		quote   entity.Quote
+		IsError bool
+	}{
+		{
+			name:    "success",
+			quote:   entity.Quote{ID: 1, ExpiresAt: time.Now().UTC().Add(time.Minute)},
+			IsError: false,
+		},
+		{
+			name:    "expired",
+			quote:   entity.Quote{ID: 1, ExpiresAt: time.Now().UTC().Add(-time.Minute)},
+			IsError: true,
+		},
+		{
+			name:    "already executed",
+			quote:   entity.Quote{ID: 1, ExpiresAt: time.Now().UTC().Add(time.Minute), ExecutedAt: &executedAt},
+			IsError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := provider()
+			ap.Repo.On("GetQuote", mock.Anything, tt.quote.ID).Return(&tt.quote, nil)
+			ap.Repo.On("ExecuteQuote", mock.Anything, tt.quote.ID, mock.AnythingOfType("time.Time")).Return(nil)
+			ap.ConvertCurrenciesRepo.On("CreateConvertCurrencies", mock.Anything, mock.Anything).Return(nil)
+
+			u := createService(&quote.Provider{Repo: ap.Repo, ConversionRepo: ap.ConversionRepo, ConvertCurrenciesRepo: ap.ConvertCurrenciesRepo})
+
+			got, err := u.ExecuteQuote(context.TODO(), tt.quote.ID)
+			if !assert.Equal(t, tt.IsError, err != nil) {
+				t.Error("Something wrong")
+			}
+
+			if err == nil {
+				assert.NotNil(t, got.ExecutedAt)
+				ap.Repo.AssertCalled(t, "ExecuteQuote", mock.Anything, tt.quote.ID, mock.AnythingOfType("time.Time"))
+				ap.ConvertCurrenciesRepo.AssertCalled(t, "CreateConvertCurrencies", mock.Anything, mock.Anything)
+			} else {
+				ap.Repo.AssertNotCalled(t, "ExecuteQuote", mock.Anything, mock.Anything, mock.Anything)
+				ap.ConvertCurrenciesRepo.AssertNotCalled(t, "CreateConvertCurrencies", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestExecuteQuoteOfAnotherClient(t *testing.T) {
+	ap := provider()
+	q := entity.Quote{ID: 1, ClientID: "pricing", ExpiresAt: time.Now().UTC().Add(time.Minute)}
+	ap.Repo.On("GetQuote", mock.Anything, int64(1)).Return(&q, nil)
+
+	u := createService(&quote.Provider{Repo: ap.Repo, ConversionRepo: ap.ConversionRepo, ConvertCurrenciesRepo: ap.ConvertCurrenciesRepo})
+
+	_, err := u.GetQuote(request.WithClientID(context.TODO(), "billing"), 1)
+	assert.EqualError(t, err, "Not Found")
+	_, err = u.ExecuteQuote(request.WithClientID(context.TODO(), "billing"), 1)
+	assert.EqualError(t, err, "Not Found")
+	ap.Repo.AssertNotCalled(t, "ExecuteQuote", mock.Anything, mock.Anything, mock.Anything)
+
+	_, err = u.GetQuote(request.WithClientID(context.TODO(), "pricing"), 1)
+	assert.NoError(t, err)
+}
+
+func TestExecuteQuoteOfAnotherTenant(t *testing.T) {
+	ap := provider()
+	q := entity.Quote{ID: 1, TenantID: "acme", ClientID: "pricing", ExpiresAt: time.Now().UTC().Add(time.Minute)}
+	ap.Repo.On("GetQuote", mock.Anything, int64(1)).Return(&q, nil)
+
+	u := createService(&quote.Provider{Repo: ap.Repo, ConversionRepo: ap.ConversionRepo, ConvertCurrenciesRepo: ap.ConvertCurrenciesRepo})
+
+	// the client pricing of globex is not the client the quote was given to
+	globex := request.WithClientID(request.WithTenantID(context.TODO(), "globex"), "pricing")
+	_, err := u.GetQuote(globex, 1)
+	assert.EqualError(t, err, "Not Found")
+	_, err = u.ExecuteQuote(globex, 1)
+	assert.EqualError(t, err, "Not Found")
+	ap.Repo.AssertNotCalled(t, "ExecuteQuote", mock.Anything, mock.Anything, mock.Anything)
+
+	_, err = u.GetQuote(request.WithClientID(request.WithTenantID(context.TODO(), "acme"), "pricing"), 1)
+	assert.NoError(t, err)
+}
+
+func TestExecuteQuoteWithinTx(t *testing.T) {
+	quoteColumns := []string{"id", "tenant_id", "conversion_id", "currency_id_from", "currency_id_to", "rate", "amount", "result", "client_id", "expires_at", "executed_at", "updated_at", "created_at"}
+
+	tests := []struct {
+		name      string
+		insertErr error
+	}{
+		{name: "committed"},
+		{name: "conversion not recorded", insertErr: errors.New("connection lost")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, sqlMock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			now := time.Now().UTC()
+			sqlMock.ExpectQuery("FROM quotes WHERE id = 1").
+				WillReturnRows(sqlmock.NewRows(quoteColumns).AddRow(1, "", 3, 1, 2, 4.0, 10.0, 40.0, "pricing", now.Add(time.Minute), nil, now, now))
+
+			// the quote is marked executed and its conversion recorded in one transaction
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectExec("^UPDATE quotes").WillReturnResult(sqlmock.NewResult(0, 1))
+			insert := sqlMock.ExpectExec("^INSERT INTO convert_currencies")
+			if tt.insertErr == nil {
+				insert.WillReturnResult(sqlmock.NewResult(5, 1))
+				sqlMock.ExpectExec("^INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(1, 1))
+				sqlMock.ExpectCommit()
+			} else {
+				insert.WillReturnError(tt.insertErr)
+				sqlMock.ExpectRollback()
+			}
+
+			quota := new(mocks.ConversionQuotaUsecase)
+			quota.On("ConsumeConversion", mock.Anything).Return(nil)
+			quota.On("ReleaseConversion", mock.Anything).Return(nil)
+
+			u := createService(&quote.Provider{
+				Repo:                  repository.NewMysqlQuote(db),
+				ConvertCurrenciesRepo: repository.NewMysqlConvertCurrencies(db),
+				Tx:                    repository.NewMysqlTransactor(db),
+				Quota:                 quota,
+			})
+
+			got, err := u.ExecuteQuote(request.WithClientID(context.TODO(), "pricing"), 1)
+			if tt.insertErr != nil {
+				assert.Equal(t, tt.insertErr, err)
+				quota.AssertCalled(t, "ReleaseConversion", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got.ExecutedAt)
+				quota.AssertNotCalled(t, "ReleaseConversion", mock.Anything)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
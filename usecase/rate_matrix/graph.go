@@ -0,0 +1,88 @@
+package rate_matrix
+
+import (
+	"sort"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+)
+
+// edge is a pair of currencies, from the first to the second
+type edge struct {
+	from, to int64
+}
+
+// rateGraph links the currencies having a rate between them, in either direction
+type rateGraph struct {
+	rates     map[edge]float64
+	neighbors map[int64][]int64
+}
+
+func newRateGraph(conversions []entity.Conversion) *rateGraph {
+	g := &rateGraph{rates: map[edge]float64{}, neighbors: map[int64][]int64{}}
+
+	linked := map[edge]bool{}
+	link := func(a, b int64) {
+		if !linked[edge{a, b}] {
+			linked[edge{a, b}] = true
+			g.neighbors[a] = append(g.neighbors[a], b)
+		}
+	}
+	for _, c := range conversions {
+		// a rate that cannot be inverted is left out
+		if c.Rate <= 0 || c.CurrencyIDFrom == c.CurrencyIDTo {
+			continue
+		}
+		if _, ok := g.rates[edge{c.CurrencyIDFrom, c.CurrencyIDTo}]; !ok {
+			g.rates[edge{c.CurrencyIDFrom, c.CurrencyIDTo}] = c.Rate
+		}
+		link(c.CurrencyIDFrom, c.CurrencyIDTo)
+		link(c.CurrencyIDTo, c.CurrencyIDFrom)
+	}
+
+	// the paths found do not depend on the order of the conversions
+	for _, ids := range g.neighbors {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+	return g
+}
+
+// step returns the rate converting from to the neighbor to, the stored rate or its inverse
+func (g *rateGraph) step(from, to int64) float64 {
+	if r, ok := g.rates[edge{from, to}]; ok {
+		return r
+	}
+	return 1 / g.rates[edge{to, from}]
+}
+
+// shortestPaths returns the currencies reachable from source with the path of fewest steps
+// to each of them, the currencies after source up to and including the target
+func (g *rateGraph) shortestPaths(source int64) map[int64][]int64 {
+	paths := map[int64][]int64{source: {}}
+	queue := []int64{source}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range g.neighbors[current] {
+			if _, ok := paths[next]; ok {
+				continue
+			}
+			path := make([]int64, len(paths[current]), len(paths[current])+1)
+			copy(path, paths[current])
+			paths[next] = append(path, next)
+			queue = append(queue, next)
+		}
+	}
+
+	delete(paths, source)
+	return paths
+}
+
+// rate returns the rate converting from along path
+func (g *rateGraph) rate(from int64, path []int64) float64 {
+	rate := 1.0
+	for _, to := range path {
+		rate *= g.step(from, to)
+		from = to
+	}
+	return rate
+}
@@ -0,0 +1,208 @@
+package rate_matrix
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+// MaxCurrencies is the largest number of currencies of a matrix
+const MaxCurrencies = 50
+
+// pageSize is the number of currencies or conversions read at a time
+const pageSize = 500
+
+// usecase
+type RateMatrixUsecase interface {
+	GetRateMatrix(ctx context.Context, p *request.RateMatrixParameter) (*entity.RateMatrix, error)
+}
+
+// Provider holds the dependencies of the service. The currencies and rates of a matrix are
+// read in one transaction of Tx, so its pages come from one snapshot of the primary, or one
+// page after the other when Tx is nil.
+type Provider struct {
+	Repo         repository.ConversionRepo
+	CurrencyRepo repository.CurrencyRepo
+	Tx           repository.Transactor
+}
+
+// Service rate matrix usecase
+type Service struct {
+	*Provider
+}
+
+// NewService create new service
+func NewService(prvd *Provider) RateMatrixUsecase {
+	return &Service{prvd}
+}
+
+// GetRateMatrix returns the cross rates of the base and the currencies of p, from the rates the
+// caller sees. A pair without a stored rate takes the inverse of the opposite rate, or else is
+// derived through the fewest other currencies.
+func (s *Service) GetRateMatrix(ctx context.Context, p *request.RateMatrixParameter) (_ *entity.RateMatrix, err error) {
+	ctx, span := tracing.Start(ctx, "rate_matrix.GetRateMatrix")
+	defer func() { tracing.End(span, err) }()
+
+	if strings.TrimSpace(p.Base) == "" {
+		return nil, fmt.Errorf("base cannot be null")
+	}
+
+	var currencies []entity.Currency
+	var conversions []entity.Conversion
+	err = s.withinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if currencies, err = s.currencies(ctx); err != nil {
+			return err
+		}
+		conversions, err = s.conversions(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := selectCurrencies(ctx, currencies, p)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[int64]string{}
+	for _, c := range currencies {
+		names[c.ID] = c.Name
+	}
+	g := newRateGraph(conversions)
+
+	matrix := &entity.RateMatrix{Base: selected[0].Name}
+	for _, from := range selected {
+		matrix.Currencies = append(matrix.Currencies, from.Name)
+
+		paths := g.shortestPaths(from.ID)
+		row := make([]entity.RateCell, 0, len(selected))
+		for _, to := range selected {
+			cell := entity.RateCell{From: from.Name, To: to.Name}
+			switch path, found := paths[to.ID]; {
+			case from.ID == to.ID:
+				cell.Rate, cell.Type = 1, entity.RateIdentity
+			case g.rates[edge{from.ID, to.ID}] > 0:
+				cell.Rate, cell.Type = g.rates[edge{from.ID, to.ID}], entity.RateDirect
+			case g.rates[edge{to.ID, from.ID}] > 0:
+				cell.Rate, cell.Type = 1/g.rates[edge{to.ID, from.ID}], entity.RateInverse
+			case found:
+				cell.Rate, cell.Type = g.rate(from.ID, path), entity.RateDerived
+				for _, id := range path[:len(path)-1] {
+					cell.Via = append(cell.Via, names[id])
+				}
+			default:
+				cell.Type = entity.RateUnavailable
+			}
+			row = append(row, cell)
+		}
+		matrix.Rates = append(matrix.Rates, row)
+	}
+
+	return matrix, nil
+}
+
+func (s *Service) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.Tx == nil {
+		return fn(ctx)
+	}
+	return s.Tx.WithinTx(ctx, fn)
+}
+
+// currencies returns every currency the caller sees, by id
+func (s *Service) currencies(ctx context.Context) ([]entity.Currency, error) {
+	var all []entity.Currency
+	for {
+		page, total, err := s.CurrencyRepo.GetCurrencies(ctx, &request.CurrencyParameter{Limit: pageSize, Offset: len(all)})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < pageSize || int64(len(all)) >= total {
+			break
+		}
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	return all, nil
+}
+
+// conversions returns every rate the caller sees, by id
+func (s *Service) conversions(ctx context.Context) ([]entity.Conversion, error) {
+	var all []entity.Conversion
+	for {
+		page, total, err := s.Repo.GetConversions(ctx, &request.ConversionParameter{Limit: pageSize, Offset: len(all)})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < pageSize || int64(len(all)) >= total {
+			break
+		}
+	}
+
+	return all, nil
+}
+
+// selectCurrencies returns the base followed by the currencies of p, or by every other
+// currency when p has none. A currency is given by its id or its name, the caller's own
+// currency is preferred to a global one of the same name.
+func selectCurrencies(ctx context.Context, currencies []entity.Currency, p *request.RateMatrixParameter) ([]entity.Currency, error) {
+	find := func(ref string) (entity.Currency, error) {
+		ref = strings.TrimSpace(ref)
+		id, err := strconv.ParseInt(ref, 10, 64)
+
+		var match *entity.Currency
+		for i, c := range currencies {
+			if (err == nil && c.ID == id) || (err != nil && strings.EqualFold(c.Name, ref)) {
+				if match == nil || c.TenantID == request.TenantID(ctx) {
+					match = &currencies[i]
+				}
+			}
+		}
+		if match == nil {
+			return entity.Currency{}, fmt.Errorf("Bad Request: unknown currency %q", ref)
+		}
+		return *match, nil
+	}
+
+	base, err := find(p.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []entity.Currency{base}
+	seen := map[int64]bool{base.ID: true}
+	add := func(c entity.Currency) {
+		if !seen[c.ID] {
+			seen[c.ID] = true
+			selected = append(selected, c)
+		}
+	}
+
+	if len(p.Currencies) == 0 {
+		for _, c := range currencies {
+			add(c)
+		}
+	}
+	for _, ref := range p.Currencies {
+		c, err := find(ref)
+		if err != nil {
+			return nil, err
+		}
+		add(c)
+	}
+
+	if len(selected) > MaxCurrencies {
+		return nil, fmt.Errorf("Bad Request: a rate matrix has at most %d currencies, got %d", MaxCurrencies, len(selected))
+	}
+	return selected, nil
+}
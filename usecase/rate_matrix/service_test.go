@@ -0,0 +1,142 @@
+package rate_matrix_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/repository"
+	"github.com/rbpermadi/whim_assignment/usecase/rate_matrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newService() rate_matrix.RateMatrixUsecase {
+	currencyRepo := new(mocks.CurrencyRepo)
+	currencyRepo.On("GetCurrencies", mock.Anything, mock.Anything).Return([]entity.Currency{
+		{ID: 5, Name: "JPY"},
+		{ID: 1, Name: "IDR"},
+		{ID: 2, Name: "USD"},
+		{ID: 3, Name: "EUR"},
+		{ID: 4, Name: "SGD"},
+		{ID: 6, TenantID: "acme", Name: "usd"},
+	}, int64(6), nil)
+
+	repo := new(mocks.ConversionRepo)
+	repo.On("GetConversions", mock.Anything, mock.Anything).Return([]entity.Conversion{
+		{ID: 1, CurrencyIDFrom: 2, CurrencyIDTo: 1, Rate: 15000},
+		{ID: 2, CurrencyIDFrom: 2, CurrencyIDTo: 3, Rate: 0.9},
+		{ID: 3, CurrencyIDFrom: 3, CurrencyIDTo: 4, Rate: 1.5},
+	}, int64(3), nil)
+
+	return rate_matrix.NewService(&rate_matrix.Provider{Repo: repo, CurrencyRepo: currencyRepo})
+}
+
+func TestGetRateMatrix(t *testing.T) {
+	u := newService()
+
+	matrix, err := u.GetRateMatrix(context.TODO(), &request.RateMatrixParameter{Base: "IDR", Currencies: []string{"USD", "3", "SGD", "JPY", "idr"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "IDR", matrix.Base)
+	assert.Equal(t, []string{"IDR", "USD", "EUR", "SGD", "JPY"}, matrix.Currencies, "the base comes first and is not repeated")
+	assert.Len(t, matrix.Rates, 5)
+
+	cell := func(from, to int) entity.RateCell {
+		c := matrix.Rates[from][to]
+		assert.Equal(t, matrix.Currencies[from], c.From)
+		assert.Equal(t, matrix.Currencies[to], c.To)
+		return c
+	}
+
+	assert.Equal(t, entity.RateCell{From: "IDR", To: "IDR", Rate: 1, Type: entity.RateIdentity}, cell(0, 0))
+	assert.Equal(t, entity.RateCell{From: "USD", To: "IDR", Rate: 15000, Type: entity.RateDirect}, cell(1, 0))
+	assert.Equal(t, entity.RateCell{From: "IDR", To: "USD", Rate: 1.0 / 15000, Type: entity.RateInverse}, cell(0, 1))
+
+	idrToSGD := cell(0, 3)
+	assert.Equal(t, entity.RateDerived, idrToSGD.Type)
+	assert.Equal(t, []string{"USD", "EUR"}, idrToSGD.Via)
+	assert.InDelta(t, 0.9*1.5/15000, idrToSGD.Rate, 1e-12)
+
+	eurToIDR := cell(2, 0)
+	assert.Equal(t, entity.RateDerived, eurToIDR.Type)
+	assert.Equal(t, []string{"USD"}, eurToIDR.Via)
+	assert.InDelta(t, 15000/0.9, eurToIDR.Rate, 1e-6)
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, entity.RateUnavailable, cell(i, 4).Type, "no rate links JPY")
+		assert.Zero(t, cell(i, 4).Rate)
+	}
+	assert.Equal(t, entity.RateIdentity, cell(4, 4).Type)
+}
+
+func TestGetRateMatrixEveryCurrency(t *testing.T) {
+	u := newService()
+
+	matrix, err := u.GetRateMatrix(request.WithTenantID(context.TODO(), "acme"), &request.RateMatrixParameter{Base: "USD"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"usd", "IDR", "USD", "EUR", "SGD", "JPY"}, matrix.Currencies,
+		"the tenant's own currency is preferred to a global one of the same name")
+	assert.Equal(t, entity.RateUnavailable, matrix.Rates[0][1].Type)
+}
+
+func TestGetRateMatrixInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       request.RateMatrixParameter
+		wantErr string
+	}{
+		{name: "no base", p: request.RateMatrixParameter{Currencies: []string{"USD"}}, wantErr: "base cannot be null"},
+		{name: "unknown base", p: request.RateMatrixParameter{Base: "XXX"}, wantErr: `Bad Request: unknown currency "XXX"`},
+		{name: "unknown currency", p: request.RateMatrixParameter{Base: "IDR", Currencies: []string{"USD", "99"}}, wantErr: `Bad Request: unknown currency "99"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newService().GetRateMatrix(context.TODO(), &tt.p)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestGetRateMatrixWithinTx(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// the currencies and the rates are read in one transaction, in the order of their ids
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("^SELECT COUNT(.+) FROM currencies").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(2))
+	sqlMock.ExpectQuery("^SELECT id(.+) FROM currencies(.*) ORDER BY id LIMIT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name", "version", "updated_at", "created_at"}).
+			AddRow(1, "", "IDR", 1, time.Time{}, time.Time{}).
+			AddRow(2, "", "USD", 1, time.Time{}, time.Time{}))
+	sqlMock.ExpectQuery("^SELECT COUNT(.+) FROM conversions").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1))
+	sqlMock.ExpectQuery("^SELECT id(.+) FROM conversions(.*) ORDER BY id LIMIT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "currency_id_from", "currency_id_to", "rate", "version", "updated_at", "created_at"}).
+			AddRow(1, "", 2, 1, 15000.0, 1, time.Time{}, time.Time{}))
+	sqlMock.ExpectCommit()
+
+	u := rate_matrix.NewService(&rate_matrix.Provider{
+		Repo:         repository.NewMysqlConversion(db),
+		CurrencyRepo: repository.NewMysqlCurrency(db),
+		Tx:           repository.NewMysqlTransactor(db),
+	})
+
+	matrix, err := u.GetRateMatrix(context.TODO(), &request.RateMatrixParameter{Base: "IDR"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"IDR", "USD"}, matrix.Currencies)
+	assert.Equal(t, entity.RateCell{From: "USD", To: "IDR", Rate: 15000, Type: entity.RateDirect}, matrix.Rates[1][0])
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
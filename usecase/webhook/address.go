@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errForbiddenAddress is returned for receivers on an address of the local network
+var errForbiddenAddress = errors.New("address is private, loopback or link-local")
+
+// reserved lists the special purpose ranges not covered by the methods of net.IP, such as the
+// shared address space some clouds serve their metadata on
+var reserved = []*net.IPNet{
+	cidr("0.0.0.0/8"),
+	cidr("100.64.0.0/10"),
+	cidr("192.0.0.0/24"),
+	cidr("198.18.0.0/15"),
+}
+
+func cidr(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// publicAddress tells whether deliveries may be sent to ip. Private, loopback, link-local,
+// multicast and unspecified addresses are refused, so a subscription cannot reach the services
+// of our network, like the metadata server at 169.254.169.254.
+func publicAddress(ip net.IP) bool {
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range reserved {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateURL checks the URL of a subscription is an absolute http or https URL, and not one
+// of the local network when its host is an address. Host names are checked when they are
+// dialed, once resolved.
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("Bad Request: url must be an absolute http or https URL")
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("Bad Request: url must not be on the local network")
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicAddress(ip) {
+		return fmt.Errorf("Bad Request: url must not be on the local network")
+	}
+	return nil
+}
+
+// NewClient returns the client sending deliveries, with requests timing out after timeout.
+// It only connects to public addresses, checked once the host is resolved so a name cannot
+// point it to the local network, and does not follow redirects: a redirect is a failed attempt.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicAddress(ip) {
+				return fmt.Errorf("%s: %w", host, errForbiddenAddress)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be dialed instead of the receiver
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,261 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/metrics"
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+// Headers of a delivery request
+const (
+	HeaderEvent     = "X-Whim-Event"
+	HeaderEventID   = "X-Whim-Event-Id"
+	HeaderDelivery  = "X-Whim-Delivery"
+	HeaderTimestamp = "X-Whim-Timestamp"
+	HeaderSignature = "X-Whim-Signature"
+)
+
+// Sign returns the signature of a delivery body sent at timestamp: "sha256=" followed by the
+// hex encoded HMAC-SHA256 of "<timestamp>.<body>", keyed by the subscription secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// purgeInterval is the time between two purges of the finished deliveries, and purgeBatch the
+// number of deliveries deleted at a time
+const (
+	purgeInterval = time.Minute
+	purgeBatch    = 1000
+)
+
+// Dispatcher sends the pending deliveries of the outbox. A delivery is retried with an
+// exponential backoff, starting at Backoff and capped at MaxBackoff, until a receiver answers
+// with a 2xx status or MaxAttempts attempts failed. The delivered and failed deliveries are
+// deleted once they are older than Retention, a zero Retention keeps them.
+type Dispatcher struct {
+	Repo        repository.WebhookRepo
+	Client      *http.Client
+	Owner       string
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	Retention   time.Duration
+}
+
+// NewDispatcher returns a dispatcher claiming deliveries under a random owner, sending them
+// with NewClient
+func NewDispatcher(repo repository.WebhookRepo, maxAttempts int, backoff, maxBackoff, timeout time.Duration, batchSize int) *Dispatcher {
+	owner, err := randomHex(16)
+	if err != nil {
+		owner = strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return &Dispatcher{
+		Repo:        repo,
+		Client:      NewClient(timeout),
+		Owner:       owner,
+		MaxAttempts: maxAttempts,
+		Backoff:     backoff,
+		MaxBackoff:  maxBackoff,
+		BatchSize:   batchSize,
+		// a batch is sent one delivery after the other
+		Lease: timeout*time.Duration(batchSize) + time.Minute,
+	}
+}
+
+// Run dispatches the due deliveries every interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var purged time.Time
+	for {
+		if d.Retention > 0 && time.Since(purged) >= purgeInterval {
+			purged = time.Now()
+			if _, err := d.Purge(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "purging webhook deliveries", slog.String("error", err.Error()))
+			}
+		}
+
+		// a full batch suggests more deliveries are due, so they are sent without waiting
+		n, err := d.DeliverDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "dispatching webhooks", slog.String("error", err.Error()))
+		}
+		if err == nil && n == d.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes the deliveries finished more than Retention ago, purgeBatch at a time, and
+// returns the number of deliveries deleted
+func (d *Dispatcher) Purge(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.Purge")
+	defer func() { tracing.End(span, err) }()
+
+	if d.Retention <= 0 {
+		return 0, nil
+	}
+
+	before := time.Now().UTC().Add(-d.Retention)
+	var total int64
+	for {
+		n, err := d.Repo.PurgeDeliveries(ctx, before, purgeBatch)
+		total += n
+		if err != nil || n < purgeBatch {
+			return total, err
+		}
+	}
+}
+
+// backoff returns the delay before the attempt following the given number of attempts
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.Backoff
+	for i := 1; i < attempts && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		return d.MaxBackoff
+	}
+	return delay
+}
+
+// DeliverDue claims a batch of due deliveries and attempts each of them, it returns the
+// number of deliveries claimed. Once ctx is done the remaining deliveries of the batch are
+// left to be claimed again when their lease expired.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.DeliverDue")
+	defer func() { tracing.End(span, err) }()
+
+	deliveries, err := d.Repo.ClaimDeliveries(ctx, d.Owner, time.Now().UTC(), d.Lease, d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	subs := map[int64]*entity.WebhookSubscription{}
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return len(deliveries), ctx.Err()
+		}
+
+		wd := &deliveries[i]
+		// an attempt in flight is completed and recorded even when the dispatcher is stopping
+		attemptCtx := request.WithTenantID(context.WithoutCancel(ctx), wd.TenantID)
+
+		ws, ok := subs[wd.SubscriptionID]
+		if !ok {
+			ws, err = d.Repo.GetSubscription(attemptCtx, wd.SubscriptionID)
+			if err != nil && err.Error() != "Not Found" {
+				return len(deliveries), err
+			}
+			subs[wd.SubscriptionID] = ws
+		}
+
+		d.attempt(attemptCtx, ws, wd)
+		if err := d.Repo.UpdateDelivery(attemptCtx, wd); err != nil {
+			return len(deliveries), err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// attempt sends wd to the subscription ws and records the outcome in wd
+func (d *Dispatcher) attempt(ctx context.Context, ws *entity.WebhookSubscription, wd *entity.WebhookDelivery) {
+	now := time.Now().UTC()
+	wd.UpdatedAt = now
+
+	if ws == nil {
+		wd.Status = entity.DeliveryFailed
+		wd.LastError = "subscription deleted"
+		metrics.WebhookDeliveries.WithLabelValues(wd.Status).Inc()
+		return
+	}
+
+	wd.Attempts++
+	wd.LastAttemptAt = &now
+	wd.ResponseStatus = 0
+	wd.LastError = ""
+
+	status, err := d.send(ctx, ws, wd, now)
+	wd.ResponseStatus = status
+	switch {
+	case err == nil && status >= 200 && status < 300:
+		wd.Status = entity.DeliveryDelivered
+		metrics.WebhookDeliveries.WithLabelValues(wd.Status).Inc()
+		return
+	case err != nil:
+		wd.LastError = err.Error()
+	default:
+		wd.LastError = fmt.Sprintf("unexpected response status %d", status)
+	}
+	if len(wd.LastError) > 1000 {
+		wd.LastError = wd.LastError[:1000]
+	}
+
+	if wd.Attempts >= d.MaxAttempts {
+		wd.Status = entity.DeliveryFailed
+		metrics.WebhookDeliveries.WithLabelValues(wd.Status).Inc()
+		return
+	}
+
+	wd.NextAttemptAt = now.Add(d.backoff(wd.Attempts))
+	metrics.WebhookDeliveries.WithLabelValues("retried").Inc()
+}
+
+func (d *Dispatcher) send(ctx context.Context, ws *entity.WebhookSubscription, wd *entity.WebhookDelivery, now time.Time) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.send")
+	defer func() { tracing.End(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ws.URL, bytes.NewReader(wd.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "whim-webhooks")
+	req.Header.Set(HeaderEvent, wd.EventType)
+	req.Header.Set(HeaderEventID, wd.EventID)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(wd.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(ws.Secret, timestamp, wd.Payload))
+
+	res, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// the body is drained so the connection can be reused, receivers are not expected to answer with much
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	return res.StatusCode, nil
+}
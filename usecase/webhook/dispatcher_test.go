@@ -0,0 +1,194 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/usecase/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// receiver is a webhook endpoint answering with status and recording the requests it verified
+type receiver struct {
+	status   int
+	secret   string
+	verified []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+	if r.Header.Get(webhook.HeaderSignature) != webhook.Sign(rc.secret, timestamp, body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	rc.verified = append(rc.verified, r.Header.Get(webhook.HeaderEventID))
+	w.WriteHeader(rc.status)
+}
+
+func dispatch(t *testing.T, rc *receiver, wd entity.WebhookDelivery) entity.WebhookDelivery {
+	return dispatchWith(t, rc, (*httptest.Server).Client, wd)
+}
+
+// dispatchWith delivers wd to h with the client returned by client for its server
+func dispatchWith(t *testing.T, h http.Handler, client func(*httptest.Server) *http.Client, wd entity.WebhookDelivery) entity.WebhookDelivery {
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	repo := new(mocks.WebhookRepo)
+	repo.On("ClaimDeliveries", mock.Anything, "test", mock.Anything, time.Minute, 10).Return([]entity.WebhookDelivery{wd}, nil)
+	repo.On("GetSubscription", mock.Anything, int64(1)).
+		Return(&entity.WebhookSubscription{ID: 1, URL: srv.URL, Secret: "whsec_test"}, nil)
+
+	var updated entity.WebhookDelivery
+	repo.On("UpdateDelivery", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		updated = *args.Get(1).(*entity.WebhookDelivery)
+	})
+
+	d := &webhook.Dispatcher{
+		Repo:        repo,
+		Client:      client(srv),
+		Owner:       "test",
+		MaxAttempts: 3,
+		Backoff:     time.Second,
+		MaxBackoff:  3 * time.Second,
+		BatchSize:   10,
+		Lease:       time.Minute,
+	}
+
+	n, err := d.DeliverDue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	return updated
+}
+
+func pending(attempts int) entity.WebhookDelivery {
+	return entity.WebhookDelivery{
+		ID:             5,
+		SubscriptionID: 1,
+		EventID:        "e1",
+		EventType:      "rate.updated",
+		Payload:        []byte(`{"id":"e1"}`),
+		Status:         entity.DeliveryPending,
+		Attempts:       attempts,
+	}
+}
+
+func TestDeliverSigned(t *testing.T) {
+	rc := &receiver{status: http.StatusNoContent, secret: "whsec_test"}
+
+	wd := dispatch(t, rc, pending(0))
+
+	assert.Equal(t, []string{"e1"}, rc.verified, "the receiver verified the signature")
+	assert.Equal(t, entity.DeliveryDelivered, wd.Status)
+	assert.Equal(t, 1, wd.Attempts)
+	assert.Equal(t, http.StatusNoContent, wd.ResponseStatus)
+	assert.NotNil(t, wd.LastAttemptAt)
+}
+
+func TestDeliverRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts  int
+		wantDelay time.Duration
+	}{
+		{attempts: 0, wantDelay: time.Second},
+		{attempts: 1, wantDelay: 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("after %d attempts", tt.attempts), func(t *testing.T) {
+			rc := &receiver{status: http.StatusInternalServerError, secret: "whsec_test"}
+
+			wd := dispatch(t, rc, pending(tt.attempts))
+
+			assert.Equal(t, entity.DeliveryPending, wd.Status)
+			assert.Equal(t, tt.attempts+1, wd.Attempts)
+			assert.Equal(t, http.StatusInternalServerError, wd.ResponseStatus)
+			assert.Equal(t, "unexpected response status 500", wd.LastError)
+			assert.Equal(t, tt.wantDelay, wd.NextAttemptAt.Sub(*wd.LastAttemptAt))
+		})
+	}
+}
+
+func TestDeliverGiveUp(t *testing.T) {
+	rc := &receiver{status: http.StatusBadGateway, secret: "another secret"}
+
+	wd := dispatch(t, rc, pending(2))
+
+	assert.Empty(t, rc.verified, "a signature made with another secret does not verify")
+	assert.Equal(t, entity.DeliveryFailed, wd.Status, "the last attempt failed")
+	assert.Equal(t, 3, wd.Attempts)
+	assert.Equal(t, http.StatusUnauthorized, wd.ResponseStatus)
+}
+
+func TestDeliverDeletedSubscription(t *testing.T) {
+	repo := new(mocks.WebhookRepo)
+	repo.On("ClaimDeliveries", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]entity.WebhookDelivery{pending(0)}, nil)
+	repo.On("GetSubscription", mock.Anything, int64(1)).Return((*entity.WebhookSubscription)(nil), fmt.Errorf("Not Found"))
+	repo.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(wd *entity.WebhookDelivery) bool {
+		return wd.Status == entity.DeliveryFailed && wd.Attempts == 0 && wd.LastError == "subscription deleted"
+	})).Return(nil).Once()
+
+	d := webhook.NewDispatcher(repo, 3, time.Second, time.Minute, time.Second, 10)
+	_, err := d.DeliverDue(context.TODO())
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestDeliverLocalNetworkRefused(t *testing.T) {
+	rc := &receiver{status: http.StatusNoContent, secret: "whsec_test"}
+
+	// the test server listens on the loopback address
+	wd := dispatchWith(t, rc, func(*httptest.Server) *http.Client { return webhook.NewClient(time.Second) }, pending(0))
+
+	assert.Empty(t, rc.verified, "the receiver is not dialed")
+	assert.Equal(t, entity.DeliveryPending, wd.Status)
+	assert.Equal(t, 1, wd.Attempts)
+	assert.Contains(t, wd.LastError, "private, loopback or link-local")
+}
+
+func TestDeliverRedirectNotFollowed(t *testing.T) {
+	rc := &receiver{status: http.StatusNoContent, secret: "whsec_test"}
+	mux := http.NewServeMux()
+	mux.Handle("/internal", rc)
+	mux.Handle("/", http.RedirectHandler("/internal", http.StatusFound))
+
+	wd := dispatchWith(t, mux, func(srv *httptest.Server) *http.Client {
+		// the redirect policy of the dispatcher, dialing the loopback address of the test server
+		client := webhook.NewClient(time.Second)
+		client.Transport = srv.Client().Transport
+		return client
+	}, pending(0))
+
+	assert.Empty(t, rc.verified, "the redirect is not followed")
+	assert.Equal(t, entity.DeliveryPending, wd.Status)
+	assert.Equal(t, http.StatusFound, wd.ResponseStatus)
+	assert.Equal(t, "unexpected response status 302", wd.LastError)
+}
+
+func TestPurgeDeliveries(t *testing.T) {
+	repo := new(mocks.WebhookRepo)
+	repo.On("PurgeDeliveries", mock.Anything, mock.Anything, 1000).Return(int64(0), errors.New("connection refused"))
+
+	d := &webhook.Dispatcher{Repo: repo}
+	n, err := d.Purge(context.TODO())
+	assert.NoError(t, err)
+	assert.Zero(t, n, "the deliveries are kept without a retention")
+
+	d.Retention = 24 * time.Hour
+	_, err = d.Purge(context.TODO())
+	assert.EqualError(t, err, "connection refused")
+	before := repo.Calls[0].Arguments.Get(1).(time.Time)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Second)
+}
@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/stream"
+	"github.com/rbpermadi/whim_assignment/app/tracing"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/repository"
+)
+
+// SecretPrefix starts every generated signing secret
+const SecretPrefix = "whsec_"
+
+// usecase
+type WebhookUsecase interface {
+	CreateSubscription(ctx context.Context, ws *entity.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id int64) (*entity.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context, p *request.WebhookParameter) ([]entity.WebhookSubscription, int64, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	GetDeliveries(ctx context.Context, p *request.WebhookDeliveryParameter) ([]entity.WebhookDelivery, int64, error)
+	// Publish queues the deliveries of the rate events relayed from the outbox
+	Publish(ctx context.Context, events []entity.DomainEvent) error
+}
+
+// Event is the body of a delivery, its id is the id of the event in the outbox, shared by the
+// deliveries of the same event and kept between attempts, so receivers can drop the events
+// they already handled
+type Event struct {
+	ID        string           `json:"id"`
+	Type      string           `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      stream.RateEvent `json:"data"`
+}
+
+type Provider struct {
+	Repo repository.WebhookRepo
+}
+
+//Service webhook usecase
+type Service struct {
+	*Provider
+}
+
+//NewService create new service
+func NewService(prvd *Provider) WebhookUsecase {
+	return &Service{prvd}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *Service) CreateSubscription(ctx context.Context, ws *entity.WebhookSubscription) (err error) {
+	ctx, span := tracing.Start(ctx, "webhook.CreateSubscription")
+	defer func() { tracing.End(span, err) }()
+
+	if ws.URL == "" {
+		return fmt.Errorf("url cannot be null")
+	}
+	if err := validateURL(ws.URL); err != nil {
+		return err
+	}
+	if _, err := stream.ParsePairs(ws.Pairs); err != nil {
+		return err
+	}
+
+	if ws.Secret == "" {
+		secret, err := randomHex(24)
+		if err != nil {
+			return err
+		}
+		ws.Secret = SecretPrefix + secret
+	} else if len(ws.Secret) < 16 {
+		return fmt.Errorf("Bad Request: secret must be at least 16 characters")
+	}
+
+	ws.CreatedAt = time.Now().UTC()
+	ws.UpdatedAt = ws.CreatedAt
+
+	return s.Repo.CreateSubscription(ctx, ws)
+}
+
+func (s *Service) GetSubscription(ctx context.Context, id int64) (_ *entity.WebhookSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.GetSubscription")
+	defer func() { tracing.End(span, err) }()
+
+	ws, err := s.Repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.Secret = ""
+	return ws, nil
+}
+
+func (s *Service) GetSubscriptions(ctx context.Context, p *request.WebhookParameter) (_ []entity.WebhookSubscription, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.GetSubscriptions")
+	defer func() { tracing.End(span, err) }()
+
+	list, total, err := s.Repo.GetSubscriptions(ctx, p)
+	for i := range list {
+		list[i].Secret = ""
+	}
+
+	return list, total, err
+}
+
+func (s *Service) DeleteSubscription(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "webhook.DeleteSubscription")
+	defer func() { tracing.End(span, err) }()
+
+	return s.Repo.DeleteSubscription(ctx, id, time.Now().UTC())
+}
+
+func (s *Service) GetDeliveries(ctx context.Context, p *request.WebhookDeliveryParameter) (_ []entity.WebhookDelivery, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.GetDeliveries")
+	defer func() { tracing.End(span, err) }()
+
+	switch p.Status {
+	case "", entity.DeliveryPending, entity.DeliveryDelivered, entity.DeliveryFailed:
+	default:
+		return nil, 0, fmt.Errorf("Bad Request: status must be one of pending, delivered or failed")
+	}
+
+	// the deliveries are those of the caller's tenant, kept after their subscription is deleted
+	return s.Repo.GetDeliveries(ctx, p)
+}
+
+// rateChange is the payload of the rate events of the outbox
+type rateChange struct {
+	ID             int64     `json:"id"`
+	TenantID       string    `json:"tenant_id"`
+	CurrencyIDFrom int64     `json:"currency_id_from"`
+	CurrencyIDTo   int64     `json:"currency_id_to"`
+	Rate           float64   `json:"rate"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Publish stores a pending delivery of each rate created or updated for each subscription
+// matching its tenant and pair, the dispatcher sends them. The other events are skipped.
+func (s *Service) Publish(ctx context.Context, events []entity.DomainEvent) (err error) {
+	ctx, span := tracing.Start(ctx, "webhook.Publish")
+	defer func() { tracing.End(span, err) }()
+
+	for _, de := range events {
+		if de.Type != stream.RateCreated && de.Type != stream.RateUpdated {
+			continue
+		}
+
+		var change rateChange
+		if err := json.Unmarshal(de.Payload, &change); err != nil {
+			return fmt.Errorf("event %d: %w", de.ID, err)
+		}
+		e := stream.RateEvent{
+			Type:           de.Type,
+			ConversionID:   change.ID,
+			TenantID:       change.TenantID,
+			CurrencyIDFrom: change.CurrencyIDFrom,
+			CurrencyIDTo:   change.CurrencyIDTo,
+			Rate:           change.Rate,
+			UpdatedAt:      change.UpdatedAt,
+		}
+
+		if err := s.queue(ctx, strconv.FormatInt(de.ID, 10), de.OccurredAt, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queue stores a pending delivery of the event id to each subscription matching e
+func (s *Service) queue(ctx context.Context, id string, occurredAt time.Time, e stream.RateEvent) error {
+	subs, err := s.Repo.MatchSubscriptions(ctx, e.TenantID, e.CurrencyIDFrom, e.CurrencyIDTo)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	payload, err := json.Marshal(Event{ID: id, Type: e.Type, CreatedAt: occurredAt, Data: e})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]entity.WebhookDelivery, 0, len(subs))
+	for _, ws := range subs {
+		if !matches(ws.Pairs, e) {
+			continue
+		}
+		deliveries = append(deliveries, entity.WebhookDelivery{
+			SubscriptionID: ws.ID,
+			TenantID:       ws.TenantID,
+			EventID:        id,
+			EventType:      e.Type,
+			Payload:        payload,
+			Status:         entity.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+
+	return s.Repo.CreateDeliveries(ctx, deliveries)
+}
+
+// matches tells whether the pair filter of a subscription includes the pair of e in either
+// direction, a rate being the rate of its reverse pair too. An empty filter includes every pair.
+func matches(filter string, e stream.RateEvent) bool {
+	pairs, err := stream.ParsePairs(filter)
+	if err != nil {
+		return false
+	}
+	if len(pairs) == 0 {
+		return true
+	}
+	for _, p := range pairs {
+		if (p.From == e.CurrencyIDFrom && p.To == e.CurrencyIDTo) || (p.From == e.CurrencyIDTo && p.To == e.CurrencyIDFrom) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rbpermadi/whim_assignment/app/request"
+	"github.com/rbpermadi/whim_assignment/app/stream"
+	"github.com/rbpermadi/whim_assignment/entity"
+	"github.com/rbpermadi/whim_assignment/mocks"
+	"github.com/rbpermadi/whim_assignment/usecase/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateSubscription(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    entity.WebhookSubscription
+		wantErr string
+	}{
+		{name: "success", data: entity.WebhookSubscription{URL: "https://example.com/hooks", Pairs: "1-2,1-3"}},
+		{name: "own secret", data: entity.WebhookSubscription{URL: "http://example.com/hooks", Secret: "a-long-enough-secret"}},
+		{name: "no url", data: entity.WebhookSubscription{}, wantErr: "url cannot be null"},
+		{name: "relative url", data: entity.WebhookSubscription{URL: "/hooks"}, wantErr: "Bad Request"},
+		{name: "other scheme", data: entity.WebhookSubscription{URL: "ftp://example.com"}, wantErr: "Bad Request"},
+		{name: "invalid pairs", data: entity.WebhookSubscription{URL: "https://example.com", Pairs: "1:2"}, wantErr: "Bad Request"},
+		{name: "short secret", data: entity.WebhookSubscription{URL: "https://example.com", Secret: "short"}, wantErr: "Bad Request"},
+		{name: "loopback", data: entity.WebhookSubscription{URL: "http://127.0.0.1:8080/hooks"}, wantErr: "local network"},
+		{name: "localhost", data: entity.WebhookSubscription{URL: "http://localhost/hooks"}, wantErr: "local network"},
+		{name: "metadata server", data: entity.WebhookSubscription{URL: "http://169.254.169.254/latest/meta-data"}, wantErr: "local network"},
+		{name: "private", data: entity.WebhookSubscription{URL: "https://10.0.0.1/hooks"}, wantErr: "local network"},
+		{name: "ipv6 loopback", data: entity.WebhookSubscription{URL: "http://[::1]/hooks"}, wantErr: "local network"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.WebhookRepo)
+			repo.On("CreateSubscription", mock.Anything, mock.Anything).Return(nil)
+
+			u := webhook.NewService(&webhook.Provider{Repo: repo})
+			ws := tt.data
+			err := u.CreateSubscription(context.TODO(), &ws)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "CreateSubscription", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			if tt.data.Secret == "" {
+				assert.True(t, strings.HasPrefix(ws.Secret, webhook.SecretPrefix), "a secret is generated")
+			} else {
+				assert.Equal(t, tt.data.Secret, ws.Secret)
+			}
+			assert.False(t, ws.CreatedAt.IsZero())
+		})
+	}
+}
+
+func TestGetSubscriptionsHideSecrets(t *testing.T) {
+	repo := new(mocks.WebhookRepo)
+	repo.On("GetSubscriptions", mock.Anything, mock.Anything).
+		Return([]entity.WebhookSubscription{{ID: 1, Secret: "whsec_1"}, {ID: 2, Secret: "whsec_2"}}, int64(2), nil)
+	repo.On("GetSubscription", mock.Anything, int64(1)).Return(&entity.WebhookSubscription{ID: 1, Secret: "whsec_1"}, nil)
+
+	u := webhook.NewService(&webhook.Provider{Repo: repo})
+
+	subs, total, err := u.GetSubscriptions(context.TODO(), &request.WebhookParameter{Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	for _, ws := range subs {
+		assert.Empty(t, ws.Secret)
+	}
+
+	ws, err := u.GetSubscription(context.TODO(), 1)
+	assert.NoError(t, err)
+	assert.Empty(t, ws.Secret)
+}
+
+func TestGetDeliveries(t *testing.T) {
+	repo := new(mocks.WebhookRepo)
+	repo.On("GetDeliveries", mock.Anything, mock.Anything).Return([]entity.WebhookDelivery{{ID: 3}}, int64(1), nil)
+
+	u := webhook.NewService(&webhook.Provider{Repo: repo})
+
+	deliveries, _, err := u.GetDeliveries(context.TODO(), &request.WebhookDeliveryParameter{SubscriptionID: 1, Status: "failed"})
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	// the log of a deleted subscription is listed too
+	repo.AssertNotCalled(t, "GetSubscription", mock.Anything, mock.Anything)
+
+	_, _, err = u.GetDeliveries(context.TODO(), &request.WebhookDeliveryParameter{SubscriptionID: 1, Status: "sent"})
+	assert.ErrorContains(t, err, "Bad Request")
+}
+
+func TestPublish(t *testing.T) {
+	repo := new(mocks.WebhookRepo)
+	repo.On("MatchSubscriptions", mock.Anything, "acme", int64(1), int64(2)).Return([]entity.WebhookSubscription{
+		{ID: 1, TenantID: "acme", Pairs: ""},
+		{ID: 2, TenantID: "acme", Pairs: "1-2,3-4"},
+		{ID: 3, TenantID: "acme", Pairs: "2-1"},
+		{ID: 4, TenantID: "acme", Pairs: "1-3"},
+	}, nil)
+
+	var queued []entity.WebhookDelivery
+	repo.On("CreateDeliveries", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		queued = args.Get(1).([]entity.WebhookDelivery)
+	})
+
+	u := webhook.NewService(&webhook.Provider{Repo: repo})
+
+	events := []entity.DomainEvent{
+		{ID: 7, Type: entity.EventCurrencyCreated, AggregateType: "currency", AggregateID: 1, Payload: []byte(`{"id":1}`)},
+		{ID: 8, Type: entity.EventRateUpdated, AggregateType: "conversion", AggregateID: 9, TenantID: "acme",
+			Payload: []byte(`{"id":9,"tenant_id":"acme","currency_id_from":1,"currency_id_to":2,"rate":14500,"updated_at":"2026-10-19T00:00:00Z"}`)},
+	}
+	assert.NoError(t, u.Publish(context.TODO(), events))
+
+	repo.AssertNumberOfCalls(t, "CreateDeliveries", 1)
+	if assert.Len(t, queued, 3, "the subscription to another pair is skipped") {
+		assert.Equal(t, int64(1), queued[0].SubscriptionID)
+		assert.Equal(t, int64(2), queued[1].SubscriptionID)
+		assert.Equal(t, int64(3), queued[2].SubscriptionID, "the rate of a pair is the rate of its reverse pair too")
+		assert.Equal(t, "8", queued[0].EventID, "the deliveries of an event share the id of the event in the outbox")
+		assert.Equal(t, queued[0].EventID, queued[1].EventID)
+		assert.Equal(t, entity.DeliveryPending, queued[0].Status)
+
+		var body webhook.Event
+		assert.NoError(t, json.Unmarshal(queued[0].Payload, &body))
+		assert.Equal(t, "8", body.ID)
+		assert.Equal(t, stream.RateUpdated, body.Type)
+		assert.Equal(t, stream.RateEvent{Type: stream.RateUpdated, ConversionID: 9, TenantID: "acme", CurrencyIDFrom: 1, CurrencyIDTo: 2, Rate: 14500,
+			UpdatedAt: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)}, body.Data)
+	}
+}